@@ -0,0 +1,144 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	kaytuAgent "github.com/opengovern/plugin-kubernetes-internal/plugin/kaytu-agent"
+	kaytuKubelet "github.com/opengovern/plugin-kubernetes-internal/plugin/kubelet"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/snapshot"
+	"strconv"
+	"strings"
+)
+
+// clusterConnection is everything the processors need from a single cluster.
+type clusterConnection struct {
+	kubeClient      *kaytuKubernetes.Kubernetes
+	identification  map[string]string
+	kaytuClient     *kaytuAgent.KaytuAgent
+	metricsProvider kaytuPrometheus.MetricsProvider
+}
+
+func connectCluster(ctx context.Context, command string, flags map[string]string, kubeContext *string, replay *snapshot.Snapshot) (*clusterConnection, error) {
+	var err error
+	conn := clusterConnection{}
+	if replay != nil {
+		conn.kubeClient = kaytuKubernetes.NewKubernetesFromObjects(replay.CapturedAt, replay.Objects()...)
+		conn.identification = replay.Identification
+	} else {
+		restclientConfig, kubeConfig, err := kaytuKubernetes.GetConfig(ctx, getFlagOrNil(flags, "kubeconfig"), kubeContext)
+		if err != nil {
+			return nil, err
+		}
+		conn.kubeClient, err = kaytuKubernetes.NewKubernetes(restclientConfig, kubeConfig)
+		if err != nil {
+			return nil, err
+		}
+		conn.identification = conn.kubeClient.Identify()
+	}
+
+	agentAddress := getFlagOrNil(flags, "agent-address")
+	agentDisabledStr := getFlagOrNil(flags, "agent-disabled")
+	agentDisabled := false
+	if agentDisabledStr != nil {
+		agentDisabled, err = strconv.ParseBool(*agentDisabledStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if replay != nil || command == "kubernetes-snapshot" {
+		agentDisabled = true
+	}
+	kaytuAgentCfg, err := kaytuAgent.GetConfig(ctx, agentAddress, agentDisabled, conn.kubeClient)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.kaytuClient, err = kaytuAgent.NewKaytuAgent(kaytuAgentCfg, agentDisabled)
+	if err != nil {
+		return nil, err
+	}
+
+	if conn.kaytuClient.IsEnabled() {
+		err = conn.kaytuClient.Ping(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to kaytu agent on %s due to %v", kaytuAgentCfg.Address, err)
+		}
+	}
+
+	promAddress := getFlagOrNil(flags, "prom-address")
+	promUsername := getFlagOrNil(flags, "prom-username")
+	promPassword := getFlagOrNil(flags, "prom-password")
+	promClientId := getFlagOrNil(flags, "prom-client-id")
+	promClientSecret := getFlagOrNil(flags, "prom-client-secret")
+	promTokenUrl := getFlagOrNil(flags, "prom-token-url")
+	promScopesStr := getFlagOrNil(flags, "prom-scopes")
+	var promScopes []string
+	if promScopesStr != nil {
+		promScopes = strings.Split(*promScopesStr, ",")
+	}
+
+	if replay != nil {
+		conn.metricsProvider = snapshot.NewReplay(replay)
+	} else if !conn.kaytuClient.IsEnabled() {
+		promCfg, err := kaytuPrometheus.GetConfig(ctx, promAddress, promUsername, promPassword, promClientId, promClientSecret, promTokenUrl, promScopes, getFlagOrNil(flags, "prom-query-concurrency"), getFlagOrNil(flags, "prom-bulk-namespace"), getFlagOrNil(flags, "prom-bulk-timeout"), conn.kubeClient)
+		if err != nil && !errors.Is(err, kaytuKubernetes.PrometheusNotFoundErr) {
+			return nil, err
+		}
+		if err != nil {
+			// no prometheus-compatible service was found, fall back to sampling the kubelets
+			kubeletClient, kubeletErr := kaytuKubelet.NewKubelet(ctx, conn.kubeClient)
+			if kubeletErr != nil {
+				return nil, fmt.Errorf("%v, kubelet stats fallback failed due to %v", err, kubeletErr)
+			}
+			conn.metricsProvider = kubeletClient
+		} else {
+			promCfg.Cache, err = kaytuPrometheus.GetCacheConfig(
+				getFlagOrNil(flags, "no-cache"),
+				getFlagOrNil(flags, "cache-dir"),
+				getFlagOrNil(flags, "cache-ttl"),
+				getFlagOrNil(flags, "cache-max-size"),
+			)
+			if err != nil {
+				return nil, err
+			}
+			promCfg.Cache.Identity = conn.kubeClient.ClusterID(ctx) + "\n" + promCfg.Source
+			promClient, err := kaytuPrometheus.NewPrometheus(ctx, promCfg)
+			if err != nil {
+				return nil, err
+			}
+			err = promClient.Ping(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to connect to prometheus on %s due to %v", promCfg.Address, err)
+			}
+			conn.metricsProvider = promClient
+		}
+	}
+
+	return &conn, nil
+}
+
+// getClusterContexts returns the kube contexts to analyze when more than one cluster was asked for, nil otherwise.
+func getClusterContexts(flags map[string]string) ([]string, error) {
+	if allContexts := getFlagOrNil(flags, "all-contexts"); allContexts != nil && *allContexts != "" {
+		all, err := strconv.ParseBool(*allContexts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid all-contexts value %s: %v", *allContexts, err)
+		}
+		if all {
+			return kaytuKubernetes.ListContexts(getFlagOrNil(flags, "kubeconfig"))
+		}
+	}
+
+	var contexts []string
+	if contextsStr := getFlagOrNil(flags, "contexts"); contextsStr != nil {
+		for _, c := range strings.Split(*contextsStr, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				contexts = append(contexts, c)
+			}
+		}
+	}
+	return contexts, nil
+}
@@ -0,0 +1,15 @@
+package plugin
+
+import (
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	"os"
+)
+
+// writeJSONExport writes the exported workloads as a json array, the json counterpart of the csv export.
+func writeJSONExport(path string, workloads []shared.ExportWorkload) error {
+	content, err := shared.ExportJSON(workloads)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
@@ -0,0 +1,273 @@
+package kaytu
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials/oauth"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultGrpcAddress    = "gapi.kaytu.io:443"
+	DefaultApiUrl         = "https://app.kaytu.io"
+	DefaultRequestTimeout = time.Minute * 1
+)
+
+type BackendConfig struct {
+	GrpcAddress    string        `json:"grpcAddress"`
+	ApiUrl         string        `json:"apiUrl"`
+	CAFile         string        `json:"caFile"`
+	Insecure       bool          `json:"insecure"`
+	Plaintext      bool          `json:"plaintext"`
+	Proxy          string        `json:"proxy"`
+	RequestTimeout time.Duration `json:"requestTimeout"`
+
+	// httpClient is built once so every request to the backend reuses its connections
+	httpClientOnce sync.Once
+	httpClient     *http.Client
+	httpClientErr  error
+}
+
+func GetBackendConfig(grpcAddress, apiUrl, caFile, insecureStr, plaintextStr, proxy, requestTimeout *string) (*BackendConfig, error) {
+	cfg := BackendConfig{
+		GrpcAddress:    DefaultGrpcAddress,
+		ApiUrl:         DefaultApiUrl,
+		RequestTimeout: DefaultRequestTimeout,
+	}
+
+	if grpcAddress != nil && *grpcAddress != "" {
+		cfg.GrpcAddress = *grpcAddress
+	}
+	if apiUrl != nil && *apiUrl != "" {
+		cfg.ApiUrl = strings.TrimSuffix(*apiUrl, "/")
+	}
+	if caFile != nil {
+		cfg.CAFile = *caFile
+	}
+	if proxy != nil {
+		cfg.Proxy = *proxy
+	}
+
+	var err error
+	if insecureStr != nil && *insecureStr != "" {
+		cfg.Insecure, err = strconv.ParseBool(*insecureStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid insecure value %s: %v", *insecureStr, err)
+		}
+	}
+	if plaintextStr != nil && *plaintextStr != "" {
+		cfg.Plaintext, err = strconv.ParseBool(*plaintextStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid plaintext value %s: %v", *plaintextStr, err)
+		}
+	}
+	if requestTimeout != nil && *requestTimeout != "" {
+		cfg.RequestTimeout, err = time.ParseDuration(*requestTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request timeout %s: %v", *requestTimeout, err)
+		}
+		if cfg.RequestTimeout <= 0 {
+			return nil, fmt.Errorf("invalid request timeout %s: must be positive", *requestTimeout)
+		}
+	}
+
+	return &cfg, nil
+}
+
+func (c *BackendConfig) tlsConfig() (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		InsecureSkipVerify: c.Insecure,
+	}
+	if c.CAFile == "" {
+		return tlsCfg, nil
+	}
+
+	pem, err := os.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca file %s: %v", c.CAFile, err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in ca file %s", c.CAFile)
+	}
+	tlsCfg.RootCAs = pool
+
+	return tlsCfg, nil
+}
+
+func (c *BackendConfig) proxyURL() (*url.URL, error) {
+	if c.Proxy == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(c.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %s: %v", c.Proxy, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported proxy scheme %s", u.Scheme)
+	}
+	return u, nil
+}
+
+// HTTPClient returns the client of the REST requests to the backend, it is built on the first call and shared afterwards.
+func (c *BackendConfig) HTTPClient() (*http.Client, error) {
+	c.httpClientOnce.Do(func() {
+		c.httpClient, c.httpClientErr = c.newHTTPClient()
+	})
+	return c.httpClient, c.httpClientErr
+}
+
+func (c *BackendConfig) newHTTPClient() (*http.Client, error) {
+	tlsCfg, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := c.proxyURL()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   c.RequestTimeout,
+	}, nil
+}
+
+func (c *BackendConfig) GrpcDialOptions(accessToken string) ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+
+	if c.Plaintext {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	} else {
+		tlsCfg, err := c.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg)))
+	}
+
+	// per-RPC oauth credentials refuse to be sent over an insecure connection
+	opts = append(opts, grpc.WithPerRPCCredentials(tokenSource{
+		TokenSource: oauth.TokenSource{
+			TokenSource: oauth2.StaticTokenSource(&oauth2.Token{
+				AccessToken: accessToken,
+			}),
+		},
+		requireTransportSecurity: !c.Plaintext,
+	}))
+
+	proxy, err := c.proxyURL()
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return c.dialThroughProxy(ctx, proxy, addr)
+		}))
+	}
+
+	return opts, nil
+}
+
+type tokenSource struct {
+	oauth.TokenSource
+	requireTransportSecurity bool
+}
+
+func (t tokenSource) RequireTransportSecurity() bool {
+	return t.requireTransportSecurity
+}
+
+// dialThroughProxy opens a tunnel to addr with a CONNECT through the proxy, an https proxy is verified like the backend is.
+func (c *BackendConfig) dialThroughProxy(ctx context.Context, proxy *url.URL, addr string) (net.Conn, error) {
+	proxyAddr := proxy.Host
+	if proxy.Port() == "" {
+		if proxy.Scheme == "https" {
+			proxyAddr = net.JoinHostPort(proxy.Hostname(), "443")
+		} else {
+			proxyAddr = net.JoinHostPort(proxy.Hostname(), "80")
+		}
+	}
+
+	var tlsCfg *tls.Config
+	if proxy.Scheme == "https" {
+		var err error
+		tlsCfg, err = c.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.ServerName = proxy.Hostname()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy %s: %v", proxyAddr, err)
+	}
+	if tlsCfg != nil {
+		tlsConn := tls.Client(conn, tlsCfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to connect to proxy %s: %v", proxyAddr, err)
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		req.SetBasicAuth(proxy.User.Username(), password)
+		req.Header.Set("Proxy-Authorization", req.Header.Get("Authorization"))
+		req.Header.Del("Authorization")
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT to proxy %s: %v", proxyAddr, err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read CONNECT response from proxy %s: %v", proxyAddr, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused CONNECT to %s: %s", proxyAddr, addr, resp.Status)
+	}
+	if br.Buffered() > 0 {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s sent unexpected data after CONNECT", proxyAddr)
+	}
+
+	return conn, nil
+}
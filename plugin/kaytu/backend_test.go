@@ -0,0 +1,166 @@
+package kaytu
+
+import (
+	"context"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetBackendConfig(t *testing.T) {
+	str := func(s string) *string {
+		return &s
+	}
+	tests := []struct {
+		name           string
+		grpcAddress    *string
+		apiUrl         *string
+		caFile         *string
+		insecure       *string
+		plaintext      *string
+		proxy          *string
+		requestTimeout *string
+		want           *BackendConfig
+		wantErr        bool
+	}{
+		{
+			name: "defaults",
+			want: &BackendConfig{GrpcAddress: DefaultGrpcAddress, ApiUrl: DefaultApiUrl, RequestTimeout: DefaultRequestTimeout},
+		},
+		{
+			name:     "empty flags keep the defaults",
+			apiUrl:   str(""),
+			insecure: str(""),
+			want:     &BackendConfig{GrpcAddress: DefaultGrpcAddress, ApiUrl: DefaultApiUrl, RequestTimeout: DefaultRequestTimeout},
+		},
+		{
+			name:           "every flag",
+			grpcAddress:    str("kaytu.internal:8443"),
+			apiUrl:         str("https://kaytu.internal/"),
+			caFile:         str("/etc/kaytu/ca.pem"),
+			insecure:       str("true"),
+			plaintext:      str("false"),
+			proxy:          str("http://proxy.internal:3128"),
+			requestTimeout: str("30s"),
+			want: &BackendConfig{
+				GrpcAddress:    "kaytu.internal:8443",
+				ApiUrl:         "https://kaytu.internal",
+				CAFile:         "/etc/kaytu/ca.pem",
+				Insecure:       true,
+				Proxy:          "http://proxy.internal:3128",
+				RequestTimeout: 30 * time.Second,
+			},
+		},
+		{name: "invalid insecure", insecure: str("maybe"), wantErr: true},
+		{name: "invalid plaintext", plaintext: str("yes please"), wantErr: true},
+		{name: "invalid request timeout", requestTimeout: str("30"), wantErr: true},
+		{name: "zero request timeout", requestTimeout: str("0s"), wantErr: true},
+		{name: "negative request timeout", requestTimeout: str("-1m"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := GetBackendConfig(tt.grpcAddress, tt.apiUrl, tt.caFile, tt.insecure, tt.plaintext, tt.proxy, tt.requestTimeout)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want.GrpcAddress, cfg.GrpcAddress)
+				assert.Equal(t, tt.want.ApiUrl, cfg.ApiUrl)
+				assert.Equal(t, tt.want.CAFile, cfg.CAFile)
+				assert.Equal(t, tt.want.Insecure, cfg.Insecure)
+				assert.Equal(t, tt.want.Plaintext, cfg.Plaintext)
+				assert.Equal(t, tt.want.Proxy, cfg.Proxy)
+				assert.Equal(t, tt.want.RequestTimeout, cfg.RequestTimeout)
+			}
+		})
+	}
+}
+
+// connectProxy accepts CONNECTs to backend.kaytu.io:443 from user:secret and echoes whatever goes through the tunnel.
+func connectProxy(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect || r.Host != "backend.kaytu.io:443" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Proxy-Authorization") != "Basic dXNlcjpzZWNyZXQ=" || r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		_, _ = io.Copy(conn, buf)
+	})
+}
+
+func TestDialThroughProxy(t *testing.T) {
+	plainProxy := httptest.NewServer(connectProxy(t))
+	defer plainProxy.Close()
+	tlsProxy := httptest.NewTLSServer(connectProxy(t))
+	defer tlsProxy.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsProxy.Certificate().Raw}), 0600)
+	assert.NoError(t, err)
+
+	proxyURL := func(server *httptest.Server, user string) *url.URL {
+		u, err := url.Parse(server.URL)
+		assert.NoError(t, err)
+		if user != "" {
+			u.User = url.UserPassword(user, "secret")
+		}
+		return u
+	}
+
+	tests := []struct {
+		name    string
+		cfg     *BackendConfig
+		proxy   *url.URL
+		wantErr string
+	}{
+		{name: "http proxy", cfg: &BackendConfig{}, proxy: proxyURL(plainProxy, "user")},
+		{name: "refused CONNECT", cfg: &BackendConfig{}, proxy: proxyURL(plainProxy, ""), wantErr: "407"},
+		{name: "https proxy trusted through the ca file", cfg: &BackendConfig{CAFile: caFile}, proxy: proxyURL(tlsProxy, "user")},
+		{name: "https proxy with insecure", cfg: &BackendConfig{Insecure: true}, proxy: proxyURL(tlsProxy, "user")},
+		{name: "untrusted https proxy", cfg: &BackendConfig{}, proxy: proxyURL(tlsProxy, "user"), wantErr: "certificate"},
+		{name: "unreadable ca file", cfg: &BackendConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}, proxy: proxyURL(tlsProxy, "user"), wantErr: "ca file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			conn, err := tt.cfg.dialThroughProxy(ctx, tt.proxy, "backend.kaytu.io:443")
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.Close()
+
+			// the tunnel is open once CONNECT succeeds, the proxy echoes it back
+			_, err = conn.Write([]byte("ping"))
+			assert.NoError(t, err)
+			reply := make([]byte, 4)
+			_, err = io.ReadFull(conn, reply)
+			assert.NoError(t, err)
+			assert.Equal(t, "ping", string(reply))
+		})
+	}
+}
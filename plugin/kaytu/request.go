@@ -11,12 +11,12 @@ import (
 
 var ErrLogin = errors.New("your session is expired, please login")
 
-func PodRequest(reqBody KubernetesPodWastageRequest, token string) (*KubernetesPodWastageResponse, error) {
+func PodRequest(cfg *BackendConfig, reqBody KubernetesPodWastageRequest, token string) (*KubernetesPodWastageResponse, error) {
 	payloadEncoded, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest("POST", "https://app.kaytu.io/kaytu/wastage/api/v1/wastage/kubernetes-pod", bytes.NewBuffer(payloadEncoded))
+	req, err := http.NewRequest("POST", cfg.ApiUrl+"/kaytu/wastage/api/v1/wastage/kubernetes-pod", bytes.NewBuffer(payloadEncoded))
 	if err != nil {
 		return nil, fmt.Errorf("[pods]: %v", err)
 	}
@@ -24,7 +24,11 @@ func PodRequest(reqBody KubernetesPodWastageRequest, token string) (*KubernetesP
 	if len(token) > 0 {
 		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
 	}
-	res, err := http.DefaultClient.Do(req)
+	httpClient, err := cfg.HTTPClient()
+	if err != nil {
+		return nil, fmt.Errorf("[pods]: %v", err)
+	}
+	res, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("[pods]: %v", err)
 	}
@@ -54,13 +58,17 @@ func PodRequest(reqBody KubernetesPodWastageRequest, token string) (*KubernetesP
 	return &response, nil
 }
 
-func ConfigurationRequest() (*Configuration, error) {
-	req, err := http.NewRequest("POST", "https://app.kaytu.io/kaytu/wastage/api/v1/wastage/configuration", nil)
+func ConfigurationRequest(cfg *BackendConfig) (*Configuration, error) {
+	req, err := http.NewRequest("POST", cfg.ApiUrl+"/kaytu/wastage/api/v1/wastage/configuration", nil)
 	if err != nil {
 		return nil, fmt.Errorf("[ConfigurationRequest]: %v", err)
 	}
 	req.Header.Add("content-type", "application/json")
-	res, err := http.DefaultClient.Do(req)
+	httpClient, err := cfg.HTTPClient()
+	if err != nil {
+		return nil, fmt.Errorf("[ConfigurationRequest]: %v", err)
+	}
+	res, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("[ConfigurationRequest]: %v", err)
 	}
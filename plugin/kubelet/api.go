@@ -0,0 +1,294 @@
+package kubelet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSampleInterval = 15 * time.Second
+
+	// minHistorySamples and minHistoryWindow are the least a container has to be observed for before its item is rightsized
+	minHistorySamples = 20
+	minHistoryWindow  = 5 * time.Minute
+
+	// maxHistoryAge is how long samples are kept, older ones and the containers that stopped being reported are dropped
+	maxHistoryAge = 24 * time.Hour
+)
+
+// Kubelet samples the kubelet /stats/summary endpoint of every node through the API server proxy.
+// It only knows about usage observed while the plugin is running, and has no throttling data.
+// The metrics jobs wait until it sampled for minHistoryWindow, items observed for less than minHistorySamples samples over
+// minHistoryWindow are still skipped. Samples are kept for maxHistoryAge.
+type Kubelet struct {
+	client         *kaytuKubernetes.Kubernetes
+	sampleInterval time.Duration
+
+	started time.Time
+	sampled int
+	ready   chan struct{}
+
+	lock   sync.RWMutex
+	cpu    map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint // Namespace -> Pod -> Container -> Datapoints
+	memory map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint // Namespace -> Pod -> Container -> Datapoints
+}
+
+func NewKubelet(ctx context.Context, client *kaytuKubernetes.Kubernetes) (*Kubelet, error) {
+	k := Kubelet{
+		client:         client,
+		sampleInterval: defaultSampleInterval,
+		started:        time.Now(),
+		ready:          make(chan struct{}),
+		cpu:            make(map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint),
+		memory:         make(map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint),
+	}
+
+	err := k.sample(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("sampling kubelet stats for %s before rightsizing", minHistoryWindow)
+
+	go func() {
+		ticker := time.NewTicker(k.sampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := k.sample(ctx); err != nil {
+					log.Printf("failed to sample kubelet stats: %v", err)
+				}
+			}
+		}
+	}()
+
+	return &k, nil
+}
+
+func (k *Kubelet) sample(ctx context.Context) error {
+	nodes, err := k.client.ListAllNodes(ctx, "")
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return errors.New("no nodes found to sample kubelet stats from")
+	}
+
+	var errs []error
+	for _, node := range nodes {
+		summary, err := k.client.GetNodeStatsSummary(ctx, node.Name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("node %s: %v", node.Name, err))
+			continue
+		}
+		k.add(summary)
+	}
+	if len(errs) == len(nodes) {
+		return errors.Join(errs...)
+	}
+	k.prune(time.Now().Add(-maxHistoryAge))
+	k.sampled++
+	if k.sampled >= minHistorySamples && time.Since(k.started) >= minHistoryWindow {
+		k.markReady()
+	}
+	return nil
+}
+
+func (k *Kubelet) markReady() {
+	select {
+	case <-k.ready:
+	default:
+		close(k.ready)
+	}
+}
+
+// prune drops the samples taken before since, and the containers and pods left without any.
+func (k *Kubelet) prune(since time.Time) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	for _, m := range []map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint{k.cpu, k.memory} {
+		for namespace, pods := range m {
+			for podName, containers := range pods {
+				for containerName, dps := range containers {
+					i := 0
+					for i < len(dps) && !dps[i].Timestamp.After(since) {
+						i++
+					}
+					if i == len(dps) {
+						delete(containers, containerName)
+					} else if i > 0 {
+						containers[containerName] = append([]kaytuPrometheus.PromDatapoint(nil), dps[i:]...)
+					}
+				}
+				if len(containers) == 0 {
+					delete(pods, podName)
+				}
+			}
+			if len(pods) == 0 {
+				delete(m, namespace)
+			}
+		}
+	}
+}
+
+func (k *Kubelet) add(summary *kaytuKubernetes.StatsSummary) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	for _, pod := range summary.Pods {
+		for _, container := range pod.Containers {
+			if container.CPU != nil && container.CPU.UsageNanoCores != nil {
+				appendDatapoint(k.cpu, pod.PodRef.Namespace, pod.PodRef.Name, container.Name, kaytuPrometheus.PromDatapoint{
+					Timestamp: container.CPU.Time.Time,
+					Value:     float64(*container.CPU.UsageNanoCores) / 1e9,
+				})
+			}
+			if container.Memory != nil && container.Memory.WorkingSetBytes != nil {
+				appendDatapoint(k.memory, pod.PodRef.Namespace, pod.PodRef.Name, container.Name, kaytuPrometheus.PromDatapoint{
+					Timestamp: container.Memory.Time.Time,
+					Value:     float64(*container.Memory.WorkingSetBytes),
+				})
+			}
+		}
+	}
+}
+
+func appendDatapoint(m map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint, namespace, pod, container string, dp kaytuPrometheus.PromDatapoint) {
+	if m[namespace] == nil {
+		m[namespace] = make(map[string]map[string][]kaytuPrometheus.PromDatapoint)
+	}
+	if m[namespace][pod] == nil {
+		m[namespace][pod] = make(map[string][]kaytuPrometheus.PromDatapoint)
+	}
+	dps := m[namespace][pod][container]
+	if len(dps) > 0 && !dp.Timestamp.After(dps[len(dps)-1].Timestamp) {
+		// the kubelet has not refreshed its stats since the last sample
+		return
+	}
+	m[namespace][pod][container] = append(dps, dp)
+}
+
+func (k *Kubelet) podMetrics(m map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint, namespace, podName string, observabilityDays int) map[string][]kaytuPrometheus.PromDatapoint {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+
+	since := time.Now().Add(time.Duration(observabilityDays) * -24 * time.Hour)
+	result := make(map[string][]kaytuPrometheus.PromDatapoint)
+	for containerName, dps := range m[namespace][podName] {
+		for _, dp := range dps {
+			if dp.Timestamp.After(since) {
+				result[containerName] = append(result[containerName], dp)
+			}
+		}
+	}
+	return result
+}
+
+func (k *Kubelet) podOwnerPrefixMetrics(m map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint, namespace, podOwnerPrefix string, observabilityDays int, suffixMode kaytuPrometheus.PodSuffixMode) (map[string]map[string][]kaytuPrometheus.PromDatapoint, error) {
+	pattern := podOwnerPrefix + "-"
+	if len(pattern) > 58 {
+		pattern = pattern[:58]
+	}
+	re, err := regexp.Compile("^" + regexp.QuoteMeta(pattern) + suffixMode.Regex() + "$")
+	if err != nil {
+		return nil, err
+	}
+
+	k.lock.RLock()
+	var pods []string
+	for podName := range m[namespace] {
+		if re.MatchString(podName) {
+			pods = append(pods, podName)
+		}
+	}
+	k.lock.RUnlock()
+
+	result := make(map[string]map[string][]kaytuPrometheus.PromDatapoint)
+	for _, podName := range pods {
+		result[podName] = k.podMetrics(m, namespace, podName, observabilityDays)
+	}
+	return result, nil
+}
+
+func (k *Kubelet) GetCpuMetricsForPod(_ context.Context, namespace, podName string, observabilityDays int) (map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return k.podMetrics(k.cpu, namespace, podName, observabilityDays), nil
+}
+
+func (k *Kubelet) GetCpuMetricsForPodOwnerPrefix(_ context.Context, namespace, _, podOwnerPrefix string, observabilityDays int, suffixMode kaytuPrometheus.PodSuffixMode) (map[string]map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return k.podOwnerPrefixMetrics(k.cpu, namespace, podOwnerPrefix, observabilityDays, suffixMode)
+}
+
+func (k *Kubelet) GetMemoryMetricsForPod(_ context.Context, namespace, podName string, observabilityDays int) (map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return k.podMetrics(k.memory, namespace, podName, observabilityDays), nil
+}
+
+func (k *Kubelet) GetMemoryMetricsForPodOwnerPrefix(_ context.Context, namespace, _, podPrefix string, observabilityDays int, suffixMode kaytuPrometheus.PodSuffixMode) (map[string]map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return k.podOwnerPrefixMetrics(k.memory, namespace, podPrefix, observabilityDays, suffixMode)
+}
+
+func (k *Kubelet) GetCpuThrottlingMetricsForPod(_ context.Context, _, _ string, _ int) (map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return make(map[string][]kaytuPrometheus.PromDatapoint), nil
+}
+
+func (k *Kubelet) GetCpuThrottlingMetricsForPodOwnerPrefix(_ context.Context, _, _, _ string, _ int, _ kaytuPrometheus.PodSuffixMode) (map[string]map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return make(map[string]map[string][]kaytuPrometheus.PromDatapoint), nil
+}
+
+func (k *Kubelet) InsufficientHistory(usage map[string]map[string][]kaytuPrometheus.PromDatapoint) string {
+	samples := 0
+	var first, last time.Time
+	for _, containers := range usage {
+		for _, dps := range containers {
+			if len(dps) > samples {
+				samples = len(dps)
+			}
+			for _, dp := range dps {
+				if first.IsZero() || dp.Timestamp.Before(first) {
+					first = dp.Timestamp
+				}
+				if dp.Timestamp.After(last) {
+					last = dp.Timestamp
+				}
+			}
+		}
+	}
+	window := last.Sub(first)
+	if samples >= minHistorySamples && window >= minHistoryWindow {
+		return ""
+	}
+	return fmt.Sprintf("only %d kubelet stats samples over %s, at least %d over %s are needed", samples, window.Round(time.Second), minHistorySamples, minHistoryWindow)
+}
+
+func (k *Kubelet) WaitForHistory(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-k.ready:
+		return nil
+	}
+}
+
+func (k *Kubelet) Ping(ctx context.Context) error {
+	nodes, err := k.client.ListAllNodes(ctx, "")
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return errors.New("no nodes found")
+	}
+	_, err = k.client.GetNodeStatsSummary(ctx, nodes[0].Name)
+	return err
+}
+
+var _ kaytuPrometheus.MetricsProvider = (*Kubelet)(nil)
+var _ kaytuPrometheus.HistoryLimitedProvider = (*Kubelet)(nil)
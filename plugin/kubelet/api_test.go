@@ -0,0 +1,40 @@
+package kubelet
+
+import (
+	"context"
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	now := time.Now()
+	k := &Kubelet{
+		cpu:    make(map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint),
+		memory: make(map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint),
+	}
+	for _, age := range []time.Duration{3 * time.Hour, 2 * time.Hour, time.Hour} {
+		appendDatapoint(k.cpu, "default", "web-1", "app", kaytuPrometheus.PromDatapoint{Timestamp: now.Add(-age), Value: 1})
+	}
+	appendDatapoint(k.cpu, "default", "gone-1", "app", kaytuPrometheus.PromDatapoint{Timestamp: now.Add(-5 * time.Hour), Value: 1})
+	appendDatapoint(k.memory, "other", "gone-2", "app", kaytuPrometheus.PromDatapoint{Timestamp: now.Add(-5 * time.Hour), Value: 1})
+
+	k.prune(now.Add(-150 * time.Minute))
+
+	assert.Len(t, k.cpu["default"]["web-1"]["app"], 2, "samples older than the cut are dropped")
+	assert.NotContains(t, k.cpu["default"], "gone-1", "pods without samples left are dropped")
+	assert.Empty(t, k.memory, "namespaces without samples left are dropped")
+}
+
+func TestWaitForHistory(t *testing.T) {
+	k := &Kubelet{ready: make(chan struct{})}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, kaytuPrometheus.WaitForHistory(ctx, k), context.DeadlineExceeded, "waits while the history is short")
+
+	k.markReady()
+	k.markReady()
+	assert.NoError(t, kaytuPrometheus.WaitForHistory(context.Background(), k))
+}
@@ -6,12 +6,22 @@ import (
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/utils"
 	appv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
 	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd/api"
 	"math/rand"
 	"sort"
@@ -25,7 +35,12 @@ var KaytuNotFoundErr = errors.New("kaytu not found")
 type Kubernetes struct {
 	restClientCfg *restclient.Config
 	kubeCfg       *api.Config
-	clientset     *kubernetes.Clientset
+	clientset     kubernetes.Interface
+	dynamicClient dynamic.Interface
+	restMapper    meta.RESTMapper
+	owners        utils.ConcurrentMap[types.UID, *unstructured.Unstructured]
+	// now is the clock everything relative to the current time is computed against, replays stop it at the capture time
+	now func() time.Time
 }
 
 func NewKubernetes(cfg *restclient.Config, kubeCfg *api.Config) (*Kubernetes, error) {
@@ -33,7 +48,49 @@ func NewKubernetes(cfg *restclient.Config, kubeCfg *api.Config) (*Kubernetes, er
 	if err != nil {
 		return nil, err
 	}
-	return &Kubernetes{restClientCfg: cfg, kubeCfg: kubeCfg, clientset: clientset}, nil
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	restMapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientset.Discovery()))
+	return &Kubernetes{
+		restClientCfg: cfg,
+		kubeCfg:       kubeCfg,
+		clientset:     clientset,
+		dynamicClient: dynamicClient,
+		restMapper:    restMapper,
+		owners:        utils.NewConcurrentMap[types.UID, *unstructured.Unstructured](),
+		now:           time.Now,
+	}, nil
+}
+
+// NewKubernetesFromObjects serves the given objects from memory instead of an API server, it is used to replay snapshots.
+// Its clock is stopped at capturedAt, so the replay sees the objects and metrics as they were when they were captured.
+// Anything that needs a live cluster, like port forwarding or kubelet stats, fails on it, and owners of custom controllers can't be resolved.
+func NewKubernetesFromObjects(capturedAt time.Time, objects ...runtime.Object) *Kubernetes {
+	return &Kubernetes{
+		clientset: fake.NewSimpleClientset(objects...),
+		owners:    utils.NewConcurrentMap[types.UID, *unstructured.Unstructured](),
+		now: func() time.Time {
+			return capturedAt
+		},
+	}
+}
+
+// NewKubernetesFromClients reads the cluster through the given clients, e.g. fakes of them in tests.
+func NewKubernetesFromClients(clientset kubernetes.Interface, dynamicClient dynamic.Interface, restMapper meta.RESTMapper) *Kubernetes {
+	return &Kubernetes{
+		clientset:     clientset,
+		dynamicClient: dynamicClient,
+		restMapper:    restMapper,
+		owners:        utils.NewConcurrentMap[types.UID, *unstructured.Unstructured](),
+		now:           time.Now,
+	}
+}
+
+// Now is the current time of the cluster, the capture time when replaying a snapshot.
+func (s *Kubernetes) Now() time.Time {
+	return s.now()
 }
 
 func (s *Kubernetes) Identify() map[string]string {
@@ -49,15 +106,16 @@ func (s *Kubernetes) Identify() map[string]string {
 		return result
 	}
 
-	currentContext := s.kubeCfg.Contexts[s.kubeCfg.CurrentContext]
+	currentContextName := s.kubeCfg.CurrentContext
+	currentContext := s.kubeCfg.Contexts[currentContextName]
 	if currentContext == nil && len(s.kubeCfg.Contexts) > 1 {
 		return result
 	} else if currentContext == nil {
-		for _, v := range s.kubeCfg.Contexts {
-			currentContext = v
+		for k, v := range s.kubeCfg.Contexts {
+			currentContextName, currentContext = k, v
 		}
 	}
-	result["context_name"] = s.kubeCfg.CurrentContext
+	result["context_name"] = currentContextName
 	result["cluster_name"] = currentContext.Cluster
 	result["auth_info_name"] = currentContext.AuthInfo
 
@@ -73,6 +131,19 @@ func (s *Kubernetes) Identify() map[string]string {
 	return result
 }
 
+// ClusterID identifies the cluster across runs and kubeconfig contexts, it is the UID of the kube-system namespace.
+// When that can't be read it falls back to the API server address, and is empty when neither is known.
+func (s *Kubernetes) ClusterID(ctx context.Context) string {
+	namespace, err := s.clientset.CoreV1().Namespaces().Get(ctx, metav1.NamespaceSystem, metav1.GetOptions{})
+	if err == nil && namespace.UID != "" {
+		return string(namespace.UID)
+	}
+	if s.restClientCfg != nil {
+		return s.restClientCfg.Host
+	}
+	return ""
+}
+
 func (s *Kubernetes) ListAllNamespaces(ctx context.Context) ([]corev1.Namespace, error) {
 	namespaces, err := s.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
 	if err != nil {
@@ -100,7 +171,8 @@ func (s *Kubernetes) ListPodsInNamespace(ctx context.Context, namespace, labelSe
 
 	orphanPods := make([]corev1.Pod, 0, len(pods.Items))
 	for _, pod := range pods.Items {
-		isOwned := false
+		// pods of any other controller are grouped under it by ResolveController, static pods have no controller to group them under
+		isOwned := s.CanResolveControllers() && metav1.GetControllerOf(&pod) != nil && !IsStaticPod(pod)
 		for _, owner := range pod.ObjectMeta.OwnerReferences {
 			if owner.Kind == "ReplicaSet" || owner.Kind == "StatefulSet" || owner.Kind == "DaemonSet" || owner.Kind == "Job" {
 				isOwned = true
@@ -115,6 +187,15 @@ func (s *Kubernetes) ListPodsInNamespace(ctx context.Context, namespace, labelSe
 	return orphanPods, nil
 }
 
+// IsStaticPod is true for the mirror pods of static pods, they are run by the kubelet of their node and can't be changed through the API.
+func IsStaticPod(pod corev1.Pod) bool {
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return true
+	}
+	ref := metav1.GetControllerOf(&pod)
+	return ref != nil && ref.Kind == "Node"
+}
+
 func (s *Kubernetes) ListDeploymentsInNamespace(ctx context.Context, namespace, labelSelector string) ([]appv1.Deployment, error) {
 	deployments, err := s.clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
 	if err != nil {
@@ -151,8 +232,84 @@ func (s *Kubernetes) ListJobsInNamespace(ctx context.Context, namespace, labelSe
 	return jobs.Items, nil
 }
 
+func (s *Kubernetes) ListCronJobsInNamespace(ctx context.Context, namespace, labelSelector string) ([]batchv1.CronJob, error) {
+	cronJobs, err := s.clientset.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	return cronJobs.Items, nil
+}
+
+func (s *Kubernetes) ListPodDisruptionBudgetsInNamespace(ctx context.Context, namespace string) ([]policyv1.PodDisruptionBudget, error) {
+	pdbs, err := s.clientset.PolicyV1().PodDisruptionBudgets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return pdbs.Items, nil
+}
+
+func (s *Kubernetes) ListHorizontalPodAutoscalersInNamespace(ctx context.Context, namespace string) ([]autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpas, err := s.clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return hpas.Items, nil
+}
+
+// ListLimitRangesInNamespace lists the LimitRanges of the namespace, or of all namespaces when it is empty.
+func (s *Kubernetes) ListLimitRangesInNamespace(ctx context.Context, namespace string) ([]corev1.LimitRange, error) {
+	limitRanges, err := s.clientset.CoreV1().LimitRanges(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return limitRanges.Items, nil
+}
+
+// ListResourceQuotasInNamespace lists the ResourceQuotas of the namespace, or of all namespaces when it is empty.
+func (s *Kubernetes) ListResourceQuotasInNamespace(ctx context.Context, namespace string) ([]corev1.ResourceQuota, error) {
+	quotas, err := s.clientset.CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return quotas.Items, nil
+}
+
+// ListPersistentVolumeClaimsInNamespace lists the PersistentVolumeClaims of the namespace, or of all namespaces when it is empty.
+func (s *Kubernetes) ListPersistentVolumeClaimsInNamespace(ctx context.Context, namespace string) ([]corev1.PersistentVolumeClaim, error) {
+	claims, err := s.clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return claims.Items, nil
+}
+
+// ListPersistentVolumes lists the PersistentVolumes of the cluster, they are not namespaced.
+func (s *Kubernetes) ListPersistentVolumes(ctx context.Context) ([]corev1.PersistentVolume, error) {
+	volumes, err := s.clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return volumes.Items, nil
+}
+
+func (s *Kubernetes) ListReplicaSetsInNamespace(ctx context.Context, namespace, labelSelector string) ([]appv1.ReplicaSet, error) {
+	replicaSets, err := s.clientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	return replicaSets.Items, nil
+}
+
 func (s *Kubernetes) ListDeploymentPodsAndHistoricalReplicaSets(ctx context.Context, deployment appv1.Deployment, maxDays int) ([]corev1.Pod, string, []string, error) {
-	timeCut := time.Now().AddDate(0, 0, -maxDays).Truncate(24 * time.Hour)
+	timeCut := s.Now().AddDate(0, 0, -maxDays).Truncate(24 * time.Hour)
 
 	probableReplicaSets, err := s.clientset.AppsV1().ReplicaSets(deployment.Namespace).List(ctx, metav1.ListOptions{
 		LabelSelector: labels.Set(deployment.Spec.Selector.MatchLabels).AsSelector().String(),
@@ -325,6 +482,26 @@ func (s *Kubernetes) ListJobPods(ctx context.Context, job batchv1.Job) ([]corev1
 	return pods, nil
 }
 
+// ListCronJobJobs returns the jobs the cronjob spawned that still exist, the cronjob's history limits decide how many that is.
+func (s *Kubernetes) ListCronJobJobs(ctx context.Context, cronJob batchv1.CronJob) ([]batchv1.Job, error) {
+	probableJobs, err := s.clientset.BatchV1().Jobs(cronJob.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]batchv1.Job, 0, len(probableJobs.Items))
+	for _, job := range probableJobs.Items {
+		for _, owner := range job.ObjectMeta.OwnerReferences {
+			if owner.UID == cronJob.UID {
+				jobs = append(jobs, job)
+				break
+			}
+		}
+	}
+
+	return jobs, nil
+}
+
 func (s *Kubernetes) DiscoverAndPortForwardKaytuAgent(ctx context.Context, reconnectMutex *sync.Mutex) (chan struct{}, string, error) {
 	stopChan := make(chan struct{}, 1)
 
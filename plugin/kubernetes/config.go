@@ -2,51 +2,63 @@ package kubernetes
 
 import (
 	"context"
-	"errors"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
-	"k8s.io/client-go/util/homedir"
-	"os"
-	"path/filepath"
+	"sort"
 )
 
-func GetConfig(ctx context.Context, kubeContextName *string) (*restclient.Config, *api.Config, error) {
-	var err error
-	kubeConfigPath := filepath.Join(homedir.HomeDir(), ".kube", "config")
+// GetConfig loads the kubeconfig with the standard clientcmd rules: an explicit path wins, otherwise the KUBECONFIG
+// files are merged, falling back to ~/.kube/config. The returned api.Config has CurrentContext set to the context in use.
+func GetConfig(ctx context.Context, kubeConfigPath, kubeContextName *string) (*restclient.Config, *api.Config, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeConfigPath != nil && *kubeConfigPath != "" {
+		loadingRules.ExplicitPath = *kubeConfigPath
+	}
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeContextName != nil && *kubeContextName != "" {
+		overrides.CurrentContext = *kubeContextName
+	}
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
 
-	if _, err = os.Stat(kubeConfigPath); err != nil && !os.IsNotExist(err) {
+	kubeConfig, err := clientConfig.RawConfig()
+	if err != nil {
 		return nil, nil, err
-	} else if os.IsNotExist(err) { // check if running in cluster
+	}
+
+	if len(kubeConfig.Contexts) == 0 && loadingRules.ExplicitPath == "" && overrides.CurrentContext == "" { // check if running in cluster
 		config, err := restclient.InClusterConfig()
 		if err == nil {
 			return config, nil, nil
 		}
-	} else if err == nil {
-		kubeConfig, err := clientcmd.LoadFromFile(kubeConfigPath)
-		if err != nil {
-			return nil, nil, err
-		}
-		var restclientConfig *restclient.Config
-		if kubeContextName == nil || *kubeContextName == "" {
-			restclientConfig, err = clientcmd.BuildConfigFromFlags("", kubeConfigPath)
-			if err != nil {
-				return nil, nil, err
-			}
-		} else {
-			restclientConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-				&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeConfigPath},
-				&clientcmd.ConfigOverrides{
-					CurrentContext: *kubeContextName,
-				}).ClientConfig()
-			if err != nil {
-				return nil, nil, err
-			}
-			kubeConfig.CurrentContext = *kubeContextName
-		}
+	}
 
-		return restclientConfig, kubeConfig, nil
+	restclientConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, nil, err
 	}
+	if overrides.CurrentContext != "" {
+		kubeConfig.CurrentContext = overrides.CurrentContext
+	}
+
+	return restclientConfig, &kubeConfig, nil
+}
 
-	return nil, nil, errors.New("unable to get kubernetes config")
+// ListContexts returns the names of the contexts of the kubeconfig, loaded with the same rules as GetConfig.
+func ListContexts(kubeConfigPath *string) ([]string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeConfigPath != nil && *kubeConfigPath != "" {
+		loadingRules.ExplicitPath = *kubeConfigPath
+	}
+	kubeConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).RawConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	contexts := make([]string, 0, len(kubeConfig.Contexts))
+	for name := range kubeConfig.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	return contexts, nil
 }
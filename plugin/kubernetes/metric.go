@@ -18,6 +18,8 @@ import (
 	"sync"
 )
 
+var PrometheusNotFoundErr = errors.New("no prometheus compatible service found - try passing the prometheus compatible endpoint with the --prom-address flag e.g. --prom-address 'http://localhost:9090'")
+
 func getNextOpenPort() (int, error) {
 	listener, err := net.Listen("tcp", ":0")
 	if err != nil {
@@ -28,53 +30,55 @@ func getNextOpenPort() (int, error) {
 	return listener.Addr().(*net.TCPAddr).Port, nil
 }
 
-func (s *Kubernetes) DiscoverAndPortForwardPrometheusCompatible(ctx context.Context, reconnectMutex *sync.Mutex) (chan struct{}, string, error) {
+// DiscoverAndPortForwardPrometheusCompatible port forwards the first prometheus compatible service found in the cluster.
+// Along with the local address it returns the namespace/name of the service, which stays the same between runs unlike the local port.
+func (s *Kubernetes) DiscoverAndPortForwardPrometheusCompatible(ctx context.Context, reconnectMutex *sync.Mutex) (chan struct{}, string, string, error) {
 	var svc *corev1.Service
 	var err error
 	stopChan := make(chan struct{}, 1)
 
 	port, err := getNextOpenPort()
 	if err != nil {
-		return nil, "", err
+		return nil, "", "", err
 	}
 
 	svc, err = s.findPrometheusService(ctx)
 	if err != nil {
-		return nil, "", err
+		return nil, "", "", err
 	}
 	if svc != nil {
 		err = s.portForward(ctx, svc.Namespace, svc.Name, []string{fmt.Sprintf("%d:9090", port)}, reconnectMutex, stopChan)
 		if err != nil {
-			return nil, "", err
+			return nil, "", "", err
 		}
-		return stopChan, fmt.Sprintf("http://localhost:%d", port), nil
+		return stopChan, fmt.Sprintf("http://localhost:%d", port), svc.Namespace + "/" + svc.Name, nil
 	}
 
 	svc, err = s.findVictoriaMetricsClusterSelectService(ctx)
 	if err != nil {
-		return nil, "", err
+		return nil, "", "", err
 	}
 	if svc != nil {
 		err = s.portForward(ctx, svc.Namespace, svc.Name, []string{fmt.Sprintf("%d:8481", port)}, reconnectMutex, stopChan)
 		if err != nil {
-			return nil, "", err
+			return nil, "", "", err
 		}
-		return stopChan, fmt.Sprintf("http://localhost:%d/select/0/prometheus", port), nil
+		return stopChan, fmt.Sprintf("http://localhost:%d/select/0/prometheus", port), svc.Namespace + "/" + svc.Name, nil
 	}
 
 	svc, err = s.findVictoriaMetricsSingleServerService(ctx)
 	if err != nil {
-		return nil, "", err
+		return nil, "", "", err
 	}
 	if svc != nil {
 		err = s.portForward(ctx, svc.Namespace, svc.Name, []string{fmt.Sprintf("%d:8429", port)}, reconnectMutex, stopChan)
 		if err != nil {
-			return nil, "", err
+			return nil, "", "", err
 		}
-		return stopChan, fmt.Sprintf("http://localhost:%d", port), nil
+		return stopChan, fmt.Sprintf("http://localhost:%d", port), svc.Namespace + "/" + svc.Name, nil
 	}
 
-	return nil, "", errors.New("no prometheus compatible service found - try passing the prometheus compatible endpoint with the --prom-address flag e.g. --prom-address 'http://localhost:9090'")
+	return nil, "", "", PrometheusNotFoundErr
 }
 
 func (s *Kubernetes) findPrometheusService(ctx context.Context) (*corev1.Service, error) {
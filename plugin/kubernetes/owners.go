@@ -0,0 +1,153 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"strings"
+)
+
+// DefaultTemplatePath is where the pod template of most controllers lives, e.g. Argo Rollouts and OpenKruise CloneSets.
+const DefaultTemplatePath = "spec.template"
+
+const maxOwnerDepth = 10
+
+// Controller is the top of a pod's chain of controller owner references.
+type Controller struct {
+	APIVersion string    `json:"apiVersion"`
+	Kind       string    `json:"kind"`
+	Namespace  string    `json:"namespace"`
+	Name       string    `json:"name"`
+	UID        types.UID `json:"uid"`
+	// Object is the controller as read with the dynamic client, nil when it could not be read
+	Object *unstructured.Unstructured `json:"object,omitempty"`
+}
+
+func (c Controller) GroupVersionKind() schema.GroupVersionKind {
+	return schema.FromAPIVersionAndKind(c.APIVersion, c.Kind)
+}
+
+// PodTemplate reads the pod template of the controller at the path configured for its kind, or DefaultTemplatePath.
+// It returns nil when the controller was not read or has no template at that path.
+func (c Controller) PodTemplate(templatePaths map[schema.GroupVersionKind]string) (*corev1.PodTemplateSpec, error) {
+	if c.Object == nil {
+		return nil, nil
+	}
+	path := DefaultTemplatePath
+	if p, ok := templatePaths[c.GroupVersionKind()]; ok {
+		path = p
+	}
+
+	raw, found, err := unstructured.NestedMap(c.Object.Object, strings.Split(path, ".")...)
+	if err != nil || !found {
+		return nil, err
+	}
+	var template corev1.PodTemplateSpec
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pod template of %s %s at %s: %v", c.Kind, c.Name, path, err)
+	}
+	return &template, nil
+}
+
+// Replicas reads spec.replicas of the controller, ok is false when the controller was not read or has no replica count.
+func (c Controller) Replicas() (int32, bool) {
+	if c.Object == nil {
+		return 0, false
+	}
+	replicas, found, err := unstructured.NestedInt64(c.Object.Object, "spec", "replicas")
+	if err != nil || !found {
+		return 0, false
+	}
+	return int32(replicas), true
+}
+
+// ParseTemplatePaths parses a comma separated list of <apiVersion>/<Kind>=<path> entries,
+// e.g. argoproj.io/v1alpha1/Rollout=spec.template,apps.kruise.io/v1alpha1/CloneSet=spec.template
+func ParseTemplatePaths(templatePaths *string) (map[schema.GroupVersionKind]string, error) {
+	result := make(map[schema.GroupVersionKind]string)
+	if templatePaths == nil || strings.TrimSpace(*templatePaths) == "" {
+		return result, nil
+	}
+
+	for _, entry := range strings.Split(*templatePaths, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		gvk, path, ok := strings.Cut(entry, "=")
+		idx := strings.LastIndex(gvk, "/")
+		if !ok || idx <= 0 || idx == len(gvk)-1 || path == "" {
+			return nil, fmt.Errorf("invalid template path %s, expected <apiVersion>/<Kind>=<path>", entry)
+		}
+		result[schema.FromAPIVersionAndKind(gvk[:idx], gvk[idx+1:])] = path
+	}
+	return result, nil
+}
+
+// CanResolveControllers is false when owners can't be read, e.g. when replaying a snapshot.
+func (s *Kubernetes) CanResolveControllers() bool {
+	return s.dynamicClient != nil && s.restMapper != nil
+}
+
+// ResolveController walks the controller owner references of the pod up to its top-level controller, nil if the pod has none.
+// Owners that can't be read, e.g. custom resources the credentials can't get, end the walk at the last reference.
+func (s *Kubernetes) ResolveController(ctx context.Context, pod corev1.Pod) *Controller {
+	ref := metav1.GetControllerOf(&pod)
+	if ref == nil {
+		return nil
+	}
+
+	controller := Controller{APIVersion: ref.APIVersion, Kind: ref.Kind, Namespace: pod.Namespace, Name: ref.Name, UID: ref.UID}
+	for depth := 0; depth < maxOwnerDepth; depth++ {
+		obj, err := s.getOwner(ctx, pod.Namespace, *ref)
+		if err != nil {
+			return &controller
+		}
+		controller.Object = obj
+
+		ref = metav1.GetControllerOf(obj)
+		if ref == nil {
+			return &controller
+		}
+		controller = Controller{APIVersion: ref.APIVersion, Kind: ref.Kind, Namespace: pod.Namespace, Name: ref.Name, UID: ref.UID}
+	}
+	return &controller
+}
+
+func (s *Kubernetes) getOwner(ctx context.Context, namespace string, ref metav1.OwnerReference) (*unstructured.Unstructured, error) {
+	if !s.CanResolveControllers() {
+		return nil, errors.New("dynamic client is not available")
+	}
+	if obj, ok := s.owners.Get(ref.UID); ok {
+		return obj, nil
+	}
+
+	gvk := schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind)
+	mapping, err := s.restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	var resource dynamic.ResourceInterface = s.dynamicClient.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		resource = s.dynamicClient.Resource(mapping.Resource).Namespace(namespace)
+	}
+	obj, err := resource.Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if obj.GetUID() != ref.UID {
+		return nil, fmt.Errorf("%s %s was replaced", ref.Kind, ref.Name)
+	}
+
+	s.owners.Set(ref.UID, obj)
+	return obj, nil
+}
@@ -0,0 +1,220 @@
+package kubernetes
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"testing"
+)
+
+var (
+	rolloutGVK    = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}
+	replicaSetGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}
+	cloneSetGVK   = schema.GroupVersionKind{Group: "apps.kruise.io", Version: "v1alpha1", Kind: "CloneSet"}
+)
+
+func controllerRef(gvk schema.GroupVersionKind, name string, uid types.UID) metav1.OwnerReference {
+	controller := true
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	return metav1.OwnerReference{APIVersion: apiVersion, Kind: kind, Name: name, UID: uid, Controller: &controller}
+}
+
+func ownerObject(gvk schema.GroupVersionKind, name string, uid types.UID, owner *metav1.OwnerReference, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace("shop")
+	obj.SetName(name)
+	obj.SetUID(uid)
+	if owner != nil {
+		obj.SetOwnerReferences([]metav1.OwnerReference{*owner})
+	}
+	return obj
+}
+
+func testKubernetes(objects ...runtime.Object) *Kubernetes {
+	restMapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range []schema.GroupVersionKind{rolloutGVK, replicaSetGVK, cloneSetGVK} {
+		restMapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	return NewKubernetesFromClients(fake.NewSimpleClientset(), dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...), restMapper)
+}
+
+func TestParseTemplatePaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   *string
+		want    map[schema.GroupVersionKind]string
+		wantErr bool
+	}{
+		{name: "nil", want: map[schema.GroupVersionKind]string{}},
+		{name: "blank", paths: ptr(" "), want: map[schema.GroupVersionKind]string{}},
+		{
+			name:  "entries",
+			paths: ptr("argoproj.io/v1alpha1/Rollout=spec.template, apps.kruise.io/v1alpha1/CloneSet=spec.workload.template,,v1/PodTemplate=template"),
+			want: map[schema.GroupVersionKind]string{
+				rolloutGVK:                           "spec.template",
+				cloneSetGVK:                          "spec.workload.template",
+				{Version: "v1", Kind: "PodTemplate"}: "template",
+			},
+		},
+		{name: "no path", paths: ptr("argoproj.io/v1alpha1/Rollout"), wantErr: true},
+		{name: "empty path", paths: ptr("argoproj.io/v1alpha1/Rollout="), wantErr: true},
+		{name: "no apiVersion", paths: ptr("Rollout=spec.template"), wantErr: true},
+		{name: "no kind", paths: ptr("argoproj.io/v1alpha1/=spec.template"), wantErr: true},
+		{name: "one bad entry", paths: ptr("argoproj.io/v1alpha1/Rollout=spec.template,CloneSet=spec.template"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, err := ParseTemplatePaths(tt.paths)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, paths)
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func TestControllerPodTemplate(t *testing.T) {
+	template := map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "web"}},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "app", "image": "web:1"}},
+		},
+	}
+	customPaths := map[schema.GroupVersionKind]string{cloneSetGVK: "spec.workload.template"}
+
+	tests := []struct {
+		name          string
+		controller    Controller
+		wantContainer string
+		wantErr       bool
+	}{
+		{name: "not read", controller: Controller{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout"}},
+		{
+			name:          "default path",
+			controller:    Controller{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Object: ownerObject(rolloutGVK, "web", "r1", nil, map[string]interface{}{"template": template})},
+			wantContainer: "app",
+		},
+		{
+			name:          "custom path",
+			controller:    Controller{APIVersion: "apps.kruise.io/v1alpha1", Kind: "CloneSet", Object: ownerObject(cloneSetGVK, "web", "c1", nil, map[string]interface{}{"workload": map[string]interface{}{"template": template}})},
+			wantContainer: "app",
+		},
+		{
+			name:       "nothing at the custom path",
+			controller: Controller{APIVersion: "apps.kruise.io/v1alpha1", Kind: "CloneSet", Object: ownerObject(cloneSetGVK, "web", "c1", nil, map[string]interface{}{"template": template})},
+		},
+		{
+			name: "unreadable template",
+			controller: Controller{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Object: ownerObject(rolloutGVK, "web", "r1", nil, map[string]interface{}{
+				"template": map[string]interface{}{"spec": map[string]interface{}{"containers": "app"}},
+			})},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			podTemplate, err := tt.controller.PodTemplate(customPaths)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.wantContainer == "" {
+				assert.Nil(t, podTemplate)
+				return
+			}
+			if assert.NotNil(t, podTemplate) && assert.Len(t, podTemplate.Spec.Containers, 1) {
+				assert.Equal(t, tt.wantContainer, podTemplate.Spec.Containers[0].Name)
+				assert.Equal(t, "web", podTemplate.Labels["app"])
+			}
+		})
+	}
+}
+
+func TestResolveController(t *testing.T) {
+	rolloutRef := controllerRef(rolloutGVK, "web", "r1")
+	replicaSetRef := controllerRef(replicaSetGVK, "web-6d4f", "rs1")
+	orphanReplicaSetRef := controllerRef(replicaSetGVK, "api-7c9b", "rs2")
+	replacedReplicaSetRef := controllerRef(replicaSetGVK, "worker-5b8d", "rs3-old")
+	cloneSetRef := controllerRef(cloneSetGVK, "cache", "c1")
+	unmappedRef := controllerRef(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Unknown"}, "thing", "u1")
+	goneRolloutRef := controllerRef(rolloutGVK, "api", "r2")
+
+	k := testKubernetes(
+		ownerObject(rolloutGVK, "web", "r1", nil, map[string]interface{}{"replicas": int64(3)}),
+		ownerObject(replicaSetGVK, "web-6d4f", "rs1", &rolloutRef, nil),
+		// owned by a rollout that is gone
+		ownerObject(replicaSetGVK, "api-7c9b", "rs2", &goneRolloutRef, nil),
+		ownerObject(replicaSetGVK, "worker-5b8d", "rs3", nil, nil),
+	)
+	pod := func(owner *metav1.OwnerReference) corev1.Pod {
+		p := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "pod"}}
+		if owner != nil {
+			p.OwnerReferences = []metav1.OwnerReference{*owner}
+		}
+		return p
+	}
+
+	tests := []struct {
+		name       string
+		pod        corev1.Pod
+		wantKind   string
+		wantName   string
+		wantUID    types.UID
+		wantObject bool
+	}{
+		{name: "no controller", pod: pod(nil)},
+		{name: "rollout through its replica set", pod: pod(&replicaSetRef), wantKind: "Rollout", wantName: "web", wantUID: "r1", wantObject: true},
+		{name: "unreadable owner ends the walk", pod: pod(&orphanReplicaSetRef), wantKind: "Rollout", wantName: "api", wantUID: "r2"},
+		{name: "unreadable direct controller", pod: pod(&cloneSetRef), wantKind: "CloneSet", wantName: "cache", wantUID: "c1"},
+		{name: "kind the cluster doesn't serve", pod: pod(&unmappedRef), wantKind: "Unknown", wantName: "thing", wantUID: "u1"},
+		{name: "replaced owner", pod: pod(&replacedReplicaSetRef), wantKind: "ReplicaSet", wantName: "worker-5b8d", wantUID: "rs3-old"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := k.ResolveController(context.Background(), tt.pod)
+			if tt.wantKind == "" {
+				assert.Nil(t, controller)
+				return
+			}
+			if !assert.NotNil(t, controller) {
+				return
+			}
+			assert.Equal(t, tt.wantKind, controller.Kind)
+			assert.Equal(t, tt.wantName, controller.Name)
+			assert.Equal(t, tt.wantUID, controller.UID)
+			assert.Equal(t, "shop", controller.Namespace)
+			assert.Equal(t, tt.wantObject, controller.Object != nil)
+		})
+	}
+
+	t.Run("replicas of the top controller", func(t *testing.T) {
+		controller := k.ResolveController(context.Background(), pod(&replicaSetRef))
+		replicas, ok := controller.Replicas()
+		assert.True(t, ok)
+		assert.Equal(t, int32(3), replicas)
+	})
+
+	t.Run("owners can't be read", func(t *testing.T) {
+		controller := NewKubernetesFromObjects(k.Now()).ResolveController(context.Background(), pod(&replicaSetRef))
+		if assert.NotNil(t, controller) {
+			assert.Equal(t, "ReplicaSet", controller.Kind)
+			assert.Nil(t, controller.Object)
+		}
+	})
+}
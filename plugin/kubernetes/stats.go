@@ -0,0 +1,57 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StatsSummary is the subset of the kubelet /stats/summary response (k8s.io/kubelet/pkg/apis/stats/v1alpha1) the plugin uses.
+type StatsSummary struct {
+	Pods []PodStats `json:"pods"`
+}
+
+type PodStats struct {
+	PodRef     PodReference     `json:"podRef"`
+	Containers []ContainerStats `json:"containers"`
+}
+
+type PodReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type ContainerStats struct {
+	Name   string       `json:"name"`
+	CPU    *CPUStats    `json:"cpu,omitempty"`
+	Memory *MemoryStats `json:"memory,omitempty"`
+}
+
+type CPUStats struct {
+	Time           metav1.Time `json:"time"`
+	UsageNanoCores *uint64     `json:"usageNanoCores,omitempty"`
+}
+
+type MemoryStats struct {
+	Time            metav1.Time `json:"time"`
+	WorkingSetBytes *uint64     `json:"workingSetBytes,omitempty"`
+}
+
+func (s *Kubernetes) GetNodeStatsSummary(ctx context.Context, nodeName string) (*StatsSummary, error) {
+	raw, err := s.clientset.CoreV1().RESTClient().Get().
+		Resource("nodes").
+		Name(nodeName).
+		SubResource("proxy").
+		Suffix("stats/summary").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary StatsSummary
+	err = json.Unmarshal(raw, &summary)
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
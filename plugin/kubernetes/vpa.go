@@ -0,0 +1,99 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var VerticalPodAutoscalerGroupVersion = schema.GroupVersion{Group: "autoscaling.k8s.io", Version: "v1"}
+
+var verticalPodAutoscalerResource = VerticalPodAutoscalerGroupVersion.WithResource("verticalpodautoscalers")
+
+// VerticalPodAutoscaler is the part of an autoscaling.k8s.io/v1 VerticalPodAutoscaler the processors read,
+// the VPA types live outside client-go so the object is read with the dynamic client.
+type VerticalPodAutoscaler struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec struct {
+		TargetRef    *autoscalingv1.CrossVersionObjectReference `json:"targetRef,omitempty"`
+		UpdatePolicy *struct {
+			UpdateMode string `json:"updateMode,omitempty"`
+		} `json:"updatePolicy,omitempty"`
+	} `json:"spec"`
+	Status struct {
+		Recommendation *struct {
+			ContainerRecommendations []VerticalPodAutoscalerContainerRecommendation `json:"containerRecommendations,omitempty"`
+		} `json:"recommendation,omitempty"`
+	} `json:"status,omitempty"`
+}
+
+type VerticalPodAutoscalerContainerRecommendation struct {
+	ContainerName  string              `json:"containerName"`
+	Target         corev1.ResourceList `json:"target"`
+	LowerBound     corev1.ResourceList `json:"lowerBound,omitempty"`
+	UpperBound     corev1.ResourceList `json:"upperBound,omitempty"`
+	UncappedTarget corev1.ResourceList `json:"uncappedTarget,omitempty"`
+}
+
+// ContainerRecommendation returns the recommendation of the container, nil when the VPA has none for it yet.
+func (v *VerticalPodAutoscaler) ContainerRecommendation(containerName string) *VerticalPodAutoscalerContainerRecommendation {
+	if v == nil || v.Status.Recommendation == nil {
+		return nil
+	}
+	for i, r := range v.Status.Recommendation.ContainerRecommendations {
+		if r.ContainerName == containerName {
+			return &v.Status.Recommendation.ContainerRecommendations[i]
+		}
+	}
+	return nil
+}
+
+// ListVerticalPodAutoscalersInNamespace lists the VPAs of the namespace, none when the VPA CRDs are not installed or owners can't be read.
+func (s *Kubernetes) ListVerticalPodAutoscalersInNamespace(ctx context.Context, namespace string) ([]VerticalPodAutoscaler, error) {
+	if !s.CanResolveControllers() {
+		return nil, nil
+	}
+	list, err := s.dynamicClient.Resource(verticalPodAutoscalerResource).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	vpas := make([]VerticalPodAutoscaler, 0, len(list.Items))
+	for _, item := range list.Items {
+		var vpa VerticalPodAutoscaler
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &vpa)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vertical pod autoscaler %s: %v", item.GetName(), err)
+		}
+		vpas = append(vpas, vpa)
+	}
+	return vpas, nil
+}
+
+// FindVerticalPodAutoscaler returns the VPA targeting the workload of the given group, kind and name, nil if there is none.
+func FindVerticalPodAutoscaler(vpas []VerticalPodAutoscaler, group, kind, name string) *VerticalPodAutoscaler {
+	for i, vpa := range vpas {
+		ref := vpa.Spec.TargetRef
+		if ref == nil {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil || gv.Group != group {
+			continue
+		}
+		if ref.Kind == kind && ref.Name == name {
+			return &vpas[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package kubernetes
+
+import (
+	"github.com/stretchr/testify/assert"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func targetingVPA(name, apiVersion, kind, targetName string) VerticalPodAutoscaler {
+	vpa := VerticalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	vpa.Spec.TargetRef = &autoscalingv1.CrossVersionObjectReference{APIVersion: apiVersion, Kind: kind, Name: targetName}
+	return vpa
+}
+
+func TestFindVerticalPodAutoscaler(t *testing.T) {
+	vpas := []VerticalPodAutoscaler{
+		{ObjectMeta: metav1.ObjectMeta{Name: "no-target"}},
+		targetingVPA("rollout", "argoproj.io/v1alpha1", "Rollout", "web"),
+		targetingVPA("deployment", "apps/v1", "Deployment", "web"),
+		targetingVPA("invalid", "apps/v1/extra", "Deployment", "api"),
+		targetingVPA("core", "v1", "ReplicationController", "web"),
+	}
+
+	tests := []struct {
+		name  string
+		group string
+		kind  string
+		named string
+		want  string
+	}{
+		{name: "apps group", group: "apps", kind: "Deployment", named: "web", want: "deployment"},
+		{name: "custom group", group: "argoproj.io", kind: "Rollout", named: "web", want: "rollout"},
+		{name: "core group", group: "", kind: "ReplicationController", named: "web", want: "core"},
+		{name: "other group", group: "apps.kruise.io", kind: "Deployment", named: "web"},
+		{name: "other kind", group: "apps", kind: "StatefulSet", named: "web"},
+		{name: "other name", group: "apps", kind: "Deployment", named: "worker"},
+		{name: "unparsable apiVersion", group: "apps", kind: "Deployment", named: "api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vpa := FindVerticalPodAutoscaler(vpas, tt.group, tt.kind, tt.named)
+			if tt.want == "" {
+				assert.Nil(t, vpa)
+				return
+			}
+			if assert.NotNil(t, vpa) {
+				assert.Equal(t, tt.want, vpa.Name)
+			}
+		})
+	}
+}
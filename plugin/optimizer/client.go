@@ -0,0 +1,106 @@
+package optimizer
+
+import (
+	"context"
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LocalClient computes recommendations in-process from the collected metrics, without calling the backend.
+type LocalClient struct{}
+
+func NewLocalClient() golang2.OptimizationClient {
+	return &LocalClient{}
+}
+
+func (c *LocalClient) KubernetesPodOptimization(_ context.Context, in *golang2.KubernetesPodOptimizationRequest, _ ...grpc.CallOption) (*golang2.KubernetesPodOptimizationResponse, error) {
+	prefs := parsePreferences(in.GetPreferences())
+
+	rightsizing := &golang2.KubernetesPodRightsizingRecommendation{
+		Name: in.GetPod().GetName(),
+	}
+	for _, container := range in.GetPod().GetContainers() {
+		rightsizing.ContainerResizing = append(rightsizing.ContainerResizing,
+			rightsizeContainer(container, []*golang2.KubernetesContainerMetrics{in.GetMetrics()[container.Name]}, prefs))
+	}
+
+	return &golang2.KubernetesPodOptimizationResponse{
+		Rightsizing: rightsizing,
+	}, nil
+}
+
+func (c *LocalClient) KubernetesDeploymentOptimization(_ context.Context, in *golang2.KubernetesDeploymentOptimizationRequest, _ ...grpc.CallOption) (*golang2.KubernetesDeploymentOptimizationResponse, error) {
+	containerResizing, podContainerResizing := rightsizeWorkload(in.GetDeployment().GetContainers(), in.GetMetrics(), parsePreferences(in.GetPreferences()))
+	return &golang2.KubernetesDeploymentOptimizationResponse{
+		Rightsizing: &golang2.KubernetesDeploymentRightsizingRecommendation{
+			Name:                 in.GetDeployment().GetName(),
+			ContainerResizing:    containerResizing,
+			PodContainerResizing: podContainerResizing,
+		},
+	}, nil
+}
+
+func (c *LocalClient) KubernetesStatefulsetOptimization(_ context.Context, in *golang2.KubernetesStatefulsetOptimizationRequest, _ ...grpc.CallOption) (*golang2.KubernetesStatefulsetOptimizationResponse, error) {
+	containerResizing, podContainerResizing := rightsizeWorkload(in.GetStatefulset().GetContainers(), in.GetMetrics(), parsePreferences(in.GetPreferences()))
+	return &golang2.KubernetesStatefulsetOptimizationResponse{
+		Rightsizing: &golang2.KubernetesStatefulsetRightsizingRecommendation{
+			Name:                 in.GetStatefulset().GetName(),
+			ContainerResizing:    containerResizing,
+			PodContainerResizing: podContainerResizing,
+		},
+	}, nil
+}
+
+func (c *LocalClient) KubernetesDaemonsetOptimization(_ context.Context, in *golang2.KubernetesDaemonsetOptimizationRequest, _ ...grpc.CallOption) (*golang2.KubernetesDaemonsetOptimizationResponse, error) {
+	containerResizing, podContainerResizing := rightsizeWorkload(in.GetDaemonset().GetContainers(), in.GetMetrics(), parsePreferences(in.GetPreferences()))
+	return &golang2.KubernetesDaemonsetOptimizationResponse{
+		Rightsizing: &golang2.KubernetesDaemonsetRightsizingRecommendation{
+			Name:                 in.GetDaemonset().GetName(),
+			ContainerResizing:    containerResizing,
+			PodContainerResizing: podContainerResizing,
+		},
+	}, nil
+}
+
+func (c *LocalClient) KubernetesJobOptimization(_ context.Context, in *golang2.KubernetesJobOptimizationRequest, _ ...grpc.CallOption) (*golang2.KubernetesJobOptimizationResponse, error) {
+	containerResizing, podContainerResizing := rightsizeWorkload(in.GetJob().GetContainers(), in.GetMetrics(), parsePreferences(in.GetPreferences()))
+	return &golang2.KubernetesJobOptimizationResponse{
+		Rightsizing: &golang2.KubernetesJobRightsizingRecommendation{
+			Name:                 in.GetJob().GetName(),
+			ContainerResizing:    containerResizing,
+			PodContainerResizing: podContainerResizing,
+		},
+	}, nil
+}
+
+// KubernetesNodeGetCost has no pricing data to work with offline, InvalidArgument makes the node job skip the cost.
+func (c *LocalClient) KubernetesNodeGetCost(_ context.Context, _ *golang2.KubernetesNodeGetCostRequest, _ ...grpc.CallOption) (*golang2.KubernetesNodeGetCostResponse, error) {
+	return nil, status.Error(codes.InvalidArgument, "node cost is not available in offline mode")
+}
+
+func rightsizeWorkload(containers []*golang2.KubernetesContainer, metrics map[string]*golang2.KubernetesPodMetrics, prefs rightsizingPreferences) ([]*golang2.KubernetesContainerRightsizingRecommendation, map[string]*golang2.KubernetesPodRightsizingRecommendation) {
+	var containerResizing []*golang2.KubernetesContainerRightsizingRecommendation
+	for _, container := range containers {
+		var containerMetrics []*golang2.KubernetesContainerMetrics
+		for _, podMetrics := range metrics {
+			containerMetrics = append(containerMetrics, podMetrics.GetMetrics()[container.Name])
+		}
+		containerResizing = append(containerResizing, rightsizeContainer(container, containerMetrics, prefs))
+	}
+
+	podContainerResizing := make(map[string]*golang2.KubernetesPodRightsizingRecommendation)
+	for podName, podMetrics := range metrics {
+		podRightsizing := &golang2.KubernetesPodRightsizingRecommendation{
+			Name: podName,
+		}
+		for _, container := range containers {
+			podRightsizing.ContainerResizing = append(podRightsizing.ContainerResizing,
+				rightsizeContainer(container, []*golang2.KubernetesContainerMetrics{podMetrics.GetMetrics()[container.Name]}, prefs))
+		}
+		podContainerResizing[podName] = podRightsizing
+	}
+
+	return containerResizing, podContainerResizing
+}
@@ -0,0 +1,188 @@
+package optimizer
+
+import (
+	"fmt"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/preferences"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"math"
+	"sort"
+	"strconv"
+)
+
+const (
+	RequestPercentile = 0.95
+	TrimmedMeanCut    = 0.01
+)
+
+type rightsizingPreferences struct {
+	cpuRequestBreathingRoom    float64
+	cpuLimitBreathingRoom      float64
+	memoryRequestBreathingRoom float64
+	memoryLimitBreathingRoom   float64
+	minCpuRequest              float64 // cores
+	minMemoryRequest           float64 // bytes
+	leaveCPULimitEmpty         bool
+	equalMemoryRequestLimit    bool
+}
+
+func preferenceValue(prefs map[string]*wrapperspb.StringValue, key string) string {
+	if v, ok := prefs[key]; ok && v != nil {
+		return v.GetValue()
+	}
+	for _, p := range preferences.DefaultKubernetesPreferences {
+		if p.Key == key && p.Value != nil {
+			return p.Value.GetValue()
+		}
+	}
+	return ""
+}
+
+func preferenceFloat(prefs map[string]*wrapperspb.StringValue, key string) float64 {
+	f, err := strconv.ParseFloat(preferenceValue(prefs, key), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+func preferenceBool(prefs map[string]*wrapperspb.StringValue, key string) bool {
+	b, err := strconv.ParseBool(preferenceValue(prefs, key))
+	if err != nil {
+		return false
+	}
+	return b
+}
+
+func parsePreferences(prefs map[string]*wrapperspb.StringValue) rightsizingPreferences {
+	return rightsizingPreferences{
+		cpuRequestBreathingRoom:    preferenceFloat(prefs, "CPURequestBreathingRoom") / 100.0,
+		cpuLimitBreathingRoom:      preferenceFloat(prefs, "CPULimitBreathingRoom") / 100.0,
+		memoryRequestBreathingRoom: preferenceFloat(prefs, "MemoryRequestBreathingRoom") / 100.0,
+		memoryLimitBreathingRoom:   preferenceFloat(prefs, "MemoryLimitBreathingRoom") / 100.0,
+		minCpuRequest:              preferenceFloat(prefs, "MinCpuRequest"),
+		minMemoryRequest:           preferenceFloat(prefs, "MinMemoryRequest") * 1024 * 1024,
+		leaveCPULimitEmpty:         preferenceBool(prefs, "LeaveCPULimitEmpty"),
+		equalMemoryRequestLimit:    preferenceBool(prefs, "EqualMemoryRequestLimit"),
+	}
+}
+
+func values(series ...map[string]float64) []float64 {
+	var result []float64
+	for _, s := range series {
+		for _, v := range s {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			result = append(result, v)
+		}
+	}
+	sort.Float64s(result)
+	return result
+}
+
+// percentile expects sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+// trimmedMean expects sorted values.
+func trimmedMean(sorted []float64, cut float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	n := int(float64(len(sorted)) * cut)
+	trimmed := sorted[n : len(sorted)-n]
+	if len(trimmed) == 0 {
+		trimmed = sorted
+	}
+	total := 0.0
+	for _, v := range trimmed {
+		total += v
+	}
+	return total / float64(len(trimmed))
+}
+
+func rightsizeContainer(container *golang2.KubernetesContainer, metrics []*golang2.KubernetesContainerMetrics, prefs rightsizingPreferences) *golang2.KubernetesContainerRightsizingRecommendation {
+	current := &golang2.RightsizingKubernetesContainer{
+		Name:          container.Name,
+		MemoryRequest: container.MemoryRequest,
+		MemoryLimit:   container.MemoryLimit,
+		CpuRequest:    container.CpuRequest,
+		CpuLimit:      container.CpuLimit,
+	}
+	result := &golang2.KubernetesContainerRightsizingRecommendation{
+		Name:    container.Name,
+		Current: current,
+	}
+
+	var cpuSeries, memorySeries, throttlingSeries []map[string]float64
+	for _, m := range metrics {
+		if m == nil {
+			continue
+		}
+		cpuSeries = append(cpuSeries, m.Cpu)
+		memorySeries = append(memorySeries, m.Memory)
+		throttlingSeries = append(throttlingSeries, m.CpuThrottling)
+	}
+	cpu := values(cpuSeries...)
+	memory := values(memorySeries...)
+	throttling := values(throttlingSeries...)
+	if len(cpu) == 0 && len(memory) == 0 {
+		result.Description = "No usage data was found for this container, keeping the current configuration."
+		return result
+	}
+
+	recommended := &golang2.RightsizingKubernetesContainer{
+		Name:          container.Name,
+		MemoryRequest: current.MemoryRequest,
+		MemoryLimit:   current.MemoryLimit,
+		CpuRequest:    current.CpuRequest,
+		CpuLimit:      current.CpuLimit,
+	}
+
+	throttled := len(throttling) > 0 && percentile(throttling, RequestPercentile) >= shared.HeavyCpuThrottlingRatio
+	if len(cpu) > 0 {
+		cpuMax := cpu[len(cpu)-1]
+		result.CpuTrimmedMean = wrapperspb.Double(trimmedMean(cpu, TrimmedMeanCut))
+		result.CpuMax = wrapperspb.Double(cpuMax)
+
+		cpuUsage := percentile(cpu, RequestPercentile)
+		if throttled {
+			// the usage of a throttled container is capped by its limit and understates what it needs, size the request on its peak instead
+			cpuUsage = cpuMax
+		}
+		recommended.CpuRequest = math.Max(cpuUsage*(1+prefs.cpuRequestBreathingRoom), prefs.minCpuRequest)
+		recommended.CpuLimit = math.Max(cpuMax*(1+prefs.cpuLimitBreathingRoom), recommended.CpuRequest)
+	}
+	if prefs.leaveCPULimitEmpty {
+		recommended.CpuLimit = 0
+	}
+
+	if len(memory) > 0 {
+		memoryMax := memory[len(memory)-1]
+		result.MemoryTrimmedMean = wrapperspb.Double(trimmedMean(memory, TrimmedMeanCut))
+		result.MemoryMax = wrapperspb.Double(memoryMax)
+
+		recommended.MemoryRequest = math.Max(percentile(memory, RequestPercentile)*(1+prefs.memoryRequestBreathingRoom), prefs.minMemoryRequest)
+		recommended.MemoryLimit = math.Max(memoryMax*(1+prefs.memoryLimitBreathingRoom), recommended.MemoryRequest)
+	}
+	if prefs.equalMemoryRequestLimit && recommended.MemoryLimit > 0 {
+		recommended.MemoryRequest = recommended.MemoryLimit
+	}
+
+	result.Recommended = recommended
+	result.Description = fmt.Sprintf("Requests are sized on the p%.0f of usage and limits on the maximum usage, including the configured breathing room.", RequestPercentile*100)
+	if throttled && len(cpu) > 0 {
+		result.Description += fmt.Sprintf("\nThe CPU request is sized on the maximum usage since the container is throttled in %.0f%% of CFS periods (p%.0f).", percentile(throttling, RequestPercentile)*100, RequestPercentile*100)
+	}
+	return result
+}
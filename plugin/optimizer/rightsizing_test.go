@@ -0,0 +1,197 @@
+package optimizer
+
+import (
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"testing"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name   string
+		sorted []float64
+		p      float64
+		want   float64
+	}{
+		{name: "empty", sorted: nil, p: 0.95, want: 0},
+		{name: "single value", sorted: []float64{3}, p: 0.95, want: 3},
+		{name: "p95 of ten", sorted: []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, p: 0.95, want: 10},
+		{name: "p50 of ten", sorted: []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, p: 0.5, want: 5},
+		{name: "p0 is the minimum", sorted: []float64{1, 2, 3}, p: 0, want: 1},
+		{name: "p100 is the maximum", sorted: []float64{1, 2, 3}, p: 1, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, percentile(tt.sorted, tt.p))
+		})
+	}
+}
+
+func TestTrimmedMean(t *testing.T) {
+	hundred := make([]float64, 100)
+	for i := range hundred {
+		hundred[i] = 1
+	}
+	hundred[0] = -1000
+	hundred[99] = 1000
+
+	tests := []struct {
+		name   string
+		sorted []float64
+		cut    float64
+		want   float64
+	}{
+		{name: "empty", sorted: nil, cut: 0.01, want: 0},
+		{name: "too few values to trim", sorted: []float64{1, 2, 3}, cut: 0.01, want: 2},
+		{name: "outliers are trimmed", sorted: hundred, cut: 0.01, want: 1},
+		{name: "trimming everything falls back to the plain mean", sorted: []float64{1, 3}, cut: 0.5, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, trimmedMean(tt.sorted, tt.cut), 1e-9)
+		})
+	}
+}
+
+func series(values ...float64) map[string]float64 {
+	result := make(map[string]float64)
+	for i, v := range values {
+		result[string(rune('a'+i))] = v
+	}
+	return result
+}
+
+func TestRightsizeContainer(t *testing.T) {
+	defaultPrefs := parsePreferences(nil)
+	// p95 of 0.5 with a single peak of 1
+	spike := series(0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 1)
+	container := &golang2.KubernetesContainer{
+		Name:          "app",
+		CpuRequest:    2,
+		CpuLimit:      4,
+		MemoryRequest: 2 * 1024 * 1024 * 1024,
+		MemoryLimit:   4 * 1024 * 1024 * 1024,
+	}
+
+	tests := []struct {
+		name    string
+		metrics []*golang2.KubernetesContainerMetrics
+		prefs   rightsizingPreferences
+
+		wantRecommended   bool
+		wantCpuRequest    float64
+		wantCpuLimit      float64
+		wantMemoryRequest float64
+		wantMemoryLimit   float64
+	}{
+		{
+			name:            "no usage keeps the current configuration",
+			metrics:         []*golang2.KubernetesContainerMetrics{nil},
+			prefs:           defaultPrefs,
+			wantRecommended: false,
+		},
+		{
+			name: "requests on p95 and limits on max with breathing room",
+			metrics: []*golang2.KubernetesContainerMetrics{{
+				Cpu:    spike,
+				Memory: series(1024*1024*1024, 2*1024*1024*1024),
+			}},
+			prefs:             defaultPrefs,
+			wantRecommended:   true,
+			wantCpuRequest:    0.55,
+			wantCpuLimit:      1.1,
+			wantMemoryRequest: 2 * 1024 * 1024 * 1024 * 1.1,
+			wantMemoryLimit:   2 * 1024 * 1024 * 1024 * 1.1,
+		},
+		{
+			name: "min cpu request is in cores",
+			metrics: []*golang2.KubernetesContainerMetrics{{
+				Cpu:    series(0.001, 0.001),
+				Memory: series(1024 * 1024 * 1024),
+			}},
+			prefs:             defaultPrefs,
+			wantRecommended:   true,
+			wantCpuRequest:    0.1,
+			wantCpuLimit:      0.1,
+			wantMemoryRequest: 1024 * 1024 * 1024 * 1.1,
+			wantMemoryLimit:   1024 * 1024 * 1024 * 1.1,
+		},
+		{
+			name: "throttled container is sized on its peak usage",
+			metrics: []*golang2.KubernetesContainerMetrics{{
+				Cpu:           spike,
+				Memory:        series(1024 * 1024 * 1024),
+				CpuThrottling: series(0.5, 0.6),
+			}},
+			prefs:             defaultPrefs,
+			wantRecommended:   true,
+			wantCpuRequest:    1.1,
+			wantCpuLimit:      1.1,
+			wantMemoryRequest: 1024 * 1024 * 1024 * 1.1,
+			wantMemoryLimit:   1024 * 1024 * 1024 * 1.1,
+		},
+		{
+			name: "light throttling is ignored",
+			metrics: []*golang2.KubernetesContainerMetrics{{
+				Cpu:           spike,
+				Memory:        series(1024 * 1024 * 1024),
+				CpuThrottling: series(0.01, 0.02),
+			}},
+			prefs:             defaultPrefs,
+			wantRecommended:   true,
+			wantCpuRequest:    0.55,
+			wantCpuLimit:      1.1,
+			wantMemoryRequest: 1024 * 1024 * 1024 * 1.1,
+			wantMemoryLimit:   1024 * 1024 * 1024 * 1.1,
+		},
+		{
+			name: "series of several pods are merged",
+			metrics: []*golang2.KubernetesContainerMetrics{
+				{Cpu: series(0.2), Memory: series(1024 * 1024 * 1024)},
+				{Cpu: series(1), Memory: series(1024 * 1024 * 1024)},
+			},
+			prefs:             defaultPrefs,
+			wantRecommended:   true,
+			wantCpuRequest:    1.1,
+			wantCpuLimit:      1.1,
+			wantMemoryRequest: 1024 * 1024 * 1024 * 1.1,
+			wantMemoryLimit:   1024 * 1024 * 1024 * 1.1,
+		},
+		{
+			name: "empty cpu limit and equal memory request and limit",
+			metrics: []*golang2.KubernetesContainerMetrics{{
+				Cpu:    series(0.5),
+				Memory: series(1024*1024*1024, 2*1024*1024*1024),
+			}},
+			prefs: parsePreferences(map[string]*wrapperspb.StringValue{
+				"LeaveCPULimitEmpty":       wrapperspb.String("true"),
+				"EqualMemoryRequestLimit":  wrapperspb.String("true"),
+				"MemoryLimitBreathingRoom": wrapperspb.String("50"),
+			}),
+			wantRecommended:   true,
+			wantCpuRequest:    0.55,
+			wantCpuLimit:      0,
+			wantMemoryRequest: 3 * 1024 * 1024 * 1024,
+			wantMemoryLimit:   3 * 1024 * 1024 * 1024,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := rightsizeContainer(container, tt.metrics, tt.prefs)
+			assert.Equal(t, "app", result.Name)
+			assert.Equal(t, container.CpuRequest, result.Current.CpuRequest)
+			if !tt.wantRecommended {
+				assert.Nil(t, result.Recommended)
+				return
+			}
+			if !assert.NotNil(t, result.Recommended) {
+				return
+			}
+			assert.InDelta(t, tt.wantCpuRequest, result.Recommended.CpuRequest, 1e-9)
+			assert.InDelta(t, tt.wantCpuLimit, result.Recommended.CpuLimit, 1e-9)
+			assert.InDelta(t, tt.wantMemoryRequest, result.Recommended.MemoryRequest, 1)
+			assert.InDelta(t, tt.wantMemoryLimit, result.Recommended.MemoryLimit, 1)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
 	"github.com/kaytu-io/kaytu/pkg/utils"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/cronjobs"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/daemonsets"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/deployments"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/jobs"
@@ -12,6 +13,8 @@ import (
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/simulation"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/statefulsets"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/workloads"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"strconv"
 )
 
@@ -27,6 +30,8 @@ type Processor struct {
 	deploymentsProcessor  *deployments.Processor
 	statefulsetsProcessor *statefulsets.Processor
 	jobsProcessor         *jobs.Processor
+	cronjobsProcessor     *cronjobs.Processor
+	workloadsProcessor    *workloads.Processor
 	podsProcessor         *pods.Processor
 	schedulingSim         *simulation.SchedulerService
 	schedulingSimPrev     *simulation.SchedulerService
@@ -49,6 +54,10 @@ func (p *Processor) publishResultSummaryFunc(kuberType string) {
 		_, resourceSummary = shared.GetAggregatedResultsSummary(p.statefulsetsProcessor.GetSummaryMap())
 	case "job":
 		_, resourceSummary = shared.GetAggregatedResultsSummary(p.jobsProcessor.GetSummaryMap())
+	case "cronjob":
+		_, resourceSummary = shared.GetAggregatedResultsSummary(p.cronjobsProcessor.GetSummaryMap())
+	case "workload":
+		_, resourceSummary = shared.GetAggregatedResultsSummary(p.workloadsProcessor.GetSummaryMap())
 	case "pod":
 		_, resourceSummary = shared.GetAggregatedResultsSummary(p.podsProcessor.GetSummaryMap())
 	}
@@ -63,19 +72,24 @@ func (p *Processor) publishResultSummaryTableFunc(kuberType string) {
 	var resourceSummary *shared.ResourceSummary
 	switch kuberType {
 	case "daemonset":
-		_, resourceSummary = shared.GetAggregatedResultsSummaryTable(p.daemonsetsProcessor.GetSummaryMap(), nil, nil, nil)
+		_, resourceSummary = shared.GetAggregatedResultsSummaryTable(p.daemonsetsProcessor.GetSummaryMap(), nil, nil, nil, nil)
 	case "deployment":
-		_, resourceSummary = shared.GetAggregatedResultsSummaryTable(p.deploymentsProcessor.GetSummaryMap(), nil, nil, nil)
+		_, resourceSummary = shared.GetAggregatedResultsSummaryTable(p.deploymentsProcessor.GetSummaryMap(), nil, nil, nil, nil)
 	case "statefulset":
-		_, resourceSummary = shared.GetAggregatedResultsSummaryTable(p.statefulsetsProcessor.GetSummaryMap(), nil, nil, nil)
+		_, resourceSummary = shared.GetAggregatedResultsSummaryTable(p.statefulsetsProcessor.GetSummaryMap(), nil, nil, nil, nil)
 	case "job":
-		_, resourceSummary = shared.GetAggregatedResultsSummaryTable(p.jobsProcessor.GetSummaryMap(), nil, nil, nil)
+		_, resourceSummary = shared.GetAggregatedResultsSummaryTable(p.jobsProcessor.GetSummaryMap(), nil, nil, nil, nil)
+	case "cronjob":
+		_, resourceSummary = shared.GetAggregatedResultsSummaryTable(p.cronjobsProcessor.GetSummaryMap(), nil, nil, nil, nil)
+	case "workload":
+		_, resourceSummary = shared.GetAggregatedResultsSummaryTable(p.workloadsProcessor.GetSummaryMap(), nil, nil, nil, nil)
 	case "pod":
-		_, resourceSummary = shared.GetAggregatedResultsSummaryTable(p.podsProcessor.GetSummaryMap(), nil, nil, nil)
+		_, resourceSummary = shared.GetAggregatedResultsSummaryTable(p.podsProcessor.GetSummaryMap(), nil, nil, nil, nil)
 	}
 	if resourceSummary != nil {
 		p.summary.Set(kuberType, *resourceSummary)
 		var nds, ndsPrev, cluster []shared.KubernetesNode
+		var nodeShapes []shared.NodePoolProposal
 		if (p.processorConf.Namespace == nil ||
 			*p.processorConf.Namespace == "") && p.processorConf.Selector == "" && p.processorConf.NodeSelector == "" {
 			var err error
@@ -90,6 +104,11 @@ func (p *Processor) publishResultSummaryTableFunc(kuberType string) {
 			if err != nil {
 				fmt.Println("failed to simulate prev due to", err)
 			}
+
+			nodeShapes, err = p.schedulingSim.ProposeNodeShapes(p.processorConf.PricingCatalog)
+			if err != nil {
+				fmt.Println("failed to propose node shapes due to", err)
+			}
 		} else {
 			fmt.Println(
 				"++++++++++++++++",
@@ -99,7 +118,9 @@ func (p *Processor) publishResultSummaryTableFunc(kuberType string) {
 			)
 		}
 
-		rs, _ := shared.GetAggregatedResultsSummaryTable(&p.summary, cluster, nds, ndsPrev)
+		quotas := p.nodesProcessor.GetResourceQuotas()
+		rs, _ := shared.GetAggregatedResultsSummaryTable(&p.summary, cluster, nds, ndsPrev, quotas)
+		rs.Message = append(rs.Message, shared.NodeShapeRows(nodeShapes)...)
 		p.publishResultSummaryTable(rs)
 	}
 }
@@ -180,6 +201,44 @@ func (p *Processor) initJobProcessor(processorConf shared.Configuration) *jobs.P
 	return pi
 }
 
+func (p *Processor) initCronJobProcessor(processorConf shared.Configuration) *cronjobs.Processor {
+	publishOptimizationItem := func(item *golang.ChartOptimizationItem) {
+		p.publishOptimizationItemFunc(item, "cronjob")
+	}
+	publishResultSummary := func(_ *golang.ResultSummary) {
+		p.publishResultSummaryFunc("cronjob")
+	}
+	publishResultSummaryTable := func(_ *golang.ResultSummaryTable) {
+		p.publishResultSummaryTableFunc("cronjob")
+	}
+
+	processorConf.PublishOptimizationItem = publishOptimizationItem
+	processorConf.PublishResultSummary = publishResultSummary
+	processorConf.PublishResultSummaryTable = publishResultSummaryTable
+	pi := cronjobs.NewProcessor(processorConf, p.nodesProcessor)
+	pi.SetSchedulingSim(p.schedulingSim, p.schedulingSimPrev)
+	return pi
+}
+
+func (p *Processor) initWorkloadProcessor(processorConf shared.Configuration) *workloads.Processor {
+	publishOptimizationItem := func(item *golang.ChartOptimizationItem) {
+		p.publishOptimizationItemFunc(item, "workload")
+	}
+	publishResultSummary := func(_ *golang.ResultSummary) {
+		p.publishResultSummaryFunc("workload")
+	}
+	publishResultSummaryTable := func(_ *golang.ResultSummaryTable) {
+		p.publishResultSummaryTableFunc("workload")
+	}
+
+	processorConf.PublishOptimizationItem = publishOptimizationItem
+	processorConf.PublishResultSummary = publishResultSummary
+	processorConf.PublishResultSummaryTable = publishResultSummaryTable
+	pi := workloads.NewProcessor(processorConf, p.nodesProcessor)
+	pi.SetSchedulingSim(p.schedulingSim, p.schedulingSimPrev)
+	return pi
+}
+
 func (p *Processor) initPodProcessor(processorConf shared.Configuration) *pods.Processor {
 	publishOptimizationItem := func(item *golang.ChartOptimizationItem) {
 		p.publishOptimizationItemFunc(item, "pod")
@@ -214,16 +273,27 @@ func NewProcessor(processorConf shared.Configuration, nodesProcessor *nodes.Proc
 
 	p.schedulingSim.SetNodes(nodesProcessor.GetKubernetesNodes())
 	p.schedulingSimPrev.SetNodes(nodesProcessor.GetKubernetesNodes())
+	p.schedulingSim.SetNodePoolMinSizes(processorConf.NodePoolMinSizes)
+	p.schedulingSimPrev.SetNodePoolMinSizes(processorConf.NodePoolMinSizes)
+	processorConf.JobQueue.Push(NewListPodDisruptionBudgetsJob(p))
+	processorConf.JobQueue.Push(NewListUnmanagedPodsJob(p))
+	processorConf.JobQueue.Push(NewListVolumeZonesJob(p))
 
 	p.daemonsetsProcessor = p.initDaemonsetProcessor(processorConf)
 	p.deploymentsProcessor = p.initDeploymentProcessor(processorConf)
 	p.statefulsetsProcessor = p.initStatefulsetProcessor(processorConf)
 	p.jobsProcessor = p.initJobProcessor(processorConf)
+	p.cronjobsProcessor = p.initCronJobProcessor(processorConf)
+	p.workloadsProcessor = p.initWorkloadProcessor(processorConf)
 	p.podsProcessor = p.initPodProcessor(processorConf)
 
 	return p
 }
 
+func (p *Processor) GetSummaryMap() *utils.ConcurrentMap[string, shared.ResourceSummary] {
+	return &p.summary
+}
+
 func (p *Processor) ReEvaluate(id string, items []*golang.PreferenceItem) {
 	nodeCpuBreathingRoom, nodeMemoryBreathingRoom, nodePodCountBreathingRoom := "", "", ""
 	for _, i := range items {
@@ -263,11 +333,48 @@ func (p *Processor) ReEvaluate(id string, items []*golang.PreferenceItem) {
 		p.statefulsetsProcessor.ReEvaluate(id, items)
 	case "job":
 		p.jobsProcessor.ReEvaluate(id, items)
+	case "cronjob":
+		p.cronjobsProcessor.ReEvaluate(id, items)
+	case "workload":
+		p.workloadsProcessor.ReEvaluate(id, items)
 	case "pod":
 		p.podsProcessor.ReEvaluate(id, items)
 	}
 }
 
 func (p *Processor) ExportNonInteractive() *golang.NonInteractiveExport {
-	return nil
+	var rows []*golang.CSVRow
+	rows = append(rows, &golang.CSVRow{Row: shared.ExportCsvHeaders})
+	rows = append(rows, p.daemonsetsProcessor.ExportCsvRows()...)
+	rows = append(rows, p.deploymentsProcessor.ExportCsvRows()...)
+	rows = append(rows, p.statefulsetsProcessor.ExportCsvRows()...)
+	rows = append(rows, p.jobsProcessor.ExportCsvRows()...)
+	rows = append(rows, p.cronjobsProcessor.ExportCsvRows()...)
+	rows = append(rows, p.workloadsProcessor.ExportCsvRows()...)
+	rows = append(rows, p.podsProcessor.ExportCsvRows()...)
+
+	return &golang.NonInteractiveExport{
+		Csv: rows,
+	}
+}
+
+func (p *Processor) ExportWorkloads() []shared.ExportWorkload {
+	var workloads []shared.ExportWorkload
+	workloads = append(workloads, p.daemonsetsProcessor.ExportWorkloads()...)
+	workloads = append(workloads, p.deploymentsProcessor.ExportWorkloads()...)
+	workloads = append(workloads, p.statefulsetsProcessor.ExportWorkloads()...)
+	workloads = append(workloads, p.jobsProcessor.ExportWorkloads()...)
+	workloads = append(workloads, p.cronjobsProcessor.ExportWorkloads()...)
+	workloads = append(workloads, p.workloadsProcessor.ExportWorkloads()...)
+	workloads = append(workloads, p.podsProcessor.ExportWorkloads()...)
+	return workloads
+}
+
+func (p *Processor) ExportVerticalPodAutoscalers() []*unstructured.Unstructured {
+	var vpas []*unstructured.Unstructured
+	vpas = append(vpas, p.daemonsetsProcessor.ExportVerticalPodAutoscalers()...)
+	vpas = append(vpas, p.deploymentsProcessor.ExportVerticalPodAutoscalers()...)
+	vpas = append(vpas, p.statefulsetsProcessor.ExportVerticalPodAutoscalers()...)
+	vpas = append(vpas, p.workloadsProcessor.ExportVerticalPodAutoscalers()...)
+	return vpas
 }
@@ -0,0 +1,128 @@
+package all
+
+import (
+	"context"
+	"fmt"
+	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type ListPodDisruptionBudgetsJob struct {
+	processor *Processor
+}
+
+func NewListPodDisruptionBudgetsJob(processor *Processor) *ListPodDisruptionBudgetsJob {
+	return &ListPodDisruptionBudgetsJob{
+		processor: processor,
+	}
+}
+
+func (j *ListPodDisruptionBudgetsJob) Properties() sdk.JobProperties {
+	return sdk.JobProperties{
+		ID:          "list_pod_disruption_budgets_for_kubernetes_all",
+		Description: "Listing all pod disruption budgets (Kubernetes)",
+		MaxRetry:    0,
+	}
+}
+
+func (j *ListPodDisruptionBudgetsJob) Run(ctx context.Context) error {
+	namespace := ""
+	if j.processor.processorConf.Namespace != nil {
+		namespace = *j.processor.processorConf.Namespace
+	}
+
+	pdbs, err := j.processor.processorConf.KubernetesProvider.ListPodDisruptionBudgetsInNamespace(ctx, namespace)
+	if err != nil {
+		return err
+	}
+
+	// the selector picks workloads, a PDB is kept when it covers the pods of one of them whatever its own labels are
+	if j.processor.processorConf.Selector != "" {
+		pods, err := j.selectedPodLabels(ctx, namespace)
+		if err != nil {
+			return err
+		}
+		pdbs = podDisruptionBudgetsForPods(pdbs, pods)
+	}
+
+	for _, pdb := range pdbs {
+		j.processor.schedulingSim.AddPodDisruptionBudget(pdb)
+		j.processor.schedulingSimPrev.AddPodDisruptionBudget(pdb)
+	}
+	return nil
+}
+
+// selectedPodLabels returns the labels of the pods of the workloads the selector picks, by namespace.
+func (j *ListPodDisruptionBudgetsJob) selectedPodLabels(ctx context.Context, namespace string) (map[string][]map[string]string, error) {
+	provider := j.processor.processorConf.KubernetesProvider
+	selector := j.processor.processorConf.Selector
+	pods := map[string][]map[string]string{}
+	add := func(namespace string, podLabels map[string]string) {
+		pods[namespace] = append(pods[namespace], podLabels)
+	}
+
+	deployments, err := provider.ListDeploymentsInNamespace(ctx, namespace, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %v", err)
+	}
+	for _, d := range deployments {
+		add(d.Namespace, d.Spec.Template.Labels)
+	}
+	statefulsets, err := provider.ListStatefulsetsInNamespace(ctx, namespace, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %v", err)
+	}
+	for _, s := range statefulsets {
+		add(s.Namespace, s.Spec.Template.Labels)
+	}
+	daemonsets, err := provider.ListDaemonsetsInNamespace(ctx, namespace, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %v", err)
+	}
+	for _, d := range daemonsets {
+		add(d.Namespace, d.Spec.Template.Labels)
+	}
+	jobs, err := provider.ListJobsInNamespace(ctx, namespace, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %v", err)
+	}
+	for _, job := range jobs {
+		add(job.Namespace, job.Spec.Template.Labels)
+	}
+	cronJobs, err := provider.ListCronJobsInNamespace(ctx, namespace, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cronjobs: %v", err)
+	}
+	for _, cronJob := range cronJobs {
+		add(cronJob.Namespace, cronJob.Spec.JobTemplate.Spec.Template.Labels)
+	}
+	orphans, err := provider.ListPodsInNamespace(ctx, namespace, selector, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+	for _, pod := range orphans {
+		add(pod.Namespace, pod.Labels)
+	}
+	return pods, nil
+}
+
+// podDisruptionBudgetsForPods keeps the PDBs whose spec.selector matches one of the pods of their namespace.
+func podDisruptionBudgetsForPods(pdbs []policyv1.PodDisruptionBudget, pods map[string][]map[string]string) []policyv1.PodDisruptionBudget {
+	var matching []policyv1.PodDisruptionBudget
+	for _, pdb := range pdbs {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			fmt.Println("failed to parse selector of pod disruption budget", pdb.Namespace+"/"+pdb.Name, "due to", err)
+			continue
+		}
+		for _, podLabels := range pods[pdb.Namespace] {
+			if selector.Matches(labels.Set(podLabels)) {
+				matching = append(matching, pdb)
+				break
+			}
+		}
+	}
+	return matching
+}
@@ -0,0 +1,41 @@
+package all
+
+import (
+	"github.com/stretchr/testify/assert"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func testPodDisruptionBudget(namespace, name string, pdbLabels map[string]string, selector *metav1.LabelSelector) policyv1.PodDisruptionBudget {
+	return policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: pdbLabels},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: selector},
+	}
+}
+
+func TestPodDisruptionBudgetsForPods(t *testing.T) {
+	pods := map[string][]map[string]string{
+		"shop": {{"app": "web", "tier": "frontend"}, {"app": "api"}},
+	}
+	pdbs := []policyv1.PodDisruptionBudget{
+		// labeled like the selected workloads but covering other pods
+		testPodDisruptionBudget("shop", "labeled", map[string]string{"team": "shop"}, &metav1.LabelSelector{MatchLabels: map[string]string{"app": "worker"}}),
+		testPodDisruptionBudget("shop", "web", nil, &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}),
+		testPodDisruptionBudget("shop", "expression", nil, &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"api", "worker"}},
+		}}),
+		testPodDisruptionBudget("shop", "everything", nil, &metav1.LabelSelector{}),
+		testPodDisruptionBudget("shop", "nothing", nil, nil),
+		testPodDisruptionBudget("other", "web", nil, &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}),
+		testPodDisruptionBudget("shop", "invalid", nil, &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: "Unknown"},
+		}}),
+	}
+
+	var names []string
+	for _, pdb := range podDisruptionBudgetsForPods(pdbs, pods) {
+		names = append(names, pdb.Namespace+"/"+pdb.Name)
+	}
+	assert.Equal(t, []string{"shop/web", "shop/expression", "shop/everything"}, names)
+}
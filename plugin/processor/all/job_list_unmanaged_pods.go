@@ -0,0 +1,46 @@
+package all
+
+import (
+	"context"
+	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type ListUnmanagedPodsJob struct {
+	processor *Processor
+}
+
+func NewListUnmanagedPodsJob(processor *Processor) *ListUnmanagedPodsJob {
+	return &ListUnmanagedPodsJob{
+		processor: processor,
+	}
+}
+
+func (j *ListUnmanagedPodsJob) Properties() sdk.JobProperties {
+	return sdk.JobProperties{
+		ID:          "list_unmanaged_pods_for_kubernetes_all",
+		Description: "Listing kube-system and static pods (Kubernetes)",
+		MaxRetry:    0,
+	}
+}
+
+func (j *ListUnmanagedPodsJob) Run(ctx context.Context) error {
+	pods, err := j.processor.processorConf.KubernetesProvider.ListPodsInNamespace(ctx, "", "", false)
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		// kube-system is skipped by the processors, static pods can't be changed through the API
+		if pod.Namespace != "kube-system" && !kaytuKubernetes.IsStaticPod(pod) {
+			continue
+		}
+		j.processor.schedulingSim.AddUnmanagedPod(pod)
+		j.processor.schedulingSimPrev.AddUnmanagedPod(pod)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package all
+
+import (
+	"context"
+	"fmt"
+	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type ListVolumeZonesJob struct {
+	processor *Processor
+}
+
+func NewListVolumeZonesJob(processor *Processor) *ListVolumeZonesJob {
+	return &ListVolumeZonesJob{
+		processor: processor,
+	}
+}
+
+func (j *ListVolumeZonesJob) Properties() sdk.JobProperties {
+	return sdk.JobProperties{
+		ID:          "list_volume_zones_for_kubernetes_all",
+		Description: "Listing zonal persistent volumes (Kubernetes)",
+		MaxRetry:    0,
+	}
+}
+
+func (j *ListVolumeZonesJob) Run(ctx context.Context) error {
+	namespace := ""
+	if j.processor.processorConf.Namespace != nil {
+		namespace = *j.processor.processorConf.Namespace
+	}
+
+	// persistent volumes are cluster scoped, without access to them pods are simulated as if they could move between zones
+	volumes, err := j.processor.processorConf.KubernetesProvider.ListPersistentVolumes(ctx)
+	if err != nil {
+		fmt.Println("failed to list persistent volumes due to", err)
+		return nil
+	}
+	volumeZones := make(map[string]string)
+	for _, pv := range volumes {
+		if zone := shared.VolumeZone(pv); zone != "" {
+			volumeZones[pv.Name] = zone
+		}
+	}
+
+	claims, err := j.processor.processorConf.KubernetesProvider.ListPersistentVolumeClaimsInNamespace(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	for _, claim := range claims {
+		if claim.Status.Phase != corev1.ClaimBound {
+			continue
+		}
+		if zone, ok := volumeZones[claim.Spec.VolumeName]; ok {
+			j.processor.schedulingSim.AddVolumeZone(claim.Namespace, claim.Name, zone)
+			j.processor.schedulingSimPrev.AddVolumeZone(claim.Namespace, claim.Name, zone)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package clusters
+
+import (
+	"fmt"
+	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	"github.com/kaytu-io/kaytu/pkg/utils"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// summaryProcessor is implemented by every processor that keeps a summary of its items.
+type summaryProcessor interface {
+	GetSummaryMap() *utils.ConcurrentMap[string, shared.ResourceSummary]
+}
+
+type clusterItem struct {
+	cluster string
+	id      string
+}
+
+// Processor runs one processor per cluster and merges their output. Item ids are prefixed with the cluster name
+// so items with the same namespace and name in different clusters stay apart.
+type Processor struct {
+	itemsToCluster            utils.ConcurrentMap[string, clusterItem]
+	publishOptimizationItem   func(item *golang.ChartOptimizationItem)
+	publishResultSummary      func(summary *golang.ResultSummary)
+	publishResultSummaryTable func(summary *golang.ResultSummaryTable)
+
+	clusters   []string
+	processors utils.ConcurrentMap[string, processor.Processor]
+	summary    utils.ConcurrentMap[string, shared.ResourceSummary]
+
+	tablesLock sync.Mutex
+	tables     map[string]*golang.ResultSummaryTable
+}
+
+// NewProcessor creates the processor of each cluster with newProcessor, using the configuration given for the cluster.
+func NewProcessor(processorConf shared.Configuration, clusterConfs map[string]shared.Configuration, newProcessor func(conf shared.Configuration) processor.Processor) *Processor {
+	p := &Processor{
+		itemsToCluster:            utils.NewConcurrentMap[string, clusterItem](),
+		publishOptimizationItem:   processorConf.PublishOptimizationItem,
+		publishResultSummary:      processorConf.PublishResultSummary,
+		publishResultSummaryTable: processorConf.PublishResultSummaryTable,
+		processors:                utils.NewConcurrentMap[string, processor.Processor](),
+		summary:                   utils.NewConcurrentMap[string, shared.ResourceSummary](),
+		tables:                    make(map[string]*golang.ResultSummaryTable),
+	}
+
+	for cluster := range clusterConfs {
+		p.clusters = append(p.clusters, cluster)
+	}
+	sort.Strings(p.clusters)
+
+	for _, cluster := range p.clusters {
+		p.processors.Set(cluster, newProcessor(p.clusterConfiguration(cluster, clusterConfs[cluster])))
+	}
+	return p
+}
+
+func (p *Processor) clusterConfiguration(cluster string, conf shared.Configuration) shared.Configuration {
+	conf.PublishOptimizationItem = func(item *golang.ChartOptimizationItem) {
+		p.publishOptimizationItemFunc(cluster, item)
+	}
+	conf.PublishResultSummary = func(_ *golang.ResultSummary) {
+		p.publishResultSummaryFunc(cluster)
+	}
+	conf.PublishResultSummaryTable = func(summary *golang.ResultSummaryTable) {
+		p.publishResultSummaryTableFunc(cluster, summary)
+	}
+	return conf
+}
+
+func (p *Processor) publishOptimizationItemFunc(cluster string, item *golang.ChartOptimizationItem) {
+	if item.OverviewChartRow != nil {
+		id := fmt.Sprintf("%s/%s", cluster, item.OverviewChartRow.RowId)
+		p.itemsToCluster.Set(id, clusterItem{cluster: cluster, id: item.OverviewChartRow.RowId})
+		item.OverviewChartRow.RowId = id
+		if item.OverviewChartRow.Values == nil {
+			item.OverviewChartRow.Values = make(map[string]*golang.ChartRowItem)
+		}
+		item.OverviewChartRow.Values["cluster"] = &golang.ChartRowItem{
+			Value: cluster,
+		}
+	}
+	p.publishOptimizationItem(item)
+}
+
+func (p *Processor) updateClusterSummary(cluster string) {
+	pi, ok := p.processors.Get(cluster)
+	if !ok {
+		return
+	}
+	sp, ok := pi.(summaryProcessor)
+	if !ok {
+		return
+	}
+	_, resourceSummary := shared.GetAggregatedResultsSummary(sp.GetSummaryMap())
+	p.summary.Set(cluster, *resourceSummary)
+}
+
+func (p *Processor) publishResultSummaryFunc(cluster string) {
+	p.updateClusterSummary(cluster)
+	rs, _ := shared.GetAggregatedResultsSummary(&p.summary)
+	rs.Message = fmt.Sprintf("%d clusters, %s", len(p.clusters), rs.Message)
+	p.publishResultSummary(rs)
+}
+
+func (p *Processor) publishResultSummaryTableFunc(cluster string, summary *golang.ResultSummaryTable) {
+	p.updateClusterSummary(cluster)
+
+	p.tablesLock.Lock()
+	p.tables[cluster] = summary
+	tables := make(map[string]*golang.ResultSummaryTable, len(p.tables))
+	for k, v := range p.tables {
+		tables[k] = v
+	}
+	p.tablesLock.Unlock()
+
+	p.publishResultSummaryTable(shared.GetCrossClusterSummaryTable(tables, &p.summary))
+}
+
+func (p *Processor) ReEvaluate(id string, items []*golang.PreferenceItem) {
+	item, ok := p.itemsToCluster.Get(id)
+	if !ok {
+		return
+	}
+	pi, ok := p.processors.Get(item.cluster)
+	if !ok {
+		return
+	}
+	pi.ReEvaluate(item.id, items)
+}
+
+func (p *Processor) ExportNonInteractive() *golang.NonInteractiveExport {
+	var rows []*golang.CSVRow
+	for _, cluster := range p.clusters {
+		pi, ok := p.processors.Get(cluster)
+		if !ok {
+			continue
+		}
+		export := pi.ExportNonInteractive()
+		if export == nil {
+			continue
+		}
+		for i, row := range export.Csv {
+			if i == 0 {
+				// every cluster starts its export with the same headers
+				if len(rows) == 0 {
+					rows = append(rows, &golang.CSVRow{Row: append([]string{"Cluster"}, row.Row...)})
+				}
+				continue
+			}
+			rows = append(rows, &golang.CSVRow{Row: append([]string{cluster}, row.Row...)})
+		}
+	}
+
+	return &golang.NonInteractiveExport{
+		Csv: rows,
+	}
+}
+
+// ExportWorkloads merges the exports of the clusters that support it, tagging each workload with its cluster.
+func (p *Processor) ExportWorkloads() []shared.ExportWorkload {
+	var workloads []shared.ExportWorkload
+	for _, cluster := range p.clusters {
+		pi, ok := p.processors.Get(cluster)
+		if !ok {
+			continue
+		}
+		exporter, ok := pi.(processor.WorkloadExporter)
+		if !ok {
+			continue
+		}
+		for _, w := range exporter.ExportWorkloads() {
+			w.Cluster = cluster
+			workloads = append(workloads, w)
+		}
+	}
+	return workloads
+}
+
+// ExportVerticalPodAutoscalers merges the VPA manifests of the clusters that support it. Manifests are written to a single file,
+// so each name is prefixed with its cluster to keep the same workload of different clusters apart.
+func (p *Processor) ExportVerticalPodAutoscalers() []*unstructured.Unstructured {
+	var vpas []*unstructured.Unstructured
+	for _, cluster := range p.clusters {
+		pi, ok := p.processors.Get(cluster)
+		if !ok {
+			continue
+		}
+		exporter, ok := pi.(processor.VPAExporter)
+		if !ok {
+			continue
+		}
+		for _, vpa := range exporter.ExportVerticalPodAutoscalers() {
+			vpa.SetName(clusterObjectName(cluster, vpa.GetName()))
+			vpas = append(vpas, vpa)
+		}
+	}
+	return vpas
+}
+
+// clusterObjectName prefixes name with the cluster, turning context names like arn:aws:eks:... into a valid object name.
+func clusterObjectName(cluster, name string) string {
+	prefix := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(cluster), "-"), "-.")
+	result := name
+	if prefix != "" {
+		result = prefix + "-" + name
+	}
+	if len(result) > validation.DNS1123SubdomainMaxLength {
+		result = strings.TrimRight(result[:validation.DNS1123SubdomainMaxLength], "-.")
+	}
+	return result
+}
+
+var _ processor.VPAExporter = (*Processor)(nil)
@@ -0,0 +1,122 @@
+package cronjobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
+)
+
+type GetCronJobPodMetricsJob struct {
+	processor *Processor
+	itemId    string
+}
+
+func NewGetCronJobPodMetricsJob(processor *Processor, itemId string) *GetCronJobPodMetricsJob {
+	return &GetCronJobPodMetricsJob{
+		processor: processor,
+		itemId:    itemId,
+	}
+}
+func (j *GetCronJobPodMetricsJob) Properties() sdk.JobProperties {
+	return sdk.JobProperties{
+		ID:          fmt.Sprintf("get_cronjob_pod_metrics_for_%s", j.itemId),
+		Description: fmt.Sprintf("Getting metrics for %s (Kubernetes CronJobs)", j.itemId),
+		MaxRetry:    5,
+	}
+}
+func (j *GetCronJobPodMetricsJob) Run(ctx context.Context) error {
+	// pods of every run in the observability window, including the jobs that were already cleaned up
+	cronJob, ok := j.processor.items.Get(j.itemId)
+	if !ok {
+		return errors.New("cronjob not found in the items list")
+	}
+
+	if err := kaytuPrometheus.WaitForHistory(ctx, j.processor.metricsProvider); err != nil {
+		return err
+	}
+
+	cpuUsageWithHistory, err := j.processor.metricsProvider.GetCpuMetricsForPodOwnerPrefix(ctx, cronJob.Namespace, kaytuPrometheus.PodOwnerKindCronJob, cronJob.CronJob.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeCronJob)
+	if err != nil {
+		return err
+	}
+	for podName, containerMetrics := range cpuUsageWithHistory {
+		if cronJob.Metrics == nil {
+			cronJob.Metrics = make(map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint)
+		}
+		if cronJob.Metrics["cpu_usage"] == nil {
+			cronJob.Metrics["cpu_usage"] = make(map[string]map[string][]kaytuPrometheus.PromDatapoint)
+		}
+		if _, ok := cronJob.Metrics["cpu_usage"][podName]; ok {
+			continue
+		} else {
+			cronJob.Metrics["cpu_usage"][podName] = containerMetrics
+		}
+	}
+
+	cpuThrottlingWithHistory, err := j.processor.metricsProvider.GetCpuThrottlingMetricsForPodOwnerPrefix(ctx, cronJob.Namespace, kaytuPrometheus.PodOwnerKindCronJob, cronJob.CronJob.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeCronJob)
+	if err != nil {
+		return err
+	}
+	for podName, containerMetrics := range cpuThrottlingWithHistory {
+		if cronJob.Metrics == nil {
+			cronJob.Metrics = make(map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint)
+		}
+		if cronJob.Metrics["cpu_throttling"] == nil {
+			cronJob.Metrics["cpu_throttling"] = make(map[string]map[string][]kaytuPrometheus.PromDatapoint)
+		}
+		if _, ok := cronJob.Metrics["cpu_throttling"][podName]; ok {
+			continue
+		} else {
+			cronJob.Metrics["cpu_throttling"][podName] = containerMetrics
+		}
+	}
+
+	memoryUsageWithHistory, err := j.processor.metricsProvider.GetMemoryMetricsForPodOwnerPrefix(ctx, cronJob.Namespace, kaytuPrometheus.PodOwnerKindCronJob, cronJob.CronJob.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeCronJob)
+	if err != nil {
+		return err
+	}
+	for podName, containerMetrics := range memoryUsageWithHistory {
+		if cronJob.Metrics == nil {
+			cronJob.Metrics = make(map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint)
+		}
+		if cronJob.Metrics["memory_usage"] == nil {
+			cronJob.Metrics["memory_usage"] = make(map[string]map[string][]kaytuPrometheus.PromDatapoint)
+		}
+		if _, ok := cronJob.Metrics["memory_usage"][podName]; ok {
+			continue
+		} else {
+			cronJob.Metrics["memory_usage"][podName] = containerMetrics
+		}
+	}
+
+	cronJob.LazyLoadingEnabled = false
+	now := j.processor.kubernetesProvider.Now()
+	earliest := now
+	for _, pm := range []map[string]map[string][]kaytuPrometheus.PromDatapoint{cpuUsageWithHistory, cpuThrottlingWithHistory, memoryUsageWithHistory} {
+		for _, kvs := range pm {
+			for _, v := range kvs {
+				for _, m := range v {
+					if m.Timestamp.Before(earliest) {
+						earliest = m.Timestamp
+					}
+				}
+			}
+		}
+	}
+	cronJob.ObservabilityDuration = now.Sub(earliest)
+	if reason := kaytuPrometheus.InsufficientHistory(j.processor.metricsProvider, cronJob.Metrics["cpu_usage"]); reason != "" {
+		cronJob.Skipped = true
+		cronJob.SkipReason = reason
+	}
+
+	j.processor.items.Set(cronJob.GetID(), cronJob)
+	j.processor.publishOptimizationItem(cronJob.ToOptimizationItem())
+	j.processor.UpdateSummary(cronJob.GetID())
+
+	if !cronJob.Skipped {
+		j.processor.jobQueue.Push(NewOptimizeCronJobJob(j.processor, cronJob.GetID()))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package cronjobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/preferences"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+)
+
+type ListCronJobsForNamespaceJob struct {
+	processor *Processor
+	namespace string
+	nodes     []shared.KubernetesNode
+}
+
+func NewListCronJobsForNamespaceJob(processor *Processor, namespace string, nodes []shared.KubernetesNode) *ListCronJobsForNamespaceJob {
+	return &ListCronJobsForNamespaceJob{
+		processor: processor,
+		namespace: namespace,
+		nodes:     nodes,
+	}
+}
+
+func (j *ListCronJobsForNamespaceJob) Properties() sdk.JobProperties {
+	return sdk.JobProperties{
+		ID:          fmt.Sprintf("list_cronjobs_for_namespace_kubernetes_%s", j.namespace),
+		Description: fmt.Sprintf("Listing all cronjobs in namespace %s (Kubernetes CronJobs)", j.namespace),
+		MaxRetry:    0,
+	}
+}
+func (j *ListCronJobsForNamespaceJob) Run(ctx context.Context) error {
+	cronJobs, err := j.processor.kubernetesProvider.ListCronJobsInNamespace(ctx, j.namespace, j.processor.selector)
+	if err != nil {
+		return err
+	}
+
+	for _, cronJob := range cronJobs {
+		item := CronJobItem{
+			CronJob:             cronJob,
+			Namespace:           j.namespace,
+			OptimizationLoading: true,
+			Preferences:         preferences.DefaultKubernetesPreferences,
+			Skipped:             false,
+			LazyLoadingEnabled:  false,
+			Nodes:               j.nodes,
+		}
+
+		if cronJob.Status.LastScheduleTime == nil && len(cronJob.Status.Active) == 0 {
+			item.Skipped = true
+			item.SkipReason = "never scheduled"
+		}
+		j.processor.lazyloadCounter.Add(1)
+		if j.processor.lazyloadCounter.Load() > uint32(j.processor.configuration.KubernetesLazyLoad) {
+			item.LazyLoadingEnabled = true
+			item.OptimizationLoading = false
+		}
+		j.processor.items.Set(item.GetID(), item)
+		j.processor.publishOptimizationItem(item.ToOptimizationItem())
+		j.processor.UpdateSummary(item.GetID())
+
+		if item.LazyLoadingEnabled || !item.OptimizationLoading || item.Skipped {
+			continue
+		}
+		j.processor.jobQueue.Push(NewListPodsForCronJobJob(j.processor, item.GetID()))
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package cronjobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+)
+
+type ListPodsForCronJobJob struct {
+	processor *Processor
+	itemId    string
+}
+
+func NewListPodsForCronJobJob(processor *Processor, itemId string) *ListPodsForCronJobJob {
+	return &ListPodsForCronJobJob{
+		processor: processor,
+		itemId:    itemId,
+	}
+}
+
+func (j *ListPodsForCronJobJob) Properties() sdk.JobProperties {
+	return sdk.JobProperties{
+		ID:          fmt.Sprintf("list_pods_for_cronjob_kubernetes_%s", j.itemId),
+		Description: fmt.Sprintf("Listing all pods for cronjob %s (Kubernetes CronJobs)", j.itemId),
+		MaxRetry:    0,
+	}
+}
+
+func (j *ListPodsForCronJobJob) Run(ctx context.Context) error {
+	var err error
+	item, ok := j.processor.items.Get(j.itemId)
+	if !ok {
+		return errors.New("cronjob not found in the items list")
+	}
+
+	item.Jobs, err = j.processor.kubernetesProvider.ListCronJobJobs(ctx, item.CronJob)
+	if err != nil {
+		return err
+	}
+	item.Pods = nil
+	for _, job := range item.Jobs {
+		pods, err := j.processor.kubernetesProvider.ListJobPods(ctx, job)
+		if err != nil {
+			return err
+		}
+		item.Pods = append(item.Pods, pods...)
+	}
+
+	item.LazyLoadingEnabled = false
+	if j.processor.nodeSelector != "" {
+		if !shared.PodsInNodes(item.Pods, item.Nodes) {
+			item.Skipped = true
+			item.SkipReason = "not in selected nodes"
+			j.processor.items.Set(j.itemId, item)
+			j.processor.publishOptimizationItem(item.ToOptimizationItem())
+			return nil
+		}
+	}
+
+	j.processor.items.Set(j.itemId, item)
+	j.processor.publishOptimizationItem(item.ToOptimizationItem())
+
+	j.processor.jobQueue.Push(NewGetCronJobPodMetricsJob(j.processor, item.GetID()))
+	return nil
+}
@@ -13,15 +13,18 @@ import (
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/simulation"
 	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
 	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
+	appv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sort"
 	"sync/atomic"
+	"time"
 )
 
 type Processor struct {
 	identification            map[string]string
 	kubernetesProvider        *kaytuKubernetes.Kubernetes
-	prometheusProvider        *kaytuPrometheus.Prometheus
+	metricsProvider           kaytuPrometheus.MetricsProvider
 	items                     utils.ConcurrentMap[string, DaemonsetItem]
 	publishOptimizationItem   func(item *golang.ChartOptimizationItem)
 	publishResultSummary      func(summary *golang.ResultSummary)
@@ -35,6 +38,7 @@ type Processor struct {
 	selector                  string
 	nodeSelector              string
 	observabilityDays         int
+	requestTimeout            time.Duration
 	defaultPreferences        []*golang.PreferenceItem
 
 	summary       utils.ConcurrentMap[string, shared.ResourceSummary]
@@ -48,7 +52,7 @@ func NewProcessor(processorConf shared.Configuration, nodeProcessor *nodes.Proce
 	r := &Processor{
 		identification:            processorConf.Identification,
 		kubernetesProvider:        processorConf.KubernetesProvider,
-		prometheusProvider:        processorConf.PrometheusProvider,
+		metricsProvider:           processorConf.MetricsProvider,
 		items:                     utils.NewConcurrentMap[string, DaemonsetItem](),
 		publishOptimizationItem:   processorConf.PublishOptimizationItem,
 		publishResultSummary:      processorConf.PublishResultSummary,
@@ -62,6 +66,7 @@ func NewProcessor(processorConf shared.Configuration, nodeProcessor *nodes.Proce
 		selector:                  processorConf.Selector,
 		nodeSelector:              processorConf.NodeSelector,
 		observabilityDays:         processorConf.ObservabilityDays,
+		requestTimeout:            processorConf.RequestTimeout,
 		defaultPreferences:        processorConf.DefaultPreferences,
 		nodeProcessor:             nodeProcessor,
 
@@ -87,7 +92,82 @@ func (m *Processor) ReEvaluate(id string, items []*golang.PreferenceItem) {
 }
 
 func (m *Processor) ExportNonInteractive() *golang.NonInteractiveExport {
-	return nil
+	return &golang.NonInteractiveExport{
+		Csv: m.exportCsv(),
+	}
+}
+
+func (m *Processor) exportCsv() []*golang.CSVRow {
+	var rows []*golang.CSVRow
+	rows = append(rows, &golang.CSVRow{Row: shared.ExportCsvHeaders})
+	rows = append(rows, m.ExportCsvRows()...)
+	return rows
+}
+
+func (m *Processor) ExportCsvRows() []*golang.CSVRow {
+	return shared.ExportCsvRows(m.ExportWorkloads())
+}
+
+func (m *Processor) ExportWorkloads() []shared.ExportWorkload {
+	var ids []string
+	m.items.Range(func(id string, _ DaemonsetItem) bool {
+		ids = append(ids, id)
+		return true
+	})
+	sort.Strings(ids)
+
+	var workloads []shared.ExportWorkload
+	for _, id := range ids {
+		item, ok := m.items.Get(id)
+		if !ok {
+			continue
+		}
+		replicas := item.Daemonset.Status.CurrentNumberScheduled
+		var rightSizing []*golang2.KubernetesContainerRightsizingRecommendation
+		if item.Wastage != nil && item.Wastage.Rightsizing != nil {
+			rightSizing = item.Wastage.Rightsizing.ContainerResizing
+		}
+		workloads = append(workloads, shared.ExportWorkload{
+			Kind:                  "DaemonSet",
+			Namespace:             item.Daemonset.Namespace,
+			Name:                  item.Daemonset.Name,
+			Replicas:              replicas,
+			Containers:            shared.PodContainers(item.Daemonset.Spec.Template.Spec),
+			Rightsizing:           rightSizing,
+			Cost:                  item.Cost,
+			ObservabilityDuration: item.ObservabilityDuration,
+			Skipped:               item.Skipped,
+			SkipReason:            item.SkipReason,
+		})
+	}
+	return workloads
+}
+
+// ExportVerticalPodAutoscalers returns VPA manifests bounded by the recommendations of the optimized daemonsets.
+func (m *Processor) ExportVerticalPodAutoscalers() []*unstructured.Unstructured {
+	var ids []string
+	m.items.Range(func(id string, _ DaemonsetItem) bool {
+		ids = append(ids, id)
+		return true
+	})
+	sort.Strings(ids)
+
+	var vpas []*unstructured.Unstructured
+	for _, id := range ids {
+		item, ok := m.items.Get(id)
+		if !ok || item.Wastage == nil || item.Wastage.Rightsizing == nil {
+			continue
+		}
+		manifest := shared.VerticalPodAutoscalerManifest(item.Daemonset.Namespace, autoscalingv1.CrossVersionObjectReference{
+			APIVersion: appv1.SchemeGroupVersion.String(),
+			Kind:       "DaemonSet",
+			Name:       item.Daemonset.Name,
+		}, item.VerticalAutoscaler, nil, item.Wastage.Rightsizing.ContainerResizing)
+		if manifest != nil {
+			vpas = append(vpas, manifest)
+		}
+	}
+	return vpas
 }
 
 func (m *Processor) GetSummaryMap() *utils.ConcurrentMap[string, shared.ResourceSummary] {
@@ -104,13 +184,17 @@ func (m *Processor) UpdateSummary(itemId string) {
 		memoryRequestChange, totalMemoryRequest := 0.0, 0.0
 		memoryLimitChange, totalMemoryLimit := 0.0, 0.0
 		for _, container := range i.Wastage.Rightsizing.ContainerResizing {
-			var pContainer corev1.Container
-			for _, podContainer := range i.Daemonset.Spec.Template.Spec.Containers {
+			var pContainer shared.PodContainer
+			for _, podContainer := range shared.PodContainers(i.Daemonset.Spec.Template.Spec) {
 				if podContainer.Name == container.Name {
 					pContainer = podContainer
 				}
 			}
-			cpuRequest, cpuLimit, memoryRequest, memoryLimit := shared.GetContainerRequestLimits(pContainer)
+			if pContainer.Type == shared.ContainerTypeInit {
+				// init containers are done before the pod runs, only the simulation accounts for them
+				continue
+			}
+			cpuRequest, cpuLimit, memoryRequest, memoryLimit := shared.GetContainerRequestLimits(pContainer.Container)
 			if container.Current != nil && container.Recommended != nil {
 				if cpuRequest != nil {
 					totalCpuRequest += container.Current.CpuRequest
@@ -132,6 +216,7 @@ func (m *Processor) UpdateSummary(itemId string) {
 		}
 
 		ds := shared.ResourceSummary{
+			Namespace:               i.Namespace,
 			ReplicaCount:            i.Daemonset.Status.CurrentNumberScheduled,
 			CPURequestDownSizing:    min(0, cpuRequestChange),
 			CPURequestUpSizing:      max(0, cpuRequestChange),
@@ -160,32 +245,7 @@ func (m *Processor) UpdateSummary(itemId string) {
 		}
 		if m.schedulingSim != nil {
 			i.Daemonset = *i.Daemonset.DeepCopy()
-			for idx, c := range i.Daemonset.Spec.Template.Spec.Containers {
-				for _, container := range i.Wastage.Rightsizing.ContainerResizing {
-					if container.Name != c.Name {
-						continue
-					}
-
-					if container.Recommended != nil {
-						c.Resources.Requests = map[corev1.ResourceName]resource.Quantity{}
-						c.Resources.Limits = map[corev1.ResourceName]resource.Quantity{}
-
-						c.Resources.Requests[corev1.ResourceCPU] = resource.Quantity{}
-						c.Resources.Requests.Cpu().SetMilli(int64(container.Recommended.CpuRequest * 1000))
-
-						c.Resources.Limits[corev1.ResourceCPU] = resource.Quantity{}
-						c.Resources.Limits.Cpu().SetMilli(int64(container.Recommended.CpuLimit * 1000))
-
-						c.Resources.Requests[corev1.ResourceMemory] = resource.Quantity{}
-						c.Resources.Requests.Memory().Set(int64(container.Recommended.MemoryRequest))
-
-						c.Resources.Limits[corev1.ResourceMemory] = resource.Quantity{}
-						c.Resources.Limits.Memory().Set(int64(container.Recommended.MemoryLimit))
-
-						i.Daemonset.Spec.Template.Spec.Containers[idx] = c
-					}
-				}
-			}
+			shared.SetRecommendedResources(&i.Daemonset.Spec.Template.Spec, i.Wastage.Rightsizing.ContainerResizing)
 
 			m.schedulingSim.AddDaemonSet(i.Daemonset)
 			nodes, err := m.schedulingSim.Simulate()
@@ -198,7 +258,7 @@ func (m *Processor) UpdateSummary(itemId string) {
 	}
 	rs, _ := shared.GetAggregatedResultsSummary(&m.summary)
 	m.publishResultSummary(rs)
-	rst, _ := shared.GetAggregatedResultsSummaryTable(&m.summary, m.nodeProcessor.GetKubernetesNodes(), removableNodes, removableNodesPrev)
+	rst, _ := shared.GetAggregatedResultsSummaryTable(&m.summary, m.nodeProcessor.GetKubernetesNodes(), removableNodes, removableNodesPrev, m.nodeProcessor.GetResourceQuotas())
 	m.publishResultSummaryTable(rst)
 }
 
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
 	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
 	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
@@ -26,6 +27,8 @@ type DaemonsetItem struct {
 	LazyLoadingEnabled    bool
 	SkipReason            string
 	Metrics               map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint // Metric -> Pod -> Container -> Datapoints
+	VerticalAutoscaler    *kaytuKubernetes.VerticalPodAutoscaler
+	LimitRangeNotes       map[string]string // container -> how its recommendation was adjusted to the namespace LimitRanges
 	Wastage               *golang2.KubernetesDaemonsetOptimizationResponse
 	Nodes                 []shared.KubernetesNode
 	ObservabilityDuration time.Duration
@@ -42,7 +45,7 @@ func (i DaemonsetItem) Devices() ([]*golang.ChartRow, map[string]*golang.Propert
 	var rows []*golang.ChartRow
 	props := make(map[string]*golang.Properties)
 
-	for _, container := range i.Daemonset.Spec.Template.Spec.Containers {
+	for _, container := range shared.PodContainers(i.Daemonset.Spec.Template.Spec) {
 		var rightSizing *golang2.KubernetesContainerRightsizingRecommendation
 		if i.Wastage != nil && i.Wastage.Rightsizing != nil {
 			for _, c := range i.Wastage.Rightsizing.ContainerResizing {
@@ -52,7 +55,9 @@ func (i DaemonsetItem) Devices() ([]*golang.ChartRow, map[string]*golang.Propert
 			}
 		}
 
-		row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Daemonset.Namespace, i.Daemonset.Name, nil, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Preferences, i.ObservabilityDuration)
+		row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Daemonset.Namespace, i.Daemonset.Name, nil, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Metrics["cpu_throttling"], i.Preferences, i.ObservabilityDuration)
+		shared.AddLimitRangeProperty(properties, i.LimitRangeNotes[container.Name])
+		shared.AddVerticalPodAutoscalerProperties(properties, i.VerticalAutoscaler, container.Name)
 		rows = append(rows, row)
 		props[row.RowId] = properties
 	}
@@ -64,7 +69,7 @@ func (i DaemonsetItem) Devices() ([]*golang.ChartRow, map[string]*golang.Propert
 		if i.Wastage != nil && i.Wastage.Rightsizing != nil {
 			podRs, podRsFound = i.Wastage.Rightsizing.PodContainerResizing[pod.Name]
 		}
-		for _, container := range pod.Spec.Containers {
+		for _, container := range shared.PodContainers(pod.Spec) {
 			var rightSizing *golang2.KubernetesContainerRightsizingRecommendation
 			if i.Wastage != nil && podRsFound {
 				for _, c := range podRs.ContainerResizing {
@@ -74,7 +79,8 @@ func (i DaemonsetItem) Devices() ([]*golang.ChartRow, map[string]*golang.Propert
 				}
 			}
 
-			row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Daemonset.Namespace, i.Daemonset.Name, &pod.Name, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Preferences, i.ObservabilityDuration)
+			row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Daemonset.Namespace, i.Daemonset.Name, &pod.Name, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Metrics["cpu_throttling"], i.Preferences, i.ObservabilityDuration)
+			shared.AddLimitRangeProperty(properties, i.LimitRangeNotes[container.Name])
 			rows = append(rows, row)
 			props[row.RowId] = properties
 		}
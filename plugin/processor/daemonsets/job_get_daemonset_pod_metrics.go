@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
 	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
-	"time"
 )
 
 type GetDaemonsetPodMetricsJob struct {
@@ -34,7 +33,11 @@ func (j *GetDaemonsetPodMetricsJob) Run(ctx context.Context) error {
 		return errors.New("daemonset not found in the items list")
 	}
 
-	cpuUsageWithHistory, err := j.processor.prometheusProvider.GetCpuMetricsForPodOwnerPrefix(ctx, daemonset.Namespace, daemonset.Daemonset.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
+	if err := kaytuPrometheus.WaitForHistory(ctx, j.processor.metricsProvider); err != nil {
+		return err
+	}
+
+	cpuUsageWithHistory, err := j.processor.metricsProvider.GetCpuMetricsForPodOwnerPrefix(ctx, daemonset.Namespace, kaytuPrometheus.PodOwnerKindDaemonSet, daemonset.Daemonset.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
 	if err != nil {
 		return err
 	}
@@ -52,7 +55,7 @@ func (j *GetDaemonsetPodMetricsJob) Run(ctx context.Context) error {
 		}
 	}
 
-	cpuThrottlingWithHistory, err := j.processor.prometheusProvider.GetCpuThrottlingMetricsForPodOwnerPrefix(ctx, daemonset.Namespace, daemonset.Daemonset.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
+	cpuThrottlingWithHistory, err := j.processor.metricsProvider.GetCpuThrottlingMetricsForPodOwnerPrefix(ctx, daemonset.Namespace, kaytuPrometheus.PodOwnerKindDaemonSet, daemonset.Daemonset.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
 	if err != nil {
 		return err
 	}
@@ -70,7 +73,7 @@ func (j *GetDaemonsetPodMetricsJob) Run(ctx context.Context) error {
 		}
 	}
 
-	memoryUsageWithHistory, err := j.processor.prometheusProvider.GetMemoryMetricsForPodOwnerPrefix(ctx, daemonset.Namespace, daemonset.Daemonset.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
+	memoryUsageWithHistory, err := j.processor.metricsProvider.GetMemoryMetricsForPodOwnerPrefix(ctx, daemonset.Namespace, kaytuPrometheus.PodOwnerKindDaemonSet, daemonset.Daemonset.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
 	if err != nil {
 		return err
 	}
@@ -89,7 +92,8 @@ func (j *GetDaemonsetPodMetricsJob) Run(ctx context.Context) error {
 	}
 
 	daemonset.LazyLoadingEnabled = false
-	earliest := time.Now()
+	now := j.processor.kubernetesProvider.Now()
+	earliest := now
 	for _, pm := range daemonset.Metrics {
 		for _, kvs := range pm {
 			for _, v := range kvs {
@@ -101,7 +105,11 @@ func (j *GetDaemonsetPodMetricsJob) Run(ctx context.Context) error {
 			}
 		}
 	}
-	daemonset.ObservabilityDuration = time.Now().Sub(earliest)
+	daemonset.ObservabilityDuration = now.Sub(earliest)
+	if reason := kaytuPrometheus.InsufficientHistory(j.processor.metricsProvider, daemonset.Metrics["cpu_usage"]); reason != "" {
+		daemonset.Skipped = true
+		daemonset.SkipReason = reason
+	}
 
 	j.processor.items.Set(daemonset.GetID(), daemonset)
 	j.processor.publishOptimizationItem(daemonset.ToOptimizationItem())
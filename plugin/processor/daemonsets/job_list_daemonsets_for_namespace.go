@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/preferences"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	appv1 "k8s.io/api/apps/v1"
 )
 
 type ListDaemonsetsForNamespaceJob struct {
@@ -35,9 +37,16 @@ func (j *ListDaemonsetsForNamespaceJob) Run(ctx context.Context) error {
 		return err
 	}
 
+	vpas, err := j.processor.kubernetesProvider.ListVerticalPodAutoscalersInNamespace(ctx, j.namespace)
+	if err != nil {
+		// autoscalers are only shown next to the recommendations, the daemonsets are analyzed without them
+		fmt.Println("failed to list vertical pod autoscalers due to", err)
+	}
+
 	for _, daemonset := range daemonsets {
 		item := DaemonsetItem{
 			Daemonset:           daemonset,
+			VerticalAutoscaler:  kaytuKubernetes.FindVerticalPodAutoscaler(vpas, appv1.GroupName, "DaemonSet", daemonset.Name),
 			Namespace:           j.namespace,
 			OptimizationLoading: true,
 			Preferences:         preferences.DefaultKubernetesPreferences,
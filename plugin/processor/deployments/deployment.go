@@ -13,15 +13,18 @@ import (
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/simulation"
 	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
 	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
+	appv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sort"
 	"sync/atomic"
+	"time"
 )
 
 type Processor struct {
 	identification            map[string]string
 	kubernetesProvider        *kaytuKubernetes.Kubernetes
-	prometheusProvider        *kaytuPrometheus.Prometheus
+	metricsProvider           kaytuPrometheus.MetricsProvider
 	items                     utils.ConcurrentMap[string, DeploymentItem]
 	publishOptimizationItem   func(item *golang.ChartOptimizationItem)
 	publishResultSummary      func(summary *golang.ResultSummary)
@@ -36,6 +39,7 @@ type Processor struct {
 	selector                  string
 	nodeSelector              string
 	observabilityDays         int
+	requestTimeout            time.Duration
 	defaultPreferences        []*golang.PreferenceItem
 	schedulingSim             *simulation.SchedulerService
 	schedulingSimPrev         *simulation.SchedulerService
@@ -48,7 +52,7 @@ func NewProcessor(processorConf shared.Configuration, nodeProcessor *nodes.Proce
 	r := &Processor{
 		identification:            processorConf.Identification,
 		kubernetesProvider:        processorConf.KubernetesProvider,
-		prometheusProvider:        processorConf.PrometheusProvider,
+		metricsProvider:           processorConf.MetricsProvider,
 		items:                     utils.NewConcurrentMap[string, DeploymentItem](),
 		publishOptimizationItem:   processorConf.PublishOptimizationItem,
 		publishResultSummary:      processorConf.PublishResultSummary,
@@ -63,6 +67,7 @@ func NewProcessor(processorConf shared.Configuration, nodeProcessor *nodes.Proce
 		selector:                  processorConf.Selector,
 		nodeSelector:              processorConf.NodeSelector,
 		observabilityDays:         processorConf.ObservabilityDays,
+		requestTimeout:            processorConf.RequestTimeout,
 		defaultPreferences:        processorConf.DefaultPreferences,
 		nodeProcessor:             nodeProcessor,
 
@@ -88,7 +93,85 @@ func (m *Processor) ReEvaluate(id string, items []*golang.PreferenceItem) {
 }
 
 func (m *Processor) ExportNonInteractive() *golang.NonInteractiveExport {
-	return nil
+	return &golang.NonInteractiveExport{
+		Csv: m.exportCsv(),
+	}
+}
+
+func (m *Processor) exportCsv() []*golang.CSVRow {
+	var rows []*golang.CSVRow
+	rows = append(rows, &golang.CSVRow{Row: shared.ExportCsvHeaders})
+	rows = append(rows, m.ExportCsvRows()...)
+	return rows
+}
+
+func (m *Processor) ExportCsvRows() []*golang.CSVRow {
+	return shared.ExportCsvRows(m.ExportWorkloads())
+}
+
+func (m *Processor) ExportWorkloads() []shared.ExportWorkload {
+	var ids []string
+	m.items.Range(func(id string, _ DeploymentItem) bool {
+		ids = append(ids, id)
+		return true
+	})
+	sort.Strings(ids)
+
+	var workloads []shared.ExportWorkload
+	for _, id := range ids {
+		item, ok := m.items.Get(id)
+		if !ok {
+			continue
+		}
+		replicas := int32(1)
+		if item.Deployment.Spec.Replicas != nil {
+			replicas = *item.Deployment.Spec.Replicas
+		}
+		var rightSizing []*golang2.KubernetesContainerRightsizingRecommendation
+		if item.Wastage != nil && item.Wastage.Rightsizing != nil {
+			rightSizing = item.Wastage.Rightsizing.ContainerResizing
+		}
+		workloads = append(workloads, shared.ExportWorkload{
+			Kind:                  "Deployment",
+			Namespace:             item.Deployment.Namespace,
+			Name:                  item.Deployment.Name,
+			Replicas:              replicas,
+			Containers:            shared.PodContainers(item.Deployment.Spec.Template.Spec),
+			Rightsizing:           rightSizing,
+			Cost:                  item.Cost,
+			ObservabilityDuration: item.ObservabilityDuration,
+			Skipped:               item.Skipped,
+			SkipReason:            item.SkipReason,
+		})
+	}
+	return workloads
+}
+
+// ExportVerticalPodAutoscalers returns VPA manifests bounded by the recommendations of the optimized deployments.
+func (m *Processor) ExportVerticalPodAutoscalers() []*unstructured.Unstructured {
+	var ids []string
+	m.items.Range(func(id string, _ DeploymentItem) bool {
+		ids = append(ids, id)
+		return true
+	})
+	sort.Strings(ids)
+
+	var vpas []*unstructured.Unstructured
+	for _, id := range ids {
+		item, ok := m.items.Get(id)
+		if !ok || item.Wastage == nil || item.Wastage.Rightsizing == nil {
+			continue
+		}
+		manifest := shared.VerticalPodAutoscalerManifest(item.Deployment.Namespace, autoscalingv1.CrossVersionObjectReference{
+			APIVersion: appv1.SchemeGroupVersion.String(),
+			Kind:       "Deployment",
+			Name:       item.Deployment.Name,
+		}, item.VerticalAutoscaler, item.Autoscaler, item.Wastage.Rightsizing.ContainerResizing)
+		if manifest != nil {
+			vpas = append(vpas, manifest)
+		}
+	}
+	return vpas
 }
 
 func (m *Processor) GetSummaryMap() *utils.ConcurrentMap[string, shared.ResourceSummary] {
@@ -104,13 +187,17 @@ func (m *Processor) UpdateSummary(itemId string) {
 		memoryRequestChange, totalMemoryRequest := 0.0, 0.0
 		memoryLimitChange, totalMemoryLimit := 0.0, 0.0
 		for _, container := range i.Wastage.Rightsizing.ContainerResizing {
-			var pContainer corev1.Container
-			for _, podContainer := range i.Deployment.Spec.Template.Spec.Containers {
+			var pContainer shared.PodContainer
+			for _, podContainer := range shared.PodContainers(i.Deployment.Spec.Template.Spec) {
 				if podContainer.Name == container.Name {
 					pContainer = podContainer
 				}
 			}
-			cpuRequest, cpuLimit, memoryRequest, memoryLimit := shared.GetContainerRequestLimits(pContainer)
+			if pContainer.Type == shared.ContainerTypeInit {
+				// init containers are done before the pod runs, only the simulation accounts for them
+				continue
+			}
+			cpuRequest, cpuLimit, memoryRequest, memoryLimit := shared.GetContainerRequestLimits(pContainer.Container)
 			if container.Current != nil && container.Recommended != nil {
 				if cpuRequest != nil {
 					totalCpuRequest += container.Current.CpuRequest
@@ -131,8 +218,23 @@ func (m *Processor) UpdateSummary(itemId string) {
 			}
 		}
 
+		replicas := int32(1)
+		if i.Deployment.Spec.Replicas != nil {
+			replicas = *i.Deployment.Spec.Replicas
+		}
+		// with an autoscaler on utilization targets the recommendation changes the replica count as well
+		recommendedReplicas := replicas
+		if estimate, ok := i.HPAEstimate(); ok {
+			recommendedReplicas = estimate.EstimatedReplicas
+			cpuRequestChange = shared.ReplicaAdjustedChange(totalCpuRequest, cpuRequestChange, replicas, recommendedReplicas)
+			cpuLimitChange = shared.ReplicaAdjustedChange(totalCpuLimit, cpuLimitChange, replicas, recommendedReplicas)
+			memoryRequestChange = shared.ReplicaAdjustedChange(totalMemoryRequest, memoryRequestChange, replicas, recommendedReplicas)
+			memoryLimitChange = shared.ReplicaAdjustedChange(totalMemoryLimit, memoryLimitChange, replicas, recommendedReplicas)
+		}
+
 		ds := shared.ResourceSummary{
-			ReplicaCount:            1,
+			Namespace:               i.Namespace,
+			ReplicaCount:            replicas,
 			CPURequestDownSizing:    min(0, cpuRequestChange),
 			CPURequestUpSizing:      max(0, cpuRequestChange),
 			TotalCPURequest:         totalCpuRequest,
@@ -146,9 +248,6 @@ func (m *Processor) UpdateSummary(itemId string) {
 			MemoryLimitDownSizing:   min(0, memoryLimitChange),
 			TotalMemoryLimit:        totalMemoryLimit,
 		}
-		if i.Deployment.Spec.Replicas != nil {
-			ds.ReplicaCount = *i.Deployment.Spec.Replicas
-		}
 
 		m.summary.Set(i.GetID(), ds)
 		if m.schedulingSimPrev != nil {
@@ -164,31 +263,8 @@ func (m *Processor) UpdateSummary(itemId string) {
 
 		if m.schedulingSim != nil {
 			i.Deployment = *i.Deployment.DeepCopy()
-			for idx, c := range i.Deployment.Spec.Template.Spec.Containers {
-				for _, container := range i.Wastage.Rightsizing.ContainerResizing {
-					if container.Name != c.Name {
-						continue
-					}
-					if container.Recommended != nil {
-						c.Resources.Requests = map[corev1.ResourceName]resource.Quantity{}
-						c.Resources.Limits = map[corev1.ResourceName]resource.Quantity{}
-
-						c.Resources.Requests[corev1.ResourceCPU] = resource.Quantity{}
-						c.Resources.Requests.Cpu().SetMilli(int64(container.Recommended.CpuRequest * 1000))
-
-						c.Resources.Limits[corev1.ResourceCPU] = resource.Quantity{}
-						c.Resources.Limits.Cpu().SetMilli(int64(container.Recommended.CpuLimit * 1000))
-
-						c.Resources.Requests[corev1.ResourceMemory] = resource.Quantity{}
-						c.Resources.Requests.Memory().Set(int64(container.Recommended.MemoryRequest))
-
-						c.Resources.Limits[corev1.ResourceMemory] = resource.Quantity{}
-						c.Resources.Limits.Memory().Set(int64(container.Recommended.MemoryLimit))
-
-						i.Deployment.Spec.Template.Spec.Containers[idx] = c
-					}
-				}
-			}
+			shared.SetRecommendedResources(&i.Deployment.Spec.Template.Spec, i.Wastage.Rightsizing.ContainerResizing)
+			i.Deployment.Spec.Replicas = &recommendedReplicas
 
 			m.schedulingSim.AddDeployment(i.Deployment)
 			nodes, err := m.schedulingSim.Simulate()
@@ -202,7 +278,7 @@ func (m *Processor) UpdateSummary(itemId string) {
 	}
 	rs, _ := shared.GetAggregatedResultsSummary(&m.summary)
 	m.publishResultSummary(rs)
-	rst, _ := shared.GetAggregatedResultsSummaryTable(&m.summary, m.nodeProcessor.GetKubernetesNodes(), removableNodes, removableNodesPrev)
+	rst, _ := shared.GetAggregatedResultsSummaryTable(&m.summary, m.nodeProcessor.GetKubernetesNodes(), removableNodes, removableNodesPrev, m.nodeProcessor.GetResourceQuotas())
 	m.publishResultSummaryTable(rst)
 }
 
@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
 	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
 	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 	appv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	"log"
 	"math"
@@ -28,6 +30,9 @@ type DeploymentItem struct {
 	LazyLoadingEnabled        bool
 	SkipReason                string
 	Metrics                   map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint // Metric -> Pod -> Container -> Datapoints
+	Autoscaler                *autoscalingv2.HorizontalPodAutoscaler
+	VerticalAutoscaler        *kaytuKubernetes.VerticalPodAutoscaler
+	LimitRangeNotes           map[string]string // container -> how its recommendation was adjusted to the namespace LimitRanges
 	Wastage                   *golang2.KubernetesDeploymentOptimizationResponse
 	Nodes                     []shared.KubernetesNode
 	ObservabilityDuration     time.Duration
@@ -40,11 +45,19 @@ func (i DeploymentItem) GetID() string {
 	return fmt.Sprintf("appv1.deployment/%s/%s", i.Deployment.Namespace, i.Deployment.Name)
 }
 
+// HPAEstimate is what the autoscaler of the deployment is expected to do once the recommendation is applied.
+func (i DeploymentItem) HPAEstimate() (shared.HPAEstimate, bool) {
+	if i.Wastage == nil || i.Wastage.Rightsizing == nil || i.Deployment.Spec.Replicas == nil {
+		return shared.HPAEstimate{}, false
+	}
+	return shared.EstimateHPAReplicas(i.Autoscaler, *i.Deployment.Spec.Replicas, i.Deployment.Spec.Template.Spec, i.Wastage.Rightsizing.ContainerResizing, i.Metrics)
+}
+
 func (i DeploymentItem) Devices() ([]*golang.ChartRow, map[string]*golang.Properties) {
 	var rows []*golang.ChartRow
 	props := make(map[string]*golang.Properties)
 
-	for _, container := range i.Deployment.Spec.Template.Spec.Containers {
+	for _, container := range shared.PodContainers(i.Deployment.Spec.Template.Spec) {
 		var rightSizing *golang2.KubernetesContainerRightsizingRecommendation
 		if i.Wastage != nil && i.Wastage.Rightsizing != nil {
 			for _, c := range i.Wastage.Rightsizing.ContainerResizing {
@@ -54,7 +67,9 @@ func (i DeploymentItem) Devices() ([]*golang.ChartRow, map[string]*golang.Proper
 			}
 		}
 
-		row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Deployment.Namespace, i.Deployment.Name, nil, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Preferences, i.ObservabilityDuration)
+		row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Deployment.Namespace, i.Deployment.Name, nil, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Metrics["cpu_throttling"], i.Preferences, i.ObservabilityDuration)
+		shared.AddLimitRangeProperty(properties, i.LimitRangeNotes[container.Name])
+		shared.AddVerticalPodAutoscalerProperties(properties, i.VerticalAutoscaler, container.Name)
 		rows = append(rows, row)
 		props[row.RowId] = properties
 	}
@@ -66,7 +81,7 @@ func (i DeploymentItem) Devices() ([]*golang.ChartRow, map[string]*golang.Proper
 		if i.Wastage != nil && i.Wastage.Rightsizing != nil {
 			podRs, podRsFound = i.Wastage.Rightsizing.PodContainerResizing[pod.Name]
 		}
-		for _, container := range pod.Spec.Containers {
+		for _, container := range shared.PodContainers(pod.Spec) {
 			var rightSizing *golang2.KubernetesContainerRightsizingRecommendation
 			if i.Wastage != nil && podRsFound {
 				for _, c := range podRs.ContainerResizing {
@@ -76,7 +91,8 @@ func (i DeploymentItem) Devices() ([]*golang.ChartRow, map[string]*golang.Proper
 				}
 			}
 
-			row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Deployment.Namespace, i.Deployment.Name, &pod.Name, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Preferences, i.ObservabilityDuration)
+			row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Deployment.Namespace, i.Deployment.Name, &pod.Name, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Metrics["cpu_throttling"], i.Preferences, i.ObservabilityDuration)
+			shared.AddLimitRangeProperty(properties, i.LimitRangeNotes[container.Name])
 			rows = append(rows, row)
 			props[row.RowId] = properties
 		}
@@ -134,6 +150,10 @@ func (i DeploymentItem) ToOptimizationItem() *golang.ChartOptimizationItem {
 	} else if i.OptimizationLoading {
 		status = "loading"
 	}
+	description := ""
+	if estimate, ok := i.HPAEstimate(); ok {
+		description = estimate.String()
+	}
 
 	metrics := i.Metrics
 	i.Metrics = nil
@@ -184,7 +204,7 @@ func (i DeploymentItem) ToOptimizationItem() *golang.ChartOptimizationItem {
 		Skipped:            i.Skipped,
 		SkipReason:         nil,
 		LazyLoadingEnabled: i.LazyLoadingEnabled,
-		Description:        "", // TODO update
+		Description:        description,
 		DevicesChartRows:   deviceRows,
 		DevicesProperties:  deviceProps,
 	}
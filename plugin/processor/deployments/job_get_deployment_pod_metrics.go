@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
 	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
-	"time"
 )
 
 type GetDeploymentPodMetricsJob struct {
@@ -33,7 +32,11 @@ func (j *GetDeploymentPodMetricsJob) Run(ctx context.Context) error {
 		return errors.New("deployment not found in the items list")
 	}
 
-	cpuUsage, err := j.processor.prometheusProvider.GetCpuMetricsForPodOwnerPrefix(ctx, deployment.Namespace, deployment.CurrentReplicaSetName, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
+	if err := kaytuPrometheus.WaitForHistory(ctx, j.processor.metricsProvider); err != nil {
+		return err
+	}
+
+	cpuUsage, err := j.processor.metricsProvider.GetCpuMetricsForPodOwnerPrefix(ctx, deployment.Namespace, kaytuPrometheus.PodOwnerKindReplicaSet, deployment.CurrentReplicaSetName, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
 	if err != nil {
 		return err
 	}
@@ -47,7 +50,7 @@ func (j *GetDeploymentPodMetricsJob) Run(ctx context.Context) error {
 		deployment.Metrics["cpu_usage"][podName] = containerMetrics
 	}
 
-	cpuThrottling, err := j.processor.prometheusProvider.GetCpuThrottlingMetricsForPodOwnerPrefix(ctx, deployment.Namespace, deployment.CurrentReplicaSetName, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
+	cpuThrottling, err := j.processor.metricsProvider.GetCpuThrottlingMetricsForPodOwnerPrefix(ctx, deployment.Namespace, kaytuPrometheus.PodOwnerKindReplicaSet, deployment.CurrentReplicaSetName, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
 	if err != nil {
 		return err
 	}
@@ -61,7 +64,7 @@ func (j *GetDeploymentPodMetricsJob) Run(ctx context.Context) error {
 		deployment.Metrics["cpu_throttling"][podName] = containerMetrics
 	}
 
-	memoryUsage, err := j.processor.prometheusProvider.GetMemoryMetricsForPodOwnerPrefix(ctx, deployment.Namespace, deployment.CurrentReplicaSetName, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
+	memoryUsage, err := j.processor.metricsProvider.GetMemoryMetricsForPodOwnerPrefix(ctx, deployment.Namespace, kaytuPrometheus.PodOwnerKindReplicaSet, deployment.CurrentReplicaSetName, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
 	if err != nil {
 		return err
 	}
@@ -76,7 +79,7 @@ func (j *GetDeploymentPodMetricsJob) Run(ctx context.Context) error {
 	}
 
 	for _, replicaSetName := range deployment.HistoricalReplicaSetNames {
-		cpuHistoryUsage, err := j.processor.prometheusProvider.GetCpuMetricsForPodOwnerPrefix(ctx, deployment.Namespace, replicaSetName, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
+		cpuHistoryUsage, err := j.processor.metricsProvider.GetCpuMetricsForPodOwnerPrefix(ctx, deployment.Namespace, kaytuPrometheus.PodOwnerKindReplicaSet, replicaSetName, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
 		if err != nil {
 			return err
 		}
@@ -94,7 +97,7 @@ func (j *GetDeploymentPodMetricsJob) Run(ctx context.Context) error {
 			}
 		}
 
-		cpuThrottlingHistory, err := j.processor.prometheusProvider.GetCpuThrottlingMetricsForPodOwnerPrefix(ctx, deployment.Namespace, replicaSetName, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
+		cpuThrottlingHistory, err := j.processor.metricsProvider.GetCpuThrottlingMetricsForPodOwnerPrefix(ctx, deployment.Namespace, kaytuPrometheus.PodOwnerKindReplicaSet, replicaSetName, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
 		if err != nil {
 			return err
 		}
@@ -112,7 +115,7 @@ func (j *GetDeploymentPodMetricsJob) Run(ctx context.Context) error {
 			}
 		}
 
-		memoryHistoryUsage, err := j.processor.prometheusProvider.GetMemoryMetricsForPodOwnerPrefix(ctx, deployment.Namespace, replicaSetName, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
+		memoryHistoryUsage, err := j.processor.metricsProvider.GetMemoryMetricsForPodOwnerPrefix(ctx, deployment.Namespace, kaytuPrometheus.PodOwnerKindReplicaSet, replicaSetName, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
 		if err != nil {
 			return err
 		}
@@ -132,7 +135,8 @@ func (j *GetDeploymentPodMetricsJob) Run(ctx context.Context) error {
 	}
 
 	deployment.LazyLoadingEnabled = false
-	earliest := time.Now()
+	now := j.processor.kubernetesProvider.Now()
+	earliest := now
 	for _, pm := range deployment.Metrics {
 		for _, kvs := range pm {
 			for _, v := range kvs {
@@ -144,7 +148,11 @@ func (j *GetDeploymentPodMetricsJob) Run(ctx context.Context) error {
 			}
 		}
 	}
-	deployment.ObservabilityDuration = time.Now().Sub(earliest)
+	deployment.ObservabilityDuration = now.Sub(earliest)
+	if reason := kaytuPrometheus.InsufficientHistory(j.processor.metricsProvider, deployment.Metrics["cpu_usage"]); reason != "" {
+		deployment.Skipped = true
+		deployment.SkipReason = reason
+	}
 
 	j.processor.items.Set(deployment.GetID(), deployment)
 	j.processor.publishOptimizationItem(deployment.ToOptimizationItem())
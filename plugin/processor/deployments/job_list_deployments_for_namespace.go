@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/preferences"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	appv1 "k8s.io/api/apps/v1"
 )
 
 type ListDeploymentsForNamespaceJob struct {
@@ -36,9 +38,22 @@ func (j *ListDeploymentsForNamespaceJob) Run(ctx context.Context) error {
 		return err
 	}
 
+	hpas, err := j.processor.kubernetesProvider.ListHorizontalPodAutoscalersInNamespace(ctx, j.namespace)
+	if err != nil {
+		// autoscalers only refine the estimates, the deployments are analyzed without them
+		fmt.Println("failed to list horizontal pod autoscalers due to", err)
+	}
+
+	vpas, err := j.processor.kubernetesProvider.ListVerticalPodAutoscalersInNamespace(ctx, j.namespace)
+	if err != nil {
+		fmt.Println("failed to list vertical pod autoscalers due to", err)
+	}
+
 	for _, deployment := range deployments {
 		item := DeploymentItem{
 			Deployment:          deployment,
+			Autoscaler:          shared.FindHorizontalPodAutoscaler(hpas, "Deployment", deployment.Name),
+			VerticalAutoscaler:  kaytuKubernetes.FindVerticalPodAutoscaler(vpas, appv1.GroupName, "Deployment", deployment.Name),
 			Namespace:           j.namespace,
 			OptimizationLoading: true,
 			Preferences:         preferences.DefaultKubernetesPreferences,
@@ -63,7 +63,7 @@ func (j *OptimizeDeploymentJob) Run(ctx context.Context) error {
 		Tolerations:  tolerations,
 		Labels:       item.Deployment.Labels,
 	}
-	for _, container := range item.Deployment.Spec.Template.Spec.Containers {
+	for _, container := range shared.PodContainers(item.Deployment.Spec.Template.Spec) {
 		deployment.Containers = append(deployment.Containers, &golang.KubernetesContainer{
 			Name:          container.Name,
 			MemoryRequest: container.Resources.Requests.Memory().AsApproximateFloat64(),
@@ -90,8 +90,9 @@ func (j *OptimizeDeploymentJob) Run(ctx context.Context) error {
 			for containerId, datapoints := range containerMetrics {
 				if metrics[podId].Metrics[containerId] == nil {
 					metrics[podId].Metrics[containerId] = &golang.KubernetesContainerMetrics{
-						Cpu:    nil,
-						Memory: nil,
+						Cpu:           nil,
+						Memory:        nil,
+						CpuThrottling: nil,
 					}
 				}
 				v := metrics[podId].Metrics[containerId]
@@ -110,6 +111,13 @@ func (j *OptimizeDeploymentJob) Run(ctx context.Context) error {
 						}
 						v.Memory[dp.Timestamp.Format("2006-01-02 15:04:05")] = dp.Value
 					}
+				case "cpu_throttling":
+					for _, dp := range datapoints {
+						if v.CpuThrottling == nil {
+							v.CpuThrottling = map[string]float64{}
+						}
+						v.CpuThrottling[dp.Timestamp.Format("2006-01-02 15:04:05")] = dp.Value
+					}
 				}
 				metrics[podId].Metrics[containerId] = v
 			}
@@ -117,7 +125,7 @@ func (j *OptimizeDeploymentJob) Run(ctx context.Context) error {
 	}
 
 	grpcCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("workspace-name", "kaytu"))
-	grpcCtx, cancel := context.WithTimeout(grpcCtx, shared.GrpcOptimizeRequestTimeout)
+	grpcCtx, cancel := context.WithTimeout(grpcCtx, j.processor.requestTimeout)
 	defer cancel()
 	resp, err := j.processor.client.KubernetesDeploymentOptimization(grpcCtx, &golang.KubernetesDeploymentOptimizationRequest{
 		RequestId:      wrapperspb.String(reqID),
@@ -132,6 +140,23 @@ func (j *OptimizeDeploymentJob) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if resp.Rightsizing != nil {
+		shared.KeepThrottledCpuLimits(resp.Rightsizing.ContainerResizing, shared.MergePodContainerDatapoints(item.Metrics["cpu_throttling"]))
+		for podName, podRightsizing := range resp.Rightsizing.PodContainerResizing {
+			if podRightsizing != nil {
+				shared.KeepThrottledCpuLimits(podRightsizing.ContainerResizing, item.Metrics["cpu_throttling"][podName])
+			}
+		}
+		if j.processor.nodeProcessor != nil {
+			limitRanges := j.processor.nodeProcessor.GetLimitRanges(item.Namespace)
+			item.LimitRangeNotes = shared.ClampToLimitRanges(resp.Rightsizing.ContainerResizing, limitRanges)
+			for _, podRightsizing := range resp.Rightsizing.PodContainerResizing {
+				if podRightsizing != nil {
+					shared.ClampToLimitRanges(podRightsizing.ContainerResizing, limitRanges)
+				}
+			}
+		}
+	}
 
 	item.LazyLoadingEnabled = false
 	item.OptimizationLoading = false
@@ -1,8 +1,22 @@
 package processor
 
-import "github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+import (
+	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
 
 type Processor interface {
 	ReEvaluate(id string, items []*golang.PreferenceItem)
 	ExportNonInteractive() *golang.NonInteractiveExport
 }
+
+// VPAExporter is implemented by the processors that can write their recommendations as VerticalPodAutoscaler manifests.
+type VPAExporter interface {
+	ExportVerticalPodAutoscalers() []*unstructured.Unstructured
+}
+
+// WorkloadExporter is implemented by the processors whose non-interactive export can also be written as JSON.
+type WorkloadExporter interface {
+	ExportWorkloads() []shared.ExportWorkload
+}
@@ -13,15 +13,15 @@ import (
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/simulation"
 	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
 	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
+	"sort"
 	"sync/atomic"
+	"time"
 )
 
 type Processor struct {
 	identification            map[string]string
 	kubernetesProvider        *kaytuKubernetes.Kubernetes
-	prometheusProvider        *kaytuPrometheus.Prometheus
+	metricsProvider           kaytuPrometheus.MetricsProvider
 	items                     utils.ConcurrentMap[string, JobItem]
 	publishOptimizationItem   func(item *golang.ChartOptimizationItem)
 	publishResultSummary      func(summary *golang.ResultSummary)
@@ -35,6 +35,7 @@ type Processor struct {
 	selector                  string
 	nodeSelector              string
 	observabilityDays         int
+	requestTimeout            time.Duration
 	defaultPreferences        []*golang.PreferenceItem
 	schedulingSim             *simulation.SchedulerService
 	schedulingSimPrev         *simulation.SchedulerService
@@ -47,7 +48,7 @@ func NewProcessor(processorConf shared.Configuration, nodeProcessor *nodes.Proce
 	r := &Processor{
 		identification:            processorConf.Identification,
 		kubernetesProvider:        processorConf.KubernetesProvider,
-		prometheusProvider:        processorConf.PrometheusProvider,
+		metricsProvider:           processorConf.MetricsProvider,
 		items:                     utils.NewConcurrentMap[string, JobItem](),
 		publishOptimizationItem:   processorConf.PublishOptimizationItem,
 		publishResultSummary:      processorConf.PublishResultSummary,
@@ -61,6 +62,7 @@ func NewProcessor(processorConf shared.Configuration, nodeProcessor *nodes.Proce
 		selector:                  processorConf.Selector,
 		nodeSelector:              processorConf.NodeSelector,
 		observabilityDays:         processorConf.ObservabilityDays,
+		requestTimeout:            processorConf.RequestTimeout,
 		defaultPreferences:        processorConf.DefaultPreferences,
 		nodeProcessor:             nodeProcessor,
 
@@ -86,7 +88,58 @@ func (m *Processor) ReEvaluate(id string, items []*golang.PreferenceItem) {
 }
 
 func (m *Processor) ExportNonInteractive() *golang.NonInteractiveExport {
-	return nil
+	return &golang.NonInteractiveExport{
+		Csv: m.exportCsv(),
+	}
+}
+
+func (m *Processor) exportCsv() []*golang.CSVRow {
+	var rows []*golang.CSVRow
+	rows = append(rows, &golang.CSVRow{Row: shared.ExportCsvHeaders})
+	rows = append(rows, m.ExportCsvRows()...)
+	return rows
+}
+
+func (m *Processor) ExportCsvRows() []*golang.CSVRow {
+	return shared.ExportCsvRows(m.ExportWorkloads())
+}
+
+func (m *Processor) ExportWorkloads() []shared.ExportWorkload {
+	var ids []string
+	m.items.Range(func(id string, _ JobItem) bool {
+		ids = append(ids, id)
+		return true
+	})
+	sort.Strings(ids)
+
+	var workloads []shared.ExportWorkload
+	for _, id := range ids {
+		item, ok := m.items.Get(id)
+		if !ok {
+			continue
+		}
+		replicas := int32(1)
+		if item.Job.Spec.Parallelism != nil {
+			replicas = *item.Job.Spec.Parallelism
+		}
+		var rightSizing []*golang2.KubernetesContainerRightsizingRecommendation
+		if item.Wastage != nil && item.Wastage.Rightsizing != nil {
+			rightSizing = item.Wastage.Rightsizing.ContainerResizing
+		}
+		workloads = append(workloads, shared.ExportWorkload{
+			Kind:                  "Job",
+			Namespace:             item.Job.Namespace,
+			Name:                  item.Job.Name,
+			Replicas:              replicas,
+			Containers:            shared.PodContainers(item.Job.Spec.Template.Spec),
+			Rightsizing:           rightSizing,
+			Cost:                  item.Cost,
+			ObservabilityDuration: item.ObservabilityDuration,
+			Skipped:               item.Skipped,
+			SkipReason:            item.SkipReason,
+		})
+	}
+	return workloads
 }
 
 func (m *Processor) GetSummaryMap() *utils.ConcurrentMap[string, shared.ResourceSummary] {
@@ -102,13 +155,17 @@ func (m *Processor) UpdateSummary(itemId string) {
 		memoryRequestChange, totalMemoryRequest := 0.0, 0.0
 		memoryLimitChange, totalMemoryLimit := 0.0, 0.0
 		for _, container := range i.Wastage.Rightsizing.ContainerResizing {
-			var pContainer corev1.Container
-			for _, podContainer := range i.Job.Spec.Template.Spec.Containers {
+			var pContainer shared.PodContainer
+			for _, podContainer := range shared.PodContainers(i.Job.Spec.Template.Spec) {
 				if podContainer.Name == container.Name {
 					pContainer = podContainer
 				}
 			}
-			cpuRequest, cpuLimit, memoryRequest, memoryLimit := shared.GetContainerRequestLimits(pContainer)
+			if pContainer.Type == shared.ContainerTypeInit {
+				// init containers are done before the pod runs, only the simulation accounts for them
+				continue
+			}
+			cpuRequest, cpuLimit, memoryRequest, memoryLimit := shared.GetContainerRequestLimits(pContainer.Container)
 			if container.Current != nil && container.Recommended != nil {
 				if cpuRequest != nil {
 					totalCpuRequest += container.Current.CpuRequest
@@ -130,6 +187,7 @@ func (m *Processor) UpdateSummary(itemId string) {
 		}
 
 		js := shared.ResourceSummary{
+			Namespace:               i.Namespace,
 			ReplicaCount:            1,
 			CPURequestDownSizing:    min(0, cpuRequestChange),
 			CPURequestUpSizing:      max(0, cpuRequestChange),
@@ -161,32 +219,7 @@ func (m *Processor) UpdateSummary(itemId string) {
 		}
 		if m.schedulingSim != nil {
 			i.Job = *i.Job.DeepCopy()
-			for idx, c := range i.Job.Spec.Template.Spec.Containers {
-				for _, container := range i.Wastage.Rightsizing.ContainerResizing {
-					if container.Name != c.Name {
-						continue
-					}
-
-					if container.Recommended != nil {
-						c.Resources.Requests = map[corev1.ResourceName]resource.Quantity{}
-						c.Resources.Limits = map[corev1.ResourceName]resource.Quantity{}
-
-						c.Resources.Requests[corev1.ResourceCPU] = resource.Quantity{}
-						c.Resources.Requests.Cpu().SetMilli(int64(container.Recommended.CpuRequest * 1000))
-
-						c.Resources.Limits[corev1.ResourceCPU] = resource.Quantity{}
-						c.Resources.Limits.Cpu().SetMilli(int64(container.Recommended.CpuLimit * 1000))
-
-						c.Resources.Requests[corev1.ResourceMemory] = resource.Quantity{}
-						c.Resources.Requests.Memory().Set(int64(container.Recommended.MemoryRequest))
-
-						c.Resources.Limits[corev1.ResourceMemory] = resource.Quantity{}
-						c.Resources.Limits.Memory().Set(int64(container.Recommended.MemoryLimit))
-
-						i.Job.Spec.Template.Spec.Containers[idx] = c
-					}
-				}
-			}
+			shared.SetRecommendedResources(&i.Job.Spec.Template.Spec, i.Wastage.Rightsizing.ContainerResizing)
 
 			m.schedulingSim.AddJob(i.Job)
 			nodes, err := m.schedulingSim.Simulate()
@@ -199,7 +232,7 @@ func (m *Processor) UpdateSummary(itemId string) {
 	}
 	rs, _ := shared.GetAggregatedResultsSummary(&m.summary)
 	m.publishResultSummary(rs)
-	rst, _ := shared.GetAggregatedResultsSummaryTable(&m.summary, m.nodeProcessor.GetKubernetesNodes(), removableNodes, removableNodesPrev)
+	rst, _ := shared.GetAggregatedResultsSummaryTable(&m.summary, m.nodeProcessor.GetKubernetesNodes(), removableNodes, removableNodesPrev, m.nodeProcessor.GetResourceQuotas())
 	m.publishResultSummaryTable(rst)
 }
 
@@ -45,6 +45,9 @@ func (j *DownloadKaytuAgentReportJob) Run(ctx context.Context) error {
 		}
 
 		item.Nodes = j.nodes
+		if isOwnedByCronJob(item.Job) {
+			continue
+		}
 		if j.processor.namespace != nil && *j.processor.namespace != "" {
 			if item.Namespace != *j.processor.namespace {
 				continue
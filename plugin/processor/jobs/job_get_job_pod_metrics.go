@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
 	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
-	"time"
 )
 
 type GetJobPodMetricsJob struct {
@@ -33,7 +32,11 @@ func (j *GetJobPodMetricsJob) Run(ctx context.Context) error {
 		return errors.New("job not found in the items list")
 	}
 
-	cpuUsageWithHistory, err := j.processor.prometheusProvider.GetCpuMetricsForPodOwnerPrefix(ctx, job.Namespace, job.Job.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
+	if err := kaytuPrometheus.WaitForHistory(ctx, j.processor.metricsProvider); err != nil {
+		return err
+	}
+
+	cpuUsageWithHistory, err := j.processor.metricsProvider.GetCpuMetricsForPodOwnerPrefix(ctx, job.Namespace, kaytuPrometheus.PodOwnerKindJob, job.Job.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
 	if err != nil {
 		return err
 	}
@@ -51,7 +54,7 @@ func (j *GetJobPodMetricsJob) Run(ctx context.Context) error {
 		}
 	}
 
-	cpuThrottlingWithHistory, err := j.processor.prometheusProvider.GetCpuThrottlingMetricsForPodOwnerPrefix(ctx, job.Namespace, job.Job.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
+	cpuThrottlingWithHistory, err := j.processor.metricsProvider.GetCpuThrottlingMetricsForPodOwnerPrefix(ctx, job.Namespace, kaytuPrometheus.PodOwnerKindJob, job.Job.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
 	if err != nil {
 		return err
 	}
@@ -69,7 +72,7 @@ func (j *GetJobPodMetricsJob) Run(ctx context.Context) error {
 		}
 	}
 
-	memoryUsageWithHistory, err := j.processor.prometheusProvider.GetMemoryMetricsForPodOwnerPrefix(ctx, job.Namespace, job.Job.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
+	memoryUsageWithHistory, err := j.processor.metricsProvider.GetMemoryMetricsForPodOwnerPrefix(ctx, job.Namespace, kaytuPrometheus.PodOwnerKindJob, job.Job.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeRandom)
 	if err != nil {
 		return err
 	}
@@ -88,7 +91,8 @@ func (j *GetJobPodMetricsJob) Run(ctx context.Context) error {
 	}
 
 	job.LazyLoadingEnabled = false
-	earliest := time.Now()
+	now := j.processor.kubernetesProvider.Now()
+	earliest := now
 	for _, pm := range []map[string]map[string][]kaytuPrometheus.PromDatapoint{cpuUsageWithHistory, cpuThrottlingWithHistory, memoryUsageWithHistory} {
 		for _, kvs := range pm {
 			for _, v := range kvs {
@@ -100,7 +104,11 @@ func (j *GetJobPodMetricsJob) Run(ctx context.Context) error {
 			}
 		}
 	}
-	job.ObservabilityDuration = time.Now().Sub(earliest)
+	job.ObservabilityDuration = now.Sub(earliest)
+	if reason := kaytuPrometheus.InsufficientHistory(j.processor.metricsProvider, job.Metrics["cpu_usage"]); reason != "" {
+		job.Skipped = true
+		job.SkipReason = reason
+	}
 
 	j.processor.items.Set(job.GetID(), job)
 	j.processor.publishOptimizationItem(job.ToOptimizationItem())
@@ -26,6 +26,7 @@ type JobItem struct {
 	LazyLoadingEnabled    bool
 	SkipReason            string
 	Metrics               map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint // Metric -> Pod -> Container -> Datapoints
+	LimitRangeNotes       map[string]string                                                // container -> how its recommendation was adjusted to the namespace LimitRanges
 	Wastage               *golang2.KubernetesJobOptimizationResponse
 	Nodes                 []shared.KubernetesNode
 	ObservabilityDuration time.Duration
@@ -42,7 +43,7 @@ func (i JobItem) Devices() ([]*golang.ChartRow, map[string]*golang.Properties) {
 	var rows []*golang.ChartRow
 	props := make(map[string]*golang.Properties)
 
-	for _, container := range i.Job.Spec.Template.Spec.Containers {
+	for _, container := range shared.PodContainers(i.Job.Spec.Template.Spec) {
 		var rightSizing *golang2.KubernetesContainerRightsizingRecommendation
 		if i.Wastage != nil && i.Wastage.Rightsizing != nil {
 			for _, c := range i.Wastage.Rightsizing.ContainerResizing {
@@ -52,7 +53,8 @@ func (i JobItem) Devices() ([]*golang.ChartRow, map[string]*golang.Properties) {
 			}
 		}
 
-		row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Job.Namespace, i.Job.Name, nil, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Preferences, i.ObservabilityDuration)
+		row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Job.Namespace, i.Job.Name, nil, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Metrics["cpu_throttling"], i.Preferences, i.ObservabilityDuration)
+		shared.AddLimitRangeProperty(properties, i.LimitRangeNotes[container.Name])
 		rows = append(rows, row)
 		props[row.RowId] = properties
 	}
@@ -64,7 +66,7 @@ func (i JobItem) Devices() ([]*golang.ChartRow, map[string]*golang.Properties) {
 		if i.Wastage != nil && i.Wastage.Rightsizing != nil {
 			podRs, podRsFound = i.Wastage.Rightsizing.PodContainerResizing[pod.Name]
 		}
-		for _, container := range pod.Spec.Containers {
+		for _, container := range shared.PodContainers(pod.Spec) {
 			var rightSizing *golang2.KubernetesContainerRightsizingRecommendation
 			if i.Wastage != nil && podRsFound {
 				for _, c := range podRs.ContainerResizing {
@@ -74,7 +76,8 @@ func (i JobItem) Devices() ([]*golang.ChartRow, map[string]*golang.Properties) {
 				}
 			}
 
-			row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Job.Namespace, i.Job.Name, &pod.Name, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Preferences, i.ObservabilityDuration)
+			row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Job.Namespace, i.Job.Name, &pod.Name, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Metrics["cpu_throttling"], i.Preferences, i.ObservabilityDuration)
+			shared.AddLimitRangeProperty(properties, i.LimitRangeNotes[container.Name])
 			rows = append(rows, row)
 			props[row.RowId] = properties
 		}
@@ -6,6 +6,7 @@ import (
 	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/preferences"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	batchv1 "k8s.io/api/batch/v1"
 )
 
 type ListJobsForNamespaceJob struct {
@@ -36,6 +37,10 @@ func (j *ListJobsForNamespaceJob) Run(ctx context.Context) error {
 	}
 
 	for _, job := range jobs {
+		if isOwnedByCronJob(job) {
+			// cronjob runs are aggregated under their cronjob by the cronjobs processor
+			continue
+		}
 		item := JobItem{
 			Job:                 job,
 			Namespace:           j.namespace,
@@ -67,3 +72,12 @@ func (j *ListJobsForNamespaceJob) Run(ctx context.Context) error {
 
 	return nil
 }
+
+func isOwnedByCronJob(job batchv1.Job) bool {
+	for _, owner := range job.OwnerReferences {
+		if owner.Kind == "CronJob" {
+			return true
+		}
+	}
+	return false
+}
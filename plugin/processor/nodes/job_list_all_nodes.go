@@ -33,8 +33,9 @@ func (j *ListAllNodesJob) Run(ctx context.Context) error {
 
 	for _, node := range nodes {
 		item := NodeItem{
-			Node:        node,
-			ClusterType: ClusterTypeUnknown,
+			Node:         node,
+			ClusterType:  ClusterTypeUnknown,
+			CapacityType: detectCapacityType(node.Labels),
 		}
 
 	clusterTypeLoop:
@@ -59,6 +60,9 @@ func (j *ListAllNodesJob) Run(ctx context.Context) error {
 		case ClusterTypeAwsEks, ClusterTypeAzureAks, ClusterTypeGoogleGke:
 			j.processor.jobQueue.Push(NewGetNodeCostJob(j.processor, item.GetID()))
 		default:
+			if _, ok := j.processor.pricingCatalog.NodeCost(node); ok {
+				continue
+			}
 			item.Skipped = true
 			item.SkipReason = "Unknown cluster type"
 			j.processor.items.Set(item.GetID(), item)
@@ -0,0 +1,49 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
+)
+
+type ListNamespacePoliciesJob struct {
+	processor *Processor
+	namespace string
+}
+
+func NewListNamespacePoliciesJob(processor *Processor, namespace *string) *ListNamespacePoliciesJob {
+	ns := ""
+	if namespace != nil {
+		ns = *namespace
+	}
+	return &ListNamespacePoliciesJob{
+		processor: processor,
+		namespace: ns,
+	}
+}
+
+func (j *ListNamespacePoliciesJob) Properties() sdk.JobProperties {
+	return sdk.JobProperties{
+		ID:          "list_namespace_policies_for_kubernetes_nodes",
+		Description: "Listing LimitRanges and ResourceQuotas (Kubernetes Nodes)",
+		MaxRetry:    0,
+	}
+}
+
+func (j *ListNamespacePoliciesJob) Run(ctx context.Context) error {
+	defer j.processor.policiesReady.Done()
+
+	// recommendations are still made without them, they are only checked against them
+	limitRanges, err := j.processor.kubernetesProvider.ListLimitRangesInNamespace(ctx, j.namespace)
+	if err != nil {
+		fmt.Println("failed to list limit ranges due to", err)
+	}
+	j.processor.limitRanges = limitRanges
+
+	resourceQuotas, err := j.processor.kubernetesProvider.ListResourceQuotasInNamespace(ctx, j.namespace)
+	if err != nil {
+		fmt.Println("failed to list resource quotas due to", err)
+	}
+	j.processor.resourceQuotas = resourceQuotas
+	return nil
+}
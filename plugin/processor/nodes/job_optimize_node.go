@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
-	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/version"
 	"google.golang.org/grpc/codes"
@@ -78,16 +77,23 @@ func (j *GetNodeCostJob) Run(ctx context.Context) error {
 		Node:           &node,
 	}
 	grpcCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("workspace-name", "kaytu"))
-	grpcCtx, cancel := context.WithTimeout(grpcCtx, shared.GrpcOptimizeRequestTimeout)
+	grpcCtx, cancel := context.WithTimeout(grpcCtx, j.processor.requestTimeout)
 	defer cancel()
 	response, err := j.processor.client.KubernetesNodeGetCost(grpcCtx, request)
 	if err != nil {
+		// the pricing catalog prices the node instead, see GetKubernetesNodes
+		if _, ok := j.processor.pricingCatalog.NodeCost(item.Node); ok {
+			return nil
+		}
 		if grpcErr, ok := status.FromError(err); ok && grpcErr.Code() == codes.InvalidArgument {
 			return nil
 		} else {
 			return err
 		}
 	}
+	if _, ok := j.processor.pricingCatalog.NodeCost(item.Node); ok && response.GetCost() == nil {
+		return nil
+	}
 
 	item.CostResponse = response
 	j.processor.items.Set(j.itemId, item)
@@ -7,8 +7,10 @@ import (
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/simulation"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	corev1 "k8s.io/api/core/v1"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type Processor struct {
@@ -20,6 +22,12 @@ type Processor struct {
 	jobQueue           *sdk.JobQueue
 	lazyloadCounter    *atomic.Uint32
 	nodesReady         sync.WaitGroup
+	requestTimeout     time.Duration
+	pricingCatalog     *shared.PricingCatalog
+
+	limitRanges    []corev1.LimitRange
+	resourceQuotas []corev1.ResourceQuota
+	policiesReady  sync.WaitGroup
 }
 
 func NewProcessor(processorConf shared.Configuration) *Processor {
@@ -32,10 +40,14 @@ func NewProcessor(processorConf shared.Configuration) *Processor {
 		lazyloadCounter:    processorConf.LazyloadCounter,
 		items:              utils.NewConcurrentMap[string, NodeItem](),
 		nodesReady:         sync.WaitGroup{},
+		requestTimeout:     processorConf.RequestTimeout,
+		pricingCatalog:     processorConf.PricingCatalog,
 	}
 	p.nodesReady.Add(1)
+	p.policiesReady.Add(1)
 
 	p.jobQueue.Push(NewListAllNodesJob(&p))
+	p.jobQueue.Push(NewListNamespacePoliciesJob(&p, processorConf.Namespace))
 	return &p
 }
 
@@ -43,17 +55,25 @@ func (p *Processor) GetKubernetesNodes() []shared.KubernetesNode {
 	p.nodesReady.Wait()
 	knodes := make([]shared.KubernetesNode, 0)
 	p.items.Range(func(_ string, nodeItem NodeItem) bool {
+		// allocatable leaves out what the kubelet reserves for the system and the eviction thresholds
+		allocatable := nodeItem.Node.Status.Allocatable
+		if len(allocatable) == 0 {
+			allocatable = nodeItem.Node.Status.Capacity
+		}
 		knode := shared.KubernetesNode{
-			Name:        nodeItem.Node.Name,
-			VCores:      float64(nodeItem.Node.Status.Capacity.Cpu().MilliValue()) / 1000.0,
-			Memory:      float64(nodeItem.Node.Status.Capacity.Memory().Value()) / simulation.GB,
-			MaxPodCount: nodeItem.Node.Status.Capacity.Pods().Value(),
-			Taints:      nodeItem.Node.Spec.Taints,
-			Labels:      nodeItem.Node.Labels,
+			Name:         nodeItem.Node.Name,
+			VCores:       float64(allocatable.Cpu().MilliValue()) / 1000.0,
+			Memory:       float64(allocatable.Memory().Value()) / simulation.GB,
+			MaxPodCount:  allocatable.Pods().Value(),
+			Taints:       nodeItem.Node.Spec.Taints,
+			Labels:       nodeItem.Node.Labels,
+			CapacityType: nodeItem.CapacityType,
 		}
 		if nodeItem.CostResponse != nil {
 			v := nodeItem.CostResponse.GetCost().GetValue()
 			knode.Cost = &v
+		} else if v, ok := p.pricingCatalog.NodeCost(nodeItem.Node); ok {
+			knode.Cost = &v
 		}
 
 		knodes = append(knodes, knode)
@@ -61,3 +81,20 @@ func (p *Processor) GetKubernetesNodes() []shared.KubernetesNode {
 	})
 	return knodes
 }
+
+// GetLimitRanges returns the LimitRanges of the namespace.
+func (p *Processor) GetLimitRanges(namespace string) []corev1.LimitRange {
+	p.policiesReady.Wait()
+	var limitRanges []corev1.LimitRange
+	for _, limitRange := range p.limitRanges {
+		if limitRange.Namespace == namespace {
+			limitRanges = append(limitRanges, limitRange)
+		}
+	}
+	return limitRanges
+}
+
+func (p *Processor) GetResourceQuotas() []corev1.ResourceQuota {
+	p.policiesReady.Wait()
+	return p.resourceQuotas
+}
@@ -2,8 +2,10 @@ package nodes
 
 import (
 	"fmt"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
 	corev1 "k8s.io/api/core/v1"
+	"strings"
 )
 
 type ClusterType int
@@ -16,8 +18,9 @@ const (
 )
 
 type NodeItem struct {
-	Node        corev1.Node
-	ClusterType ClusterType
+	Node         corev1.Node
+	ClusterType  ClusterType
+	CapacityType shared.CapacityType
 
 	Skipped            bool
 	SkipReason         string
@@ -29,3 +32,16 @@ type NodeItem struct {
 func (i NodeItem) GetID() string {
 	return fmt.Sprintf("corev1.node/%s", i.Node.Name)
 }
+
+// detectCapacityType reads the capacity type from the labels Karpenter, EKS, GKE and AKS put on spot and preemptible nodes.
+func detectCapacityType(labels map[string]string) shared.CapacityType {
+	switch {
+	case strings.EqualFold(labels["karpenter.sh/capacity-type"], "spot"),
+		strings.EqualFold(labels["eks.amazonaws.com/capacityType"], "SPOT"),
+		strings.EqualFold(labels["cloud.google.com/gke-spot"], "true"),
+		strings.EqualFold(labels["cloud.google.com/gke-preemptible"], "true"),
+		strings.EqualFold(labels["kubernetes.azure.com/scalesetpriority"], "spot"):
+		return shared.CapacityTypeSpot
+	}
+	return shared.CapacityTypeOnDemand
+}
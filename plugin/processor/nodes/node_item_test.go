@@ -0,0 +1,34 @@
+package nodes
+
+import (
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDetectCapacityType(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   shared.CapacityType
+	}{
+		{name: "no labels", want: shared.CapacityTypeOnDemand},
+		{name: "karpenter spot", labels: map[string]string{"karpenter.sh/capacity-type": "spot"}, want: shared.CapacityTypeSpot},
+		{name: "karpenter on-demand", labels: map[string]string{"karpenter.sh/capacity-type": "on-demand"}, want: shared.CapacityTypeOnDemand},
+		{name: "eks spot", labels: map[string]string{"eks.amazonaws.com/capacityType": "SPOT"}, want: shared.CapacityTypeSpot},
+		{name: "eks spot lower case", labels: map[string]string{"eks.amazonaws.com/capacityType": "spot"}, want: shared.CapacityTypeSpot},
+		{name: "eks on-demand", labels: map[string]string{"eks.amazonaws.com/capacityType": "ON_DEMAND"}, want: shared.CapacityTypeOnDemand},
+		{name: "gke spot", labels: map[string]string{"cloud.google.com/gke-spot": "true"}, want: shared.CapacityTypeSpot},
+		{name: "gke spot upper case", labels: map[string]string{"cloud.google.com/gke-spot": "TRUE"}, want: shared.CapacityTypeSpot},
+		{name: "gke preemptible", labels: map[string]string{"cloud.google.com/gke-preemptible": "true"}, want: shared.CapacityTypeSpot},
+		{name: "gke not spot", labels: map[string]string{"cloud.google.com/gke-spot": "false", "cloud.google.com/gke-preemptible": "false"}, want: shared.CapacityTypeOnDemand},
+		{name: "aks spot", labels: map[string]string{"kubernetes.azure.com/scalesetpriority": "spot"}, want: shared.CapacityTypeSpot},
+		{name: "aks spot mixed case", labels: map[string]string{"kubernetes.azure.com/scalesetpriority": "Spot"}, want: shared.CapacityTypeSpot},
+		{name: "aks regular", labels: map[string]string{"kubernetes.azure.com/scalesetpriority": "regular"}, want: shared.CapacityTypeOnDemand},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectCapacityType(tt.labels))
+		})
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
 	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
-	"time"
 )
 
 type GetPodMetricsJob struct {
@@ -35,17 +34,21 @@ func (j *GetPodMetricsJob) Run(ctx context.Context) error {
 		return errors.New("pod not found in items list")
 	}
 
-	cpuUsage, err := j.processor.prometheusProvider.GetCpuMetricsForPod(ctx, pod.Pod.Namespace, pod.Pod.Name, j.processor.observabilityDays)
+	if err := kaytuPrometheus.WaitForHistory(ctx, j.processor.metricsProvider); err != nil {
+		return err
+	}
+
+	cpuUsage, err := j.processor.metricsProvider.GetCpuMetricsForPod(ctx, pod.Pod.Namespace, pod.Pod.Name, j.processor.observabilityDays)
 	if err != nil {
 		return err
 	}
 
-	cpuThrottling, err := j.processor.prometheusProvider.GetCpuThrottlingMetricsForPod(ctx, pod.Pod.Namespace, pod.Pod.Name, j.processor.observabilityDays)
+	cpuThrottling, err := j.processor.metricsProvider.GetCpuThrottlingMetricsForPod(ctx, pod.Pod.Namespace, pod.Pod.Name, j.processor.observabilityDays)
 	if err != nil {
 		return err
 	}
 
-	memoryUsage, err := j.processor.prometheusProvider.GetMemoryMetricsForPod(ctx, pod.Pod.Namespace, pod.Pod.Name, j.processor.observabilityDays)
+	memoryUsage, err := j.processor.metricsProvider.GetMemoryMetricsForPod(ctx, pod.Pod.Namespace, pod.Pod.Name, j.processor.observabilityDays)
 	if err != nil {
 		return err
 	}
@@ -67,7 +70,8 @@ func (j *GetPodMetricsJob) Run(ctx context.Context) error {
 	}
 
 	pod.LazyLoadingEnabled = false
-	earliest := time.Now()
+	now := j.processor.kubernetesProvider.Now()
+	earliest := now
 	for _, pm := range []map[string][]kaytuPrometheus.PromDatapoint{cpuUsage, cpuThrottling, memoryUsage} {
 		for _, dps := range pm {
 			for _, m := range dps {
@@ -77,7 +81,11 @@ func (j *GetPodMetricsJob) Run(ctx context.Context) error {
 			}
 		}
 	}
-	pod.ObservabilityDuration = time.Now().Sub(earliest)
+	pod.ObservabilityDuration = now.Sub(earliest)
+	if reason := kaytuPrometheus.InsufficientHistory(j.processor.metricsProvider, map[string]map[string][]kaytuPrometheus.PromDatapoint{pod.Pod.Name: pod.Metrics["cpu_usage"]}); reason != "" {
+		pod.Skipped = true
+		pod.SkipReason = reason
+	}
 
 	j.processor.items.Set(pod.GetID(), pod)
 	j.processor.publishOptimizationItem(pod.ToOptimizationItem())
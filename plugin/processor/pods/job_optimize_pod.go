@@ -68,7 +68,7 @@ func (j *OptimizePodJob) Run(ctx context.Context) error {
 		OwnerKind:    ownerRefs,
 	}
 
-	for _, container := range item.Pod.Spec.Containers {
+	for _, container := range shared.PodContainers(item.Pod.Spec) {
 		pod.Containers = append(pod.Containers, &golang.KubernetesContainer{
 			Name:          container.Name,
 			MemoryRequest: container.Resources.Requests.Memory().AsApproximateFloat64(),
@@ -110,12 +110,21 @@ func (j *OptimizePodJob) Run(ctx context.Context) error {
 					v.Memory[dp.Timestamp.Format("2006-01-02 15:04:05")] = dp.Value
 				}
 				metrics[containerId] = v
+			} else if metricId == "cpu_throttling" {
+				v := metrics[containerId]
+				for _, dp := range datapoints {
+					if v.CpuThrottling == nil {
+						v.CpuThrottling = map[string]float64{}
+					}
+					v.CpuThrottling[dp.Timestamp.Format("2006-01-02 15:04:05")] = dp.Value
+				}
+				metrics[containerId] = v
 			}
 		}
 	}
 
 	grpcCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("workspace-name", "kaytu"))
-	grpcCtx, cancel := context.WithTimeout(grpcCtx, shared.GrpcOptimizeRequestTimeout)
+	grpcCtx, cancel := context.WithTimeout(grpcCtx, j.processor.requestTimeout)
 	defer cancel()
 	resp, err := j.processor.client.KubernetesPodOptimization(grpcCtx, &golang.KubernetesPodOptimizationRequest{
 		RequestId:      wrapperspb.String(reqID),
@@ -130,6 +139,12 @@ func (j *OptimizePodJob) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if resp.Rightsizing != nil {
+		shared.KeepThrottledCpuLimits(resp.Rightsizing.ContainerResizing, item.Metrics["cpu_throttling"])
+		if j.processor.nodeProcessor != nil {
+			item.LimitRangeNotes = shared.ClampToLimitRanges(resp.Rightsizing.ContainerResizing, j.processor.nodeProcessor.GetLimitRanges(item.Namespace))
+		}
+	}
 
 	item.LazyLoadingEnabled = false
 	item.OptimizationLoading = false
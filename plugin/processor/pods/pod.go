@@ -13,10 +13,9 @@ import (
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/simulation"
 	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
 	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
-	"strings"
+	"sort"
 	"sync/atomic"
+	"time"
 )
 
 type ProcessorMode int
@@ -31,7 +30,7 @@ type Processor struct {
 
 	identification            map[string]string
 	kubernetesProvider        *kaytuKubernetes.Kubernetes
-	prometheusProvider        *kaytuPrometheus.Prometheus
+	metricsProvider           kaytuPrometheus.MetricsProvider
 	items                     utils.ConcurrentMap[string, PodItem]
 	publishOptimizationItem   func(item *golang.ChartOptimizationItem)
 	publishResultSummary      func(summary *golang.ResultSummary)
@@ -44,6 +43,7 @@ type Processor struct {
 	selector                  string
 	nodeSelector              string
 	observabilityDays         int
+	requestTimeout            time.Duration
 	kaytuClient               *kaytuAgent.KaytuAgent
 	schedulingSim             *simulation.SchedulerService
 	schedulingSimPrev         *simulation.SchedulerService
@@ -58,7 +58,7 @@ func NewProcessor(processorConf shared.Configuration, mode ProcessorMode, nodePr
 		mode:                      mode,
 		identification:            processorConf.Identification,
 		kubernetesProvider:        processorConf.KubernetesProvider,
-		prometheusProvider:        processorConf.PrometheusProvider,
+		metricsProvider:           processorConf.MetricsProvider,
 		items:                     utils.NewConcurrentMap[string, PodItem](),
 		publishOptimizationItem:   processorConf.PublishOptimizationItem,
 		publishResultSummary:      processorConf.PublishResultSummary,
@@ -71,6 +71,7 @@ func NewProcessor(processorConf shared.Configuration, mode ProcessorMode, nodePr
 		selector:                  processorConf.Selector,
 		nodeSelector:              processorConf.NodeSelector,
 		observabilityDays:         processorConf.ObservabilityDays,
+		requestTimeout:            processorConf.RequestTimeout,
 		kaytuClient:               processorConf.KaytuClient,
 		defaultPreferences:        processorConf.DefaultPreferences,
 		nodeProcessor:             nodeProcessor,
@@ -103,120 +104,52 @@ func (m *Processor) ExportNonInteractive() *golang.NonInteractiveExport {
 }
 
 func (m *Processor) exportCsv() []*golang.CSVRow {
-	headers := []string{
-		"Namespace", "Pod Name", "Container Name", "Current CPU Request", "Current CPU Limit", "Current Memory Request",
-		"Current Memory Limit",
-		"Suggested CPU Request", "Suggested CPU Limit", "Suggested Memory Request", "Suggested Memory Limit",
-		"CPU Request Change", "CPU Limit Change", "Memory Request Change", "Memory Limit Change",
-		"Justification", "Additional Details",
-	}
 	var rows []*golang.CSVRow
-	rows = append(rows, &golang.CSVRow{Row: headers})
-
-	m.summary.Range(func(name string, value shared.ResourceSummary) bool {
-		pod, ok := m.items.Get(name)
-		if !ok {
-			return true
-		}
-		for _, container := range pod.Pod.Spec.Containers {
-			var row []string
-
-			row = append(row, pod.Pod.Namespace, pod.Pod.Name, container.Name)
-
-			var righSizing *golang2.KubernetesContainerRightsizingRecommendation
-			if pod.Wastage != nil {
-				for _, c := range pod.Wastage.Rightsizing.ContainerResizing {
-					if c.Name == container.Name {
-						righSizing = c
-					}
-				}
-			}
-			cpuRequest, cpuLimit, memoryRequest, memoryLimit := shared.GetContainerRequestLimits(container)
-
-			if cpuRequest != nil {
-				row = append(row, fmt.Sprintf("%.2f Core", *cpuRequest))
-			} else {
-				row = append(row, "Not configured")
-			}
-			if cpuLimit != nil {
-				row = append(row, fmt.Sprintf("%.2f Core", *cpuLimit))
-			} else {
-				row = append(row, "Not configured")
-			}
-			if memoryRequest != nil {
-				row = append(row, fmt.Sprintf("%.2f GB", *memoryRequest/(1024*1024*1024)))
-			} else {
-				row = append(row, "Not configured")
-			}
-			if memoryLimit != nil {
-				row = append(row, fmt.Sprintf("%.2f GB", *memoryLimit/(1024*1024*1024)))
-			} else {
-				row = append(row, "Not configured")
-			}
-
-			var additionalDetails []string
-			if righSizing != nil && righSizing.Recommended != nil {
-				row = append(row, fmt.Sprintf("%.2f Core", righSizing.Recommended.CpuRequest),
-					fmt.Sprintf("%.2f Core", righSizing.Recommended.CpuLimit),
-					fmt.Sprintf("%.2f GB", righSizing.Recommended.MemoryRequest/(1024*1024*1024)),
-					fmt.Sprintf("%.2f GB", righSizing.Recommended.MemoryLimit/(1024*1024*1024)))
-
-				if cpuRequest != nil {
-					row = append(row, fmt.Sprintf("%.2f Core", righSizing.Recommended.CpuRequest-*cpuRequest))
-				} else {
-					row = append(row, "Not configured")
-				}
-				if cpuLimit != nil {
-					row = append(row, fmt.Sprintf("%.2f Core", righSizing.Recommended.CpuLimit-*cpuLimit))
-				} else {
-					row = append(row, "Not configured")
-				}
-				if memoryRequest != nil {
-					row = append(row, fmt.Sprintf("%s", shared.SizeByte(righSizing.Recommended.MemoryRequest-*memoryRequest, false)))
-				} else {
-					row = append(row, "Not configured")
-				}
-				if memoryLimit != nil {
-					row = append(row, fmt.Sprintf("%s", shared.SizeByte(righSizing.Recommended.MemoryLimit-*memoryLimit, false)))
-				} else {
-					row = append(row, "Not configured")
-				}
-
-				row = append(row, righSizing.Description)
+	rows = append(rows, &golang.CSVRow{Row: shared.PodExportCsvHeaders})
+	rows = append(rows, m.ExportCsvRows()...)
+	return rows
+}
 
-				cpuTrimmedMean := 0.0
-				cpuMax := 0.0
-				memoryTrimmedMean := 0.0
-				memoryMax := 0.0
-				if righSizing.CpuTrimmedMean != nil {
-					cpuTrimmedMean = righSizing.CpuTrimmedMean.Value
-				}
-				if righSizing.CpuMax != nil {
-					cpuMax = righSizing.CpuMax.Value
-				}
-				if righSizing.MemoryTrimmedMean != nil {
-					memoryTrimmedMean = righSizing.MemoryTrimmedMean.Value
-				}
-				if righSizing.MemoryMax != nil {
-					memoryMax = righSizing.MemoryMax.Value
-				}
+func (m *Processor) ExportCsvRows() []*golang.CSVRow {
+	return shared.ExportCsvRows(m.ExportWorkloads())
+}
 
-				additionalDetails = append(additionalDetails,
-					fmt.Sprintf("CPU Usage:: Avg: %s - Max: %s",
-						fmt.Sprintf("%.2f", cpuTrimmedMean),
-						fmt.Sprintf("%.2f", cpuMax)))
-				additionalDetails = append(additionalDetails,
-					fmt.Sprintf("Memory Usage:: Avg: %s - Max: %s",
-						fmt.Sprintf("%.2f", memoryTrimmedMean/(1024*1024*1024)),
-						fmt.Sprintf("%.2f", memoryMax/(1024*1024*1024))))
-				row = append(row, strings.Join(additionalDetails, "---"))
-			}
-			rows = append(rows, &golang.CSVRow{Row: row})
-		}
+func (m *Processor) ExportWorkloads() []shared.ExportWorkload {
+	var ids []string
+	m.items.Range(func(id string, _ PodItem) bool {
+		ids = append(ids, id)
 		return true
 	})
+	sort.Strings(ids)
 
-	return rows
+	var workloads []shared.ExportWorkload
+	for _, id := range ids {
+		// only the pods that made it into the summary are exported
+		if _, ok := m.summary.Get(id); !ok {
+			continue
+		}
+		item, ok := m.items.Get(id)
+		if !ok {
+			continue
+		}
+		var rightSizing []*golang2.KubernetesContainerRightsizingRecommendation
+		if item.Wastage != nil && item.Wastage.Rightsizing != nil {
+			rightSizing = item.Wastage.Rightsizing.ContainerResizing
+		}
+		workloads = append(workloads, shared.ExportWorkload{
+			Kind:                  "Pod",
+			Namespace:             item.Pod.Namespace,
+			Name:                  item.Pod.Name,
+			Replicas:              1,
+			Containers:            shared.PodContainers(item.Pod.Spec),
+			Rightsizing:           rightSizing,
+			Cost:                  item.Cost,
+			ObservabilityDuration: item.ObservabilityDuration,
+			Skipped:               item.Skipped,
+			SkipReason:            item.SkipReason,
+		})
+	}
+	return workloads
 }
 
 func (m *Processor) GetSummaryMap() *utils.ConcurrentMap[string, shared.ResourceSummary] {
@@ -232,13 +165,17 @@ func (m *Processor) UpdateSummary(itemId string) {
 		memoryRequestChange, totalMemoryRequest := 0.0, 0.0
 		memoryLimitChange, totalMemoryLimit := 0.0, 0.0
 		for _, container := range i.Wastage.Rightsizing.ContainerResizing {
-			var pContainer corev1.Container
-			for _, podContainer := range i.Pod.Spec.Containers {
+			var pContainer shared.PodContainer
+			for _, podContainer := range shared.PodContainers(i.Pod.Spec) {
 				if podContainer.Name == container.Name {
 					pContainer = podContainer
 				}
 			}
-			cpuRequest, cpuLimit, memoryRequest, memoryLimit := shared.GetContainerRequestLimits(pContainer)
+			if pContainer.Type == shared.ContainerTypeInit {
+				// init containers are done before the pod runs, only the simulation accounts for them
+				continue
+			}
+			cpuRequest, cpuLimit, memoryRequest, memoryLimit := shared.GetContainerRequestLimits(pContainer.Container)
 			if container.Current != nil && container.Recommended != nil {
 				if cpuRequest != nil {
 					totalCpuRequest += container.Current.CpuRequest
@@ -260,6 +197,7 @@ func (m *Processor) UpdateSummary(itemId string) {
 		}
 
 		m.summary.Set(i.GetID(), shared.ResourceSummary{
+			Namespace:               i.Namespace,
 			ReplicaCount:            1,
 			CPURequestDownSizing:    min(0, cpuRequestChange),
 			CPURequestUpSizing:      max(0, cpuRequestChange),
@@ -286,31 +224,7 @@ func (m *Processor) UpdateSummary(itemId string) {
 		}
 		if m.schedulingSim != nil {
 			i.Pod = *i.Pod.DeepCopy()
-			for idx, c := range i.Pod.Spec.Containers {
-				for _, container := range i.Wastage.Rightsizing.ContainerResizing {
-					if container.Name != c.Name {
-						continue
-					}
-					if container.Recommended != nil {
-						c.Resources.Requests = map[corev1.ResourceName]resource.Quantity{}
-						c.Resources.Limits = map[corev1.ResourceName]resource.Quantity{}
-
-						c.Resources.Requests[corev1.ResourceCPU] = resource.Quantity{}
-						c.Resources.Requests.Cpu().SetMilli(int64(container.Recommended.CpuRequest * 1000))
-
-						c.Resources.Limits[corev1.ResourceCPU] = resource.Quantity{}
-						c.Resources.Limits.Cpu().SetMilli(int64(container.Recommended.CpuLimit * 1000))
-
-						c.Resources.Requests[corev1.ResourceMemory] = resource.Quantity{}
-						c.Resources.Requests.Memory().Set(int64(container.Recommended.MemoryRequest))
-
-						c.Resources.Limits[corev1.ResourceMemory] = resource.Quantity{}
-						c.Resources.Limits.Memory().Set(int64(container.Recommended.MemoryLimit))
-
-						i.Pod.Spec.Containers[idx] = c
-					}
-				}
-			}
+			shared.SetRecommendedResources(&i.Pod.Spec, i.Wastage.Rightsizing.ContainerResizing)
 
 			m.schedulingSim.AddPod(i.Pod)
 			nodes, err := m.schedulingSim.Simulate()
@@ -323,7 +237,7 @@ func (m *Processor) UpdateSummary(itemId string) {
 	}
 	rs, _ := shared.GetAggregatedResultsSummary(&m.summary)
 	m.publishResultSummary(rs)
-	rst, _ := shared.GetAggregatedResultsSummaryTable(&m.summary, m.nodeProcessor.GetKubernetesNodes(), removableNodes, removableNodesPrev)
+	rst, _ := shared.GetAggregatedResultsSummaryTable(&m.summary, m.nodeProcessor.GetKubernetesNodes(), removableNodes, removableNodesPrev, m.nodeProcessor.GetResourceQuotas())
 	m.publishResultSummaryTable(rst)
 }
 
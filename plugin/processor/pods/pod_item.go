@@ -25,6 +25,7 @@ type PodItem struct {
 	SkipReason            string
 	Metrics               map[string]map[string][]kaytuPrometheus.PromDatapoint // Metric -> Container -> Datapoints
 	ObservabilityDuration time.Duration
+	LimitRangeNotes       map[string]string // container -> how its recommendation was adjusted to the namespace LimitRanges
 	Wastage               *golang2.KubernetesPodOptimizationResponse
 	Nodes                 []shared.KubernetesNode
 	Cost                  float64
@@ -39,7 +40,7 @@ func (i PodItem) GetID() string {
 func (i PodItem) Devices() ([]*golang.ChartRow, map[string]*golang.Properties) {
 	var rows []*golang.ChartRow
 	props := make(map[string]*golang.Properties)
-	for _, container := range i.Pod.Spec.Containers {
+	for _, container := range shared.PodContainers(i.Pod.Spec) {
 		var rightSizing *golang2.KubernetesContainerRightsizingRecommendation
 		if i.Wastage != nil {
 			for _, c := range i.Wastage.Rightsizing.ContainerResizing {
@@ -49,7 +50,8 @@ func (i PodItem) Devices() ([]*golang.ChartRow, map[string]*golang.Properties) {
 			}
 		}
 
-		row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Pod.Namespace, i.Pod.Name, nil, map[string]map[string]float64{i.Pod.Name: i.VCpuHoursInPeriod}, map[string]map[string]float64{i.Pod.Name: i.MemoryGBHoursInPeriod}, i.Preferences, i.ObservabilityDuration)
+		row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Pod.Namespace, i.Pod.Name, nil, map[string]map[string]float64{i.Pod.Name: i.VCpuHoursInPeriod}, map[string]map[string]float64{i.Pod.Name: i.MemoryGBHoursInPeriod}, map[string]map[string][]kaytuPrometheus.PromDatapoint{i.Pod.Name: i.Metrics["cpu_throttling"]}, i.Preferences, i.ObservabilityDuration)
+		shared.AddLimitRangeProperty(properties, i.LimitRangeNotes[container.Name])
 		rows = append(rows, row)
 		props[row.RowId] = properties
 	}
@@ -0,0 +1,55 @@
+package shared
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+)
+
+// CapacityType tells spot and preemptible nodes, which the provider can take back at any time, from on-demand ones.
+type CapacityType string
+
+const (
+	CapacityTypeOnDemand CapacityType = "On-Demand"
+	CapacityTypeSpot     CapacityType = "Spot"
+)
+
+// capacityTypeCostRows breaks the cluster cost down by capacity type, it shows nothing when the cluster only has on-demand nodes.
+func capacityTypeCostRows(cluster, removableNodes []KubernetesNode) []*golang.ResultSummaryTableRow {
+	clusterCost := map[CapacityType]float64{}
+	reducedCost := map[CapacityType]float64{}
+	nodes := make(map[string]KubernetesNode)
+	for _, n := range cluster {
+		nodes[n.Name] = n
+		if n.Cost != nil {
+			clusterCost[n.capacityType()] += *n.Cost
+		}
+	}
+	if _, ok := clusterCost[CapacityTypeSpot]; !ok {
+		return nil
+	}
+	for _, r := range removableNodes {
+		if n, ok := nodes[r.Name]; ok && n.Cost != nil {
+			reducedCost[n.capacityType()] += *n.Cost
+		}
+	}
+
+	var rows []*golang.ResultSummaryTableRow
+	for _, capacityType := range []CapacityType{CapacityTypeOnDemand, CapacityTypeSpot} {
+		cost, reduced := clusterCost[capacityType], reducedCost[capacityType]
+		percentage := "-"
+		if cost > 0 {
+			percentage = SprintfWithStyle("%.2f%%", -reduced/cost*100.0, false)
+		}
+		rows = append(rows, &golang.ResultSummaryTableRow{
+			Cells: []string{
+				lipgloss.NewStyle().Foreground(lipgloss.Color("#dddddd")).Render(fmt.Sprintf("Cluster (%s Cost)", capacityType)),
+				fmt.Sprintf("$%.2f", cost),
+				fmt.Sprintf("$%.2f", cost-reduced),
+				fmt.Sprintf("-$%.2f", reduced),
+				percentage,
+			},
+		})
+	}
+	return rows
+}
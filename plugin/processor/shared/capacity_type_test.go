@@ -0,0 +1,70 @@
+package shared
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCapacityTypeCostRows(t *testing.T) {
+	cost := func(c float64) *float64 { return &c }
+
+	t.Run("only on-demand nodes", func(t *testing.T) {
+		cluster := []KubernetesNode{
+			{Name: "on-demand-1", CapacityType: CapacityTypeOnDemand, Cost: cost(100)},
+			{Name: "no-type", Cost: cost(50)},
+		}
+		assert.Empty(t, capacityTypeCostRows(cluster, cluster[:1]))
+	})
+
+	t.Run("spot without a cost", func(t *testing.T) {
+		cluster := []KubernetesNode{
+			{Name: "on-demand-1", CapacityType: CapacityTypeOnDemand, Cost: cost(100)},
+			{Name: "spot-1", CapacityType: CapacityTypeSpot},
+		}
+		assert.Empty(t, capacityTypeCostRows(cluster, nil))
+	})
+
+	tests := []struct {
+		name      string
+		removable []KubernetesNode
+		want      [][]string
+	}{
+		{
+			name: "nothing removed",
+			want: [][]string{
+				{"$150.00", "$150.00", "-$0.00", "0.00%"},
+				{"$40.00", "$40.00", "-$0.00", "0.00%"},
+			},
+		},
+		{
+			name:      "nodes removed from both",
+			removable: []KubernetesNode{{Name: "no-type"}, {Name: "spot-1"}, {Name: "unknown"}},
+			want: [][]string{
+				{"$150.00", "$100.00", "-$50.00", "-33.33%"},
+				{"$40.00", "$10.00", "-$30.00", "-75.00%"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := []KubernetesNode{
+				{Name: "on-demand-1", CapacityType: CapacityTypeOnDemand, Cost: cost(100)},
+				// nodes without a capacity type count as on-demand
+				{Name: "no-type", Cost: cost(50)},
+				{Name: "spot-1", CapacityType: CapacityTypeSpot, Cost: cost(30)},
+				{Name: "spot-2", CapacityType: CapacityTypeSpot, Cost: cost(10)},
+				{Name: "spot-no-cost", CapacityType: CapacityTypeSpot},
+			}
+			rows := capacityTypeCostRows(cluster, tt.removable)
+			if !assert.Len(t, rows, 2) {
+				return
+			}
+			assert.Contains(t, rows[0].Cells[0], "Cluster (On-Demand Cost)")
+			assert.Contains(t, rows[1].Cells[0], "Cluster (Spot Cost)")
+			for i, row := range rows {
+				assert.Equal(t, tt.want[i][:3], row.Cells[1:4])
+				assert.Contains(t, row.Cells[4], tt.want[i][3])
+			}
+		})
+	}
+}
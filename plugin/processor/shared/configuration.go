@@ -8,13 +8,15 @@ import (
 	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
 	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
 	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sync/atomic"
+	"time"
 )
 
 type Configuration struct {
 	Identification            map[string]string
 	KubernetesProvider        *kaytuKubernetes.Kubernetes
-	PrometheusProvider        *kaytuPrometheus.Prometheus
+	MetricsProvider           kaytuPrometheus.MetricsProvider
 	KaytuClient               *kaytuAgent.KaytuAgent
 	KaytuAcccessToken         string
 	PublishOptimizationItem   func(item *golang.ChartOptimizationItem)
@@ -29,4 +31,8 @@ type Configuration struct {
 	NodeSelector              string
 	ObservabilityDays         int
 	DefaultPreferences        []*golang.PreferenceItem
+	RequestTimeout            time.Duration
+	WorkloadTemplatePaths     map[schema.GroupVersionKind]string
+	PricingCatalog            *PricingCatalog
+	NodePoolMinSizes          map[string]int
 }
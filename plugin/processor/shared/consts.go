@@ -1,7 +1,6 @@
 package shared
 
-import "time"
-
 const (
-	GrpcOptimizeRequestTimeout = time.Minute * 1
+	// HeavyCpuThrottlingRatio is the p95 throttled-periods ratio above which a CPU limit is never cut.
+	HeavyCpuThrottlingRatio = 0.25
 )
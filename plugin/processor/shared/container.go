@@ -3,12 +3,87 @@ package shared
 import (
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
 	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"strconv"
 	"time"
 )
 
+type ContainerType string
+
+const (
+	ContainerTypeRegular ContainerType = ""
+	ContainerTypeInit    ContainerType = "init"
+	ContainerTypeSidecar ContainerType = "sidecar"
+)
+
+// PodContainer is a container of a pod spec along with the way the pod runs it.
+type PodContainer struct {
+	corev1.Container
+	Type ContainerType
+}
+
+// DisplayName is the container name, marked when it is an init container or a sidecar.
+func (c PodContainer) DisplayName() string {
+	if c.Type == ContainerTypeRegular {
+		return c.Name
+	}
+	return fmt.Sprintf("%s [%s]", c.Name, c.Type)
+}
+
+// IsSidecar reports whether the init container is a native sidecar, which keeps running next to the containers of the pod.
+func IsSidecar(container corev1.Container) bool {
+	return container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways
+}
+
+// PodContainers returns the containers of the pod spec followed by its init containers and sidecars.
+func PodContainers(spec corev1.PodSpec) []PodContainer {
+	containers := make([]PodContainer, 0, len(spec.Containers)+len(spec.InitContainers))
+	for _, container := range spec.Containers {
+		containers = append(containers, PodContainer{Container: container, Type: ContainerTypeRegular})
+	}
+	for _, container := range spec.InitContainers {
+		if IsSidecar(container) {
+			containers = append(containers, PodContainer{Container: container, Type: ContainerTypeSidecar})
+		} else {
+			containers = append(containers, PodContainer{Container: container, Type: ContainerTypeInit})
+		}
+	}
+	return containers
+}
+
+// SetRecommendedResources replaces the requests and limits of the containers, init containers and sidecars of the spec
+// with the recommended ones.
+func SetRecommendedResources(spec *corev1.PodSpec, containerResizing []*golang2.KubernetesContainerRightsizingRecommendation) {
+	for _, recommendation := range containerResizing {
+		if recommendation == nil || recommendation.Recommended == nil {
+			continue
+		}
+		resources := corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    *resource.NewMilliQuantity(int64(recommendation.Recommended.CpuRequest*1000), resource.DecimalSI),
+				corev1.ResourceMemory: *resource.NewQuantity(int64(recommendation.Recommended.MemoryRequest), resource.BinarySI),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    *resource.NewMilliQuantity(int64(recommendation.Recommended.CpuLimit*1000), resource.DecimalSI),
+				corev1.ResourceMemory: *resource.NewQuantity(int64(recommendation.Recommended.MemoryLimit), resource.BinarySI),
+			},
+		}
+		for idx := range spec.Containers {
+			if spec.Containers[idx].Name == recommendation.Name {
+				spec.Containers[idx].Resources = resources
+			}
+		}
+		for idx := range spec.InitContainers {
+			if spec.InitContainers[idx].Name == recommendation.Name {
+				spec.InitContainers[idx].Resources = resources
+			}
+		}
+	}
+}
+
 func GetContainerRequestLimits(container corev1.Container) (cpuRequest, cpuLimit, memoryRequest, memoryLimit *float64) {
 	if container.Resources.Requests.Cpu() != nil {
 		v := container.Resources.Requests.Cpu().AsApproximateFloat64()
@@ -38,13 +113,14 @@ func GetContainerRequestLimits(container corev1.Container) (cpuRequest, cpuLimit
 }
 
 func GetContainerDeviceRowAndProperties(
-	container corev1.Container,
+	container PodContainer,
 	rightSizing *golang2.KubernetesContainerRightsizingRecommendation,
 	namespace string,
 	name string,
 	podName *string,
 	vCpuHoursInPeriod map[string]map[string]float64,
 	memoryGBHoursInPeriod map[string]map[string]float64,
+	cpuThrottling map[string]map[string][]kaytuPrometheus.PromDatapoint,
 	preferences []*golang.PreferenceItem,
 	observabilityDuration time.Duration,
 ) (*golang.ChartRow, *golang.Properties) {
@@ -59,7 +135,7 @@ func GetContainerDeviceRowAndProperties(
 
 	if podName == nil {
 		row.Values["name"] = &golang.ChartRowItem{
-			Value: fmt.Sprintf("%s - Overall", container.Name),
+			Value: fmt.Sprintf("%s - Overall", container.DisplayName()),
 		}
 		properties.Properties = append(properties.Properties, &golang.Property{
 			Key:     "Name",
@@ -67,11 +143,11 @@ func GetContainerDeviceRowAndProperties(
 		})
 	} else {
 		row.Values["name"] = &golang.ChartRowItem{
-			Value: fmt.Sprintf("%s - %s", container.Name, *podName),
+			Value: fmt.Sprintf("%s - %s", container.DisplayName(), *podName),
 		}
 		properties.Properties = append(properties.Properties, &golang.Property{
 			Key:     "Container Name",
-			Current: container.Name,
+			Current: container.DisplayName(),
 		})
 		properties.Properties = append(properties.Properties, &golang.Property{
 			Key:     "Pod Name",
@@ -79,7 +155,7 @@ func GetContainerDeviceRowAndProperties(
 		})
 	}
 
-	cpuRequest, cpuLimit, memoryRequest, memoryLimit := GetContainerRequestLimits(container)
+	cpuRequest, cpuLimit, memoryRequest, memoryLimit := GetContainerRequestLimits(container.Container)
 
 	cpuRequestProperty := golang.Property{
 		Key: "CPU Request",
@@ -171,6 +247,25 @@ func GetContainerDeviceRowAndProperties(
 		properties.Properties = append(properties.Properties, &memoryHourProperty)
 	}
 
+	if cpuThrottling != nil {
+		var datapoints []kaytuPrometheus.PromDatapoint
+		if podName == nil {
+			for _, pod := range cpuThrottling {
+				datapoints = append(datapoints, pod[container.Name]...)
+			}
+		} else {
+			datapoints = cpuThrottling[*podName][container.Name]
+		}
+		if len(datapoints) > 0 {
+			avg, p95 := CpuThrottlingAverageAndP95(datapoints)
+			properties.Properties = append(properties.Properties, &golang.Property{
+				Key:     "CPU Throttling",
+				Average: fmt.Sprintf("avg: %.1f%%", avg*100),
+				Max:     fmt.Sprintf("p95: %.1f%%", p95*100),
+			})
+		}
+	}
+
 	if rightSizing != nil && rightSizing.Recommended != nil {
 		cpuRequestProperty.Recommended = fmt.Sprintf("%.2f (%+.2f)", rightSizing.Recommended.CpuRequest, rightSizing.Recommended.CpuRequest-rightSizing.Current.CpuRequest)
 		if rightSizing.CpuTrimmedMean != nil {
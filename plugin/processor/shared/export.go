@@ -0,0 +1,251 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	"math"
+	"strings"
+	"time"
+)
+
+// ExportCsvHeaders keeps the columns of the original pods export in place, columns added since are appended after them
+// so existing consumers keep working.
+var ExportCsvHeaders = exportCsvHeaders("Name")
+
+// PodExportCsvHeaders are the headers of the pods export, which has always named its name column "Pod Name".
+var PodExportCsvHeaders = exportCsvHeaders("Pod Name")
+
+func exportCsvHeaders(nameHeader string) []string {
+	return []string{
+		"Namespace", nameHeader, "Container Name",
+		"Current CPU Request", "Current CPU Limit", "Current Memory Request", "Current Memory Limit",
+		"Suggested CPU Request", "Suggested CPU Limit", "Suggested Memory Request", "Suggested Memory Limit",
+		"CPU Request Change", "CPU Limit Change", "Memory Request Change", "Memory Limit Change",
+		"Justification", "Additional Details",
+		"Kind", "Replicas", "Cost", "Observability Duration", "Skip Reason",
+	}
+}
+
+type ExportWorkload struct {
+	Cluster               string
+	Kind                  string
+	Namespace             string
+	Name                  string
+	Replicas              int32
+	Containers            []PodContainer
+	Rightsizing           []*golang2.KubernetesContainerRightsizingRecommendation
+	Cost                  float64
+	ObservabilityDuration time.Duration
+	Skipped               bool
+	SkipReason            string
+}
+
+func (w ExportWorkload) CsvRows() []*golang.CSVRow {
+	var rows []*golang.CSVRow
+	for _, container := range w.Containers {
+		row := []string{w.Namespace, w.Name, container.Name}
+
+		var rightSizing *golang2.KubernetesContainerRightsizingRecommendation
+		for _, c := range w.Rightsizing {
+			if c != nil && c.Name == container.Name {
+				rightSizing = c
+			}
+		}
+		cpuRequest, cpuLimit, memoryRequest, memoryLimit := GetContainerRequestLimits(container.Container)
+
+		row = append(row, csvCpu(cpuRequest), csvCpu(cpuLimit), csvMemory(memoryRequest), csvMemory(memoryLimit))
+
+		justification, additionalDetails := "", ""
+		if rightSizing != nil && rightSizing.Recommended != nil {
+			row = append(row, fmt.Sprintf("%.2f Core", rightSizing.Recommended.CpuRequest),
+				fmt.Sprintf("%.2f Core", rightSizing.Recommended.CpuLimit),
+				fmt.Sprintf("%.2f GB", rightSizing.Recommended.MemoryRequest/(1024*1024*1024)),
+				fmt.Sprintf("%.2f GB", rightSizing.Recommended.MemoryLimit/(1024*1024*1024)))
+
+			if cpuRequest != nil {
+				row = append(row, fmt.Sprintf("%.2f Core", rightSizing.Recommended.CpuRequest-*cpuRequest))
+			} else {
+				row = append(row, "Not configured")
+			}
+			if cpuLimit != nil {
+				row = append(row, fmt.Sprintf("%.2f Core", rightSizing.Recommended.CpuLimit-*cpuLimit))
+			} else {
+				row = append(row, "Not configured")
+			}
+			if memoryRequest != nil {
+				row = append(row, SizeByte(rightSizing.Recommended.MemoryRequest-*memoryRequest, false))
+			} else {
+				row = append(row, "Not configured")
+			}
+			if memoryLimit != nil {
+				row = append(row, SizeByte(rightSizing.Recommended.MemoryLimit-*memoryLimit, false))
+			} else {
+				row = append(row, "Not configured")
+			}
+
+			justification = rightSizing.Description
+
+			cpuTrimmedMean, cpuMax, memoryTrimmedMean, memoryMax := 0.0, 0.0, 0.0, 0.0
+			if rightSizing.CpuTrimmedMean != nil {
+				cpuTrimmedMean = rightSizing.CpuTrimmedMean.Value
+			}
+			if rightSizing.CpuMax != nil {
+				cpuMax = rightSizing.CpuMax.Value
+			}
+			if rightSizing.MemoryTrimmedMean != nil {
+				memoryTrimmedMean = rightSizing.MemoryTrimmedMean.Value
+			}
+			if rightSizing.MemoryMax != nil {
+				memoryMax = rightSizing.MemoryMax.Value
+			}
+			additionalDetails = strings.Join([]string{
+				fmt.Sprintf("CPU Usage:: Avg: %.2f - Max: %.2f", cpuTrimmedMean, cpuMax),
+				fmt.Sprintf("Memory Usage:: Avg: %.2f - Max: %.2f", memoryTrimmedMean/(1024*1024*1024), memoryMax/(1024*1024*1024)),
+			}, "---")
+		} else {
+			row = append(row, "", "", "", "", "", "", "", "")
+		}
+
+		cost := w.Cost
+		if math.IsNaN(cost) {
+			cost = 0
+		}
+		skipReason := ""
+		if w.Skipped {
+			skipReason = w.SkipReason
+		}
+		row = append(row, justification, additionalDetails,
+			w.Kind, fmt.Sprintf("%d", w.Replicas), fmt.Sprintf("$%0.2f", cost), w.ObservabilityDuration.String(), skipReason)
+
+		rows = append(rows, &golang.CSVRow{Row: row})
+	}
+	return rows
+}
+
+// ExportCsvRows returns the csv rows of the workloads, without headers.
+func ExportCsvRows(workloads []ExportWorkload) []*golang.CSVRow {
+	var rows []*golang.CSVRow
+	for _, w := range workloads {
+		rows = append(rows, w.CsvRows()...)
+	}
+	return rows
+}
+
+// ExportJSONResources are requests and limits in cores and bytes, nil when not configured.
+type ExportJSONResources struct {
+	CpuRequest    *float64 `json:"cpuRequest"`
+	CpuLimit      *float64 `json:"cpuLimit"`
+	MemoryRequest *float64 `json:"memoryRequest"`
+	MemoryLimit   *float64 `json:"memoryLimit"`
+}
+
+type ExportJSONContainer struct {
+	Name              string               `json:"name"`
+	Current           ExportJSONResources  `json:"current"`
+	Suggested         *ExportJSONResources `json:"suggested,omitempty"`
+	CpuTrimmedMean    *float64             `json:"cpuTrimmedMean,omitempty"`
+	CpuMax            *float64             `json:"cpuMax,omitempty"`
+	MemoryTrimmedMean *float64             `json:"memoryTrimmedMean,omitempty"`
+	MemoryMax         *float64             `json:"memoryMax,omitempty"`
+	Justification     string               `json:"justification,omitempty"`
+}
+
+// ExportJSONWorkload carries the same information as the csv rows of a workload, with its containers nested and raw numbers instead of formatted ones.
+type ExportJSONWorkload struct {
+	Cluster                      string                `json:"cluster,omitempty"`
+	Kind                         string                `json:"kind"`
+	Namespace                    string                `json:"namespace"`
+	Name                         string                `json:"name"`
+	Replicas                     int32                 `json:"replicas"`
+	Cost                         float64               `json:"cost"`
+	ObservabilityDurationSeconds float64               `json:"observabilityDurationSeconds"`
+	Skipped                      bool                  `json:"skipped"`
+	SkipReason                   string                `json:"skipReason,omitempty"`
+	Containers                   []ExportJSONContainer `json:"containers"`
+}
+
+func (w ExportWorkload) JSON() ExportJSONWorkload {
+	cost := w.Cost
+	if math.IsNaN(cost) {
+		cost = 0
+	}
+	result := ExportJSONWorkload{
+		Cluster:                      w.Cluster,
+		Kind:                         w.Kind,
+		Namespace:                    w.Namespace,
+		Name:                         w.Name,
+		Replicas:                     w.Replicas,
+		Cost:                         cost,
+		ObservabilityDurationSeconds: w.ObservabilityDuration.Seconds(),
+		Skipped:                      w.Skipped,
+		Containers:                   []ExportJSONContainer{},
+	}
+	if w.Skipped {
+		result.SkipReason = w.SkipReason
+	}
+	for _, container := range w.Containers {
+		cpuRequest, cpuLimit, memoryRequest, memoryLimit := GetContainerRequestLimits(container.Container)
+		c := ExportJSONContainer{
+			Name: container.Name,
+			Current: ExportJSONResources{
+				CpuRequest:    cpuRequest,
+				CpuLimit:      cpuLimit,
+				MemoryRequest: memoryRequest,
+				MemoryLimit:   memoryLimit,
+			},
+		}
+		for _, r := range w.Rightsizing {
+			if r == nil || r.Name != container.Name {
+				continue
+			}
+			if r.Recommended != nil {
+				c.Suggested = &ExportJSONResources{
+					CpuRequest:    &r.Recommended.CpuRequest,
+					CpuLimit:      &r.Recommended.CpuLimit,
+					MemoryRequest: &r.Recommended.MemoryRequest,
+					MemoryLimit:   &r.Recommended.MemoryLimit,
+				}
+			}
+			if r.CpuTrimmedMean != nil {
+				c.CpuTrimmedMean = &r.CpuTrimmedMean.Value
+			}
+			if r.CpuMax != nil {
+				c.CpuMax = &r.CpuMax.Value
+			}
+			if r.MemoryTrimmedMean != nil {
+				c.MemoryTrimmedMean = &r.MemoryTrimmedMean.Value
+			}
+			if r.MemoryMax != nil {
+				c.MemoryMax = &r.MemoryMax.Value
+			}
+			c.Justification = r.Description
+		}
+		result.Containers = append(result.Containers, c)
+	}
+	return result
+}
+
+// ExportJSON returns the workloads as an indented json array.
+func ExportJSON(workloads []ExportWorkload) ([]byte, error) {
+	result := make([]ExportJSONWorkload, 0, len(workloads))
+	for _, w := range workloads {
+		result = append(result, w.JSON())
+	}
+	return json.MarshalIndent(result, "", "  ")
+}
+
+func csvCpu(v *float64) string {
+	if v == nil {
+		return "Not configured"
+	}
+	return fmt.Sprintf("%.2f Core", *v)
+}
+
+func csvMemory(v *float64) string {
+	if v == nil {
+		return "Not configured"
+	}
+	return fmt.Sprintf("%.2f GB", *v/(1024*1024*1024))
+}
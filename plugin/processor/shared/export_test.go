@@ -0,0 +1,176 @@
+package shared
+
+import (
+	"encoding/json"
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"math"
+	"testing"
+	"time"
+)
+
+func exportTestWorkload() ExportWorkload {
+	return ExportWorkload{
+		Kind:      "Deployment",
+		Namespace: "shop",
+		Name:      "web",
+		Replicas:  3,
+		Containers: []PodContainer{
+			{Container: corev1.Container{Name: "app", Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+				Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			}}},
+			{Container: corev1.Container{Name: "proxy"}, Type: ContainerTypeSidecar},
+		},
+		Rightsizing: []*golang2.KubernetesContainerRightsizingRecommendation{
+			nil,
+			{
+				Name: "app",
+				Recommended: &golang2.RightsizingKubernetesContainer{
+					CpuRequest: 0.25, CpuLimit: 0.5, MemoryRequest: 512 * 1024 * 1024, MemoryLimit: gib,
+				},
+				CpuTrimmedMean:    wrapperspb.Double(0.1),
+				CpuMax:            wrapperspb.Double(0.2),
+				MemoryTrimmedMean: wrapperspb.Double(256 * 1024 * 1024),
+				MemoryMax:         wrapperspb.Double(512 * 1024 * 1024),
+				Description:       "Usage is well below the requests.",
+			},
+		},
+		Cost:                  12.5,
+		ObservabilityDuration: 7 * 24 * time.Hour,
+	}
+}
+
+func TestExportWorkloadCsvRows(t *testing.T) {
+	appRow := []string{
+		"shop", "web", "app",
+		"0.50 Core", "1.00 Core", "1.00 GB", "Not configured",
+		"0.25 Core", "0.50 Core", "0.50 GB", "1.00 GB",
+		"-0.25 Core", "-0.50 Core", "-512.0 MB", "Not configured",
+		"Usage is well below the requests.", "CPU Usage:: Avg: 0.10 - Max: 0.20---Memory Usage:: Avg: 0.25 - Max: 0.50",
+		"Deployment", "3", "$12.50", "168h0m0s", "",
+	}
+	// a container without a recommendation keeps its current values and leaves the 8 suggestion and change cells empty
+	proxyRow := []string{
+		"shop", "web", "proxy",
+		"Not configured", "Not configured", "Not configured", "Not configured",
+		"", "", "", "", "", "", "", "",
+		"", "",
+		"Deployment", "3", "$12.50", "168h0m0s", "",
+	}
+	skippedAppRow := []string{
+		"shop", "web", "app",
+		"0.50 Core", "1.00 Core", "1.00 GB", "Not configured",
+		"", "", "", "", "", "", "", "",
+		"", "",
+		"Deployment", "3", "$12.50", "168h0m0s", "not in selected nodes",
+	}
+	with := func(row []string, idx int, value string) []string {
+		row = append([]string(nil), row...)
+		row[idx] = value
+		return row
+	}
+
+	tests := []struct {
+		name     string
+		workload func(w *ExportWorkload)
+		want     [][]string
+	}{
+		{name: "recommended", workload: func(*ExportWorkload) {}, want: [][]string{appRow, proxyRow}},
+		{
+			name: "skipped",
+			workload: func(w *ExportWorkload) {
+				w.Rightsizing = nil
+				w.Skipped = true
+				w.SkipReason = "not in selected nodes"
+			},
+			want: [][]string{skippedAppRow, with(proxyRow, 21, "not in selected nodes")},
+		},
+		{
+			name: "skip reason without skip",
+			workload: func(w *ExportWorkload) {
+				w.SkipReason = "left over"
+			},
+			want: [][]string{appRow, proxyRow},
+		},
+		{
+			name: "NaN cost",
+			workload: func(w *ExportWorkload) {
+				w.Cost = math.NaN()
+			},
+			want: [][]string{with(appRow, 19, "$0.00"), with(proxyRow, 19, "$0.00")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := exportTestWorkload()
+			tt.workload(&w)
+
+			var rows [][]string
+			for _, row := range w.CsvRows() {
+				assert.Len(t, row.Row, len(ExportCsvHeaders))
+				rows = append(rows, row.Row)
+			}
+			assert.Equal(t, tt.want, rows)
+		})
+	}
+}
+
+func TestExportCsvHeaders(t *testing.T) {
+	// the pods export keeps the columns it always had, in place
+	assert.Equal(t, []string{
+		"Namespace", "Pod Name", "Container Name", "Current CPU Request", "Current CPU Limit", "Current Memory Request",
+		"Current Memory Limit",
+		"Suggested CPU Request", "Suggested CPU Limit", "Suggested Memory Request", "Suggested Memory Limit",
+		"CPU Request Change", "CPU Limit Change", "Memory Request Change", "Memory Limit Change",
+		"Justification", "Additional Details",
+	}, PodExportCsvHeaders[:17])
+	assert.Equal(t, "Name", ExportCsvHeaders[1])
+	assert.Equal(t, PodExportCsvHeaders[2:], ExportCsvHeaders[2:])
+}
+
+func TestExportWorkloadJSON(t *testing.T) {
+	w := exportTestWorkload()
+	w.Cost = math.NaN()
+	w.SkipReason = "left over"
+
+	result := w.JSON()
+	assert.Equal(t, 0.0, result.Cost)
+	assert.Equal(t, float64(7*24*60*60), result.ObservabilityDurationSeconds)
+	assert.Empty(t, result.SkipReason)
+	if assert.Len(t, result.Containers, 2) {
+		app, proxy := result.Containers[0], result.Containers[1]
+		assert.Equal(t, 0.5, *app.Current.CpuRequest)
+		assert.Nil(t, app.Current.MemoryLimit)
+		if assert.NotNil(t, app.Suggested) {
+			assert.Equal(t, 0.25, *app.Suggested.CpuRequest)
+			assert.Equal(t, float64(gib), *app.Suggested.MemoryLimit)
+		}
+		assert.Equal(t, 0.2, *app.CpuMax)
+		assert.Equal(t, "Usage is well below the requests.", app.Justification)
+
+		assert.Equal(t, "proxy", proxy.Name)
+		assert.Nil(t, proxy.Suggested)
+		assert.Nil(t, proxy.CpuMax)
+	}
+
+	// a NaN cost would make the whole export fail to encode
+	data, err := ExportJSON([]ExportWorkload{w})
+	assert.NoError(t, err)
+	var decoded []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	if assert.Len(t, decoded, 1) {
+		assert.Equal(t, "web", decoded[0]["name"])
+		assert.NotContains(t, decoded[0], "skipReason")
+	}
+
+	w.Skipped = true
+	w.Rightsizing = nil
+	result = w.JSON()
+	assert.True(t, result.Skipped)
+	assert.Equal(t, "left over", result.SkipReason)
+	assert.Nil(t, result.Containers[0].Suggested)
+}
@@ -0,0 +1,182 @@
+package shared
+
+import (
+	"fmt"
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"math"
+)
+
+// HPAEstimate is how many replicas a horizontal pod autoscaler is expected to run once a recommendation is applied.
+type HPAEstimate struct {
+	Name              string
+	Replicas          int32
+	EstimatedReplicas int32
+	MaxReplicas       int32
+}
+
+// AtMaxReplicas is true when the autoscaler would have to go past its maxReplicas to keep its utilization targets.
+func (e HPAEstimate) AtMaxReplicas() bool {
+	return e.EstimatedReplicas >= e.MaxReplicas
+}
+
+func (e HPAEstimate) String() string {
+	s := fmt.Sprintf("HPA %s: %d replicas now, about %d after the change, maxReplicas %d", e.Name, e.Replicas, e.EstimatedReplicas, e.MaxReplicas)
+	if e.AtMaxReplicas() {
+		s += ", the HPA would run at its maxReplicas and lose its headroom"
+	}
+	return s
+}
+
+// FindHorizontalPodAutoscaler returns the autoscaler scaling the apps/v1 workload of the given kind and name, nil if there is none.
+func FindHorizontalPodAutoscaler(hpas []autoscalingv2.HorizontalPodAutoscaler, kind, name string) *autoscalingv2.HorizontalPodAutoscaler {
+	for _, hpa := range hpas {
+		ref := hpa.Spec.ScaleTargetRef
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil || gv.Group != "apps" {
+			continue
+		}
+		if ref.Kind == kind && ref.Name == name {
+			hpa := hpa
+			return &hpa
+		}
+	}
+	return nil
+}
+
+// ScalesOnResources is true when the autoscaler scales on the cpu or memory of the pods or of one of their containers.
+func ScalesOnResources(hpa *autoscalingv2.HorizontalPodAutoscaler) bool {
+	if hpa == nil {
+		return false
+	}
+	for _, metric := range hpa.Spec.Metrics {
+		var name corev1.ResourceName
+		switch {
+		case metric.Type == autoscalingv2.ResourceMetricSourceType && metric.Resource != nil:
+			name = metric.Resource.Name
+		case metric.Type == autoscalingv2.ContainerResourceMetricSourceType && metric.ContainerResource != nil:
+			name = metric.ContainerResource.Name
+		default:
+			continue
+		}
+		if name == corev1.ResourceCPU || name == corev1.ResourceMemory {
+			return true
+		}
+	}
+	return false
+}
+
+// recommendedPodRequests sums the requests of the containers and sidecars of the pod, with the recommended values where there are any.
+func recommendedPodRequests(spec corev1.PodSpec, containerResizing []*golang2.KubernetesContainerRightsizingRecommendation) (cpu, memory float64) {
+	for _, container := range PodContainers(spec) {
+		if container.Type == ContainerTypeInit {
+			continue
+		}
+		cpuRequest := container.Resources.Requests.Cpu().AsApproximateFloat64()
+		memoryRequest := container.Resources.Requests.Memory().AsApproximateFloat64()
+		for _, c := range containerResizing {
+			if c != nil && c.Name == container.Name && c.Recommended != nil {
+				cpuRequest, memoryRequest = c.Recommended.CpuRequest, c.Recommended.MemoryRequest
+			}
+		}
+		cpu += cpuRequest
+		memory += memoryRequest
+	}
+	return cpu, memory
+}
+
+// averagePodUsage is the average over pods of what their containers use together, pods without a usable datapoint are left out.
+func averagePodUsage(podMetrics map[string]map[string][]kaytuPrometheus.PromDatapoint) float64 {
+	total, pods := 0.0, 0
+	for _, containerMetrics := range podMetrics {
+		podUsage, sampled := 0.0, false
+		for _, datapoints := range containerMetrics {
+			sum, count := 0.0, 0
+			for _, dp := range datapoints {
+				if math.IsNaN(dp.Value) || math.IsInf(dp.Value, 0) {
+					continue
+				}
+				sum += dp.Value
+				count++
+			}
+			if count > 0 {
+				podUsage += sum / float64(count)
+				sampled = true
+			}
+		}
+		if !sampled {
+			continue
+		}
+		total += podUsage
+		pods++
+	}
+	if pods == 0 {
+		return 0
+	}
+	return total / float64(pods)
+}
+
+// EstimateHPAReplicas estimates the replicas the autoscaler runs once the recommended requests are applied, assuming the workload
+// keeps using what it used over the observability period. Only cpu and memory utilization targets depend on requests, ok is false
+// when the autoscaler has none of them or there is not enough usage data.
+func EstimateHPAReplicas(hpa *autoscalingv2.HorizontalPodAutoscaler, replicas int32, spec corev1.PodSpec, containerResizing []*golang2.KubernetesContainerRightsizingRecommendation, metrics map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint) (HPAEstimate, bool) {
+	if hpa == nil || replicas <= 0 {
+		return HPAEstimate{}, false
+	}
+	estimate := HPAEstimate{
+		Name:        hpa.Name,
+		Replicas:    replicas,
+		MaxReplicas: hpa.Spec.MaxReplicas,
+	}
+	minReplicas := int32(1)
+	if hpa.Spec.MinReplicas != nil {
+		minReplicas = *hpa.Spec.MinReplicas
+	}
+
+	cpuRequest, memoryRequest := recommendedPodRequests(spec, containerResizing)
+	found := false
+	for _, metric := range hpa.Spec.Metrics {
+		if metric.Type != autoscalingv2.ResourceMetricSourceType || metric.Resource == nil ||
+			metric.Resource.Target.Type != autoscalingv2.UtilizationMetricType || metric.Resource.Target.AverageUtilization == nil {
+			continue
+		}
+		var usage, request float64
+		switch metric.Resource.Name {
+		case corev1.ResourceCPU:
+			usage, request = averagePodUsage(metrics["cpu_usage"]), cpuRequest
+		case corev1.ResourceMemory:
+			usage, request = averagePodUsage(metrics["memory_usage"]), memoryRequest
+		default:
+			continue
+		}
+		target := float64(*metric.Resource.Target.AverageUtilization) / 100
+		if usage <= 0 || request <= 0 || target <= 0 {
+			continue
+		}
+
+		// the same total usage spread over pods with the new request, sized to meet the target
+		desired := int32(math.Ceil(usage * float64(replicas) / (request * target)))
+		if !found || desired > estimate.EstimatedReplicas {
+			estimate.EstimatedReplicas = desired
+		}
+		found = true
+	}
+	if !found {
+		return HPAEstimate{}, false
+	}
+
+	estimate.EstimatedReplicas = max(minReplicas, min(estimate.EstimatedReplicas, estimate.MaxReplicas))
+	return estimate, true
+}
+
+// ReplicaAdjustedChange returns the per replica change that, over replicas replicas, adds up to the same total as applying change
+// to every one of recommendedReplicas replicas.
+func ReplicaAdjustedChange(total, change float64, replicas, recommendedReplicas int32) float64 {
+	if replicas <= 0 || replicas == recommendedReplicas {
+		return change
+	}
+	return ((total+change)*float64(recommendedReplicas) - total*float64(replicas)) / float64(replicas)
+}
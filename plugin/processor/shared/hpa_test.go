@@ -0,0 +1,180 @@
+package shared
+
+import (
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	"github.com/stretchr/testify/assert"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"math"
+	"testing"
+)
+
+func utilizationMetric(name corev1.ResourceName, utilization int32) autoscalingv2.MetricSpec {
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name:   name,
+			Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &utilization},
+		},
+	}
+}
+
+func testHPA(minReplicas *int32, maxReplicas int32, metrics ...autoscalingv2.MetricSpec) *autoscalingv2.HorizontalPodAutoscaler {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+		MinReplicas: minReplicas,
+		MaxReplicas: maxReplicas,
+		Metrics:     metrics,
+	}}
+	hpa.Name = "web"
+	return hpa
+}
+
+// podUsage is the usage of the app container of every pod, one datapoint each.
+func podUsage(values ...float64) map[string]map[string][]kaytuPrometheus.PromDatapoint {
+	usage := map[string]map[string][]kaytuPrometheus.PromDatapoint{}
+	for i, v := range values {
+		usage[string(rune('a'+i))] = map[string][]kaytuPrometheus.PromDatapoint{"app": {{Value: v}}}
+	}
+	return usage
+}
+
+func TestEstimateHPAReplicas(t *testing.T) {
+	spec := corev1.PodSpec{Containers: []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		}},
+	}}}
+	resizing := func(cpu, memory float64) []*golang2.KubernetesContainerRightsizingRecommendation {
+		return []*golang2.KubernetesContainerRightsizingRecommendation{{
+			Name:        "app",
+			Recommended: &golang2.RightsizingKubernetesContainer{CpuRequest: cpu, MemoryRequest: memory},
+		}}
+	}
+	three := int32(3)
+	averageValue := autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name:   corev1.ResourceCPU,
+			Target: autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: resource.NewMilliQuantity(500, resource.DecimalSI)},
+		},
+	}
+	metrics := map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint{
+		"cpu_usage":    podUsage(0.25, 0.25, math.NaN()),
+		"memory_usage": podUsage(256*1024*1024, 256*1024*1024),
+	}
+
+	tests := []struct {
+		name     string
+		hpa      *autoscalingv2.HorizontalPodAutoscaler
+		replicas int32
+		resizing []*golang2.KubernetesContainerRightsizingRecommendation
+		metrics  map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint
+		want     HPAEstimate
+		wantOk   bool
+	}{
+		{
+			name:     "no autoscaler",
+			replicas: 4,
+			metrics:  metrics,
+		},
+		{
+			name:     "no utilization metric",
+			hpa:      testHPA(nil, 10, averageValue),
+			replicas: 4,
+			resizing: resizing(0.5, gib),
+			metrics:  metrics,
+		},
+		{
+			name:     "no usage",
+			hpa:      testHPA(nil, 10, utilizationMetric(corev1.ResourceCPU, 50)),
+			replicas: 4,
+			resizing: resizing(0.5, gib),
+		},
+		{
+			name:     "current request without a recommendation",
+			hpa:      testHPA(nil, 10, utilizationMetric(corev1.ResourceCPU, 50)),
+			replicas: 4,
+			metrics:  metrics,
+			want:     HPAEstimate{Name: "web", Replicas: 4, EstimatedReplicas: 2, MaxReplicas: 10},
+			wantOk:   true,
+		},
+		{
+			name:     "cpu utilization with the recommended request",
+			hpa:      testHPA(nil, 10, utilizationMetric(corev1.ResourceCPU, 50)),
+			replicas: 4,
+			resizing: resizing(0.25, gib),
+			metrics:  metrics,
+			want:     HPAEstimate{Name: "web", Replicas: 4, EstimatedReplicas: 8, MaxReplicas: 10},
+			wantOk:   true,
+		},
+		{
+			name:     "clamped to maxReplicas",
+			hpa:      testHPA(nil, 5, utilizationMetric(corev1.ResourceCPU, 50)),
+			replicas: 4,
+			resizing: resizing(0.25, gib),
+			metrics:  metrics,
+			want:     HPAEstimate{Name: "web", Replicas: 4, EstimatedReplicas: 5, MaxReplicas: 5},
+			wantOk:   true,
+		},
+		{
+			name:     "clamped to minReplicas",
+			hpa:      testHPA(&three, 10, utilizationMetric(corev1.ResourceCPU, 50)),
+			replicas: 1,
+			resizing: resizing(1, gib),
+			metrics:  metrics,
+			want:     HPAEstimate{Name: "web", Replicas: 1, EstimatedReplicas: 3, MaxReplicas: 10},
+			wantOk:   true,
+		},
+		{
+			name:     "the metric asking for the most replicas wins",
+			hpa:      testHPA(nil, 10, utilizationMetric(corev1.ResourceCPU, 50), utilizationMetric(corev1.ResourceMemory, 25)),
+			replicas: 4,
+			resizing: resizing(0.5, 512*1024*1024),
+			metrics:  metrics,
+			want:     HPAEstimate{Name: "web", Replicas: 4, EstimatedReplicas: 8, MaxReplicas: 10},
+			wantOk:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate, ok := EstimateHPAReplicas(tt.hpa, tt.replicas, spec, tt.resizing, tt.metrics)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, estimate)
+		})
+	}
+}
+
+func TestHPAEstimateAtMaxReplicas(t *testing.T) {
+	assert.False(t, HPAEstimate{EstimatedReplicas: 4, MaxReplicas: 5}.AtMaxReplicas())
+	assert.True(t, HPAEstimate{EstimatedReplicas: 5, MaxReplicas: 5}.AtMaxReplicas())
+	assert.Contains(t, HPAEstimate{Name: "web", Replicas: 4, EstimatedReplicas: 5, MaxReplicas: 5}.String(), "lose its headroom")
+}
+
+func TestReplicaAdjustedChange(t *testing.T) {
+	tests := []struct {
+		name                string
+		total, change       float64
+		replicas            int32
+		recommendedReplicas int32
+		want                float64
+	}{
+		{name: "same replicas", total: 10, change: -2, replicas: 4, recommendedReplicas: 4, want: -2},
+		{name: "no replicas", total: 10, change: -2, replicas: 0, recommendedReplicas: 4, want: -2},
+		{name: "more replicas", total: 10, change: -2, replicas: 4, recommendedReplicas: 6, want: 2},
+		{name: "fewer replicas", total: 10, change: 2, replicas: 4, recommendedReplicas: 2, want: -4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change := ReplicaAdjustedChange(tt.total, tt.change, tt.replicas, tt.recommendedReplicas)
+			assert.Equal(t, tt.want, change)
+			if tt.replicas > 0 {
+				// the change over the current replicas adds up to the recommended change over the recommended replicas
+				assert.Equal(t, (tt.total+tt.change)*float64(tt.recommendedReplicas), (tt.total+change)*float64(tt.replicas))
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package shared
+
+import (
+	"fmt"
+	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	corev1 "k8s.io/api/core/v1"
+	"strings"
+)
+
+// ClampToLimitRanges moves the recommendations of the containers inside the Container limits of the namespace LimitRanges, so they
+// are not rejected at admission. It returns why each adjusted container was changed, keyed by container name.
+func ClampToLimitRanges(containers []*golang2.KubernetesContainerRightsizingRecommendation, limitRanges []corev1.LimitRange) map[string]string {
+	notes := make(map[string]string)
+	for _, c := range containers {
+		if c == nil || c.Recommended == nil {
+			continue
+		}
+
+		var changes []string
+		for _, limitRange := range limitRanges {
+			for _, limit := range limitRange.Spec.Limits {
+				if limit.Type != corev1.LimitTypeContainer {
+					continue
+				}
+				changes = append(changes, clampToLimit(limitRange.Name, corev1.ResourceCPU, &c.Recommended.CpuRequest, &c.Recommended.CpuLimit, limit)...)
+				changes = append(changes, clampToLimit(limitRange.Name, corev1.ResourceMemory, &c.Recommended.MemoryRequest, &c.Recommended.MemoryLimit, limit)...)
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		note := strings.Join(changes, ", ")
+		notes[c.Name] = note
+		if c.Description != "" {
+			c.Description = c.Description + "\n" + note
+		} else {
+			c.Description = note
+		}
+	}
+	return notes
+}
+
+// clampToLimit applies default, min, max and maxLimitRequestRatio of the resource. A zero limit means there is none, it is left
+// as is unless admission would fill it with a default limit below the request and reject the container.
+func clampToLimit(limitRangeName string, resourceName corev1.ResourceName, request, limit *float64, item corev1.LimitRangeItem) []string {
+	var changes []string
+	format := func(v float64) string {
+		if resourceName == corev1.ResourceMemory {
+			return SizeByte(v, false)
+		}
+		return fmt.Sprintf("%.2f", v)
+	}
+
+	if q, ok := item.Default[resourceName]; ok && *limit == 0 {
+		defaultValue := q.AsApproximateFloat64()
+		if *request > defaultValue {
+			changes = append(changes, fmt.Sprintf("%s limit set to the request since the %s default limit of LimitRange %s is below it", resourceName, format(defaultValue), limitRangeName))
+			*limit = *request
+		}
+	}
+	if q, ok := item.Min[resourceName]; ok {
+		minValue := q.AsApproximateFloat64()
+		if *request < minValue {
+			changes = append(changes, fmt.Sprintf("%s request raised to the %s min of LimitRange %s", resourceName, format(minValue), limitRangeName))
+			*request = minValue
+		}
+		if *limit != 0 && *limit < minValue {
+			changes = append(changes, fmt.Sprintf("%s limit raised to the %s min of LimitRange %s", resourceName, format(minValue), limitRangeName))
+			*limit = minValue
+		}
+	}
+	if q, ok := item.Max[resourceName]; ok {
+		maxValue := q.AsApproximateFloat64()
+		if *request > maxValue {
+			changes = append(changes, fmt.Sprintf("%s request lowered to the %s max of LimitRange %s", resourceName, format(maxValue), limitRangeName))
+			*request = maxValue
+		}
+		if *limit > maxValue {
+			changes = append(changes, fmt.Sprintf("%s limit lowered to the %s max of LimitRange %s", resourceName, format(maxValue), limitRangeName))
+			*limit = maxValue
+		}
+	}
+	if q, ok := item.MaxLimitRequestRatio[resourceName]; ok && *limit != 0 && *request > 0 {
+		ratio := q.AsApproximateFloat64()
+		if ratio > 0 && *limit / *request > ratio {
+			// raising the request keeps the headroom the limit was recommended for
+			*request = *limit / ratio
+			changes = append(changes, fmt.Sprintf("%s request raised to %s to keep the %.2f maxLimitRequestRatio of LimitRange %s", resourceName, format(*request), ratio, limitRangeName))
+		}
+	}
+	return changes
+}
+
+// AddLimitRangeProperty shows how the recommendation of the container was adjusted to the namespace LimitRanges, if it was.
+func AddLimitRangeProperty(properties *golang.Properties, note string) {
+	if note == "" {
+		return
+	}
+	properties.Properties = append(properties.Properties, &golang.Property{
+		Key:         "LimitRange",
+		Recommended: note,
+	})
+}
@@ -0,0 +1,130 @@
+package shared
+
+import (
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+const gib = 1024 * 1024 * 1024
+
+// recommendation is the part of golang2.RightsizingKubernetesContainer the LimitRanges apply to, the message itself can't be copied
+type recommendation struct {
+	CpuRequest, CpuLimit, MemoryRequest, MemoryLimit float64
+}
+
+func containerLimitRange(name string, item corev1.LimitRangeItem) corev1.LimitRange {
+	item.Type = corev1.LimitTypeContainer
+	return corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{item}},
+	}
+}
+
+func TestClampToLimitRanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		recommended recommendation
+		limitRanges []corev1.LimitRange
+		want        recommendation
+		wantNote    []string
+	}{
+		{
+			name:        "within the limits is left as is",
+			recommended: recommendation{CpuRequest: 0.5, CpuLimit: 1, MemoryRequest: gib, MemoryLimit: 2 * gib},
+			limitRanges: []corev1.LimitRange{containerLimitRange("bounds", corev1.LimitRangeItem{
+				Min: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("128Mi")},
+				Max: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("4Gi")},
+			})},
+			want: recommendation{CpuRequest: 0.5, CpuLimit: 1, MemoryRequest: gib, MemoryLimit: 2 * gib},
+		},
+		{
+			name:        "raised to min",
+			recommended: recommendation{CpuRequest: 0.05, CpuLimit: 0.08, MemoryRequest: 64 * 1024 * 1024, MemoryLimit: 0},
+			limitRanges: []corev1.LimitRange{containerLimitRange("floor", corev1.LimitRangeItem{
+				Min: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("128Mi")},
+			})},
+			want:     recommendation{CpuRequest: 0.1, CpuLimit: 0.1, MemoryRequest: 128 * 1024 * 1024, MemoryLimit: 0},
+			wantNote: []string{"cpu request raised", "cpu limit raised", "memory request raised", "LimitRange floor"},
+		},
+		{
+			name:        "lowered to max",
+			recommended: recommendation{CpuRequest: 3, CpuLimit: 4, MemoryRequest: gib, MemoryLimit: 8 * gib},
+			limitRanges: []corev1.LimitRange{containerLimitRange("ceiling", corev1.LimitRangeItem{
+				Max: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("4Gi")},
+			})},
+			want:     recommendation{CpuRequest: 2, CpuLimit: 2, MemoryRequest: gib, MemoryLimit: 4 * gib},
+			wantNote: []string{"cpu request lowered", "cpu limit lowered", "memory limit lowered", "LimitRange ceiling"},
+		},
+		{
+			name:        "empty limit with a default below the request",
+			recommended: recommendation{CpuRequest: 1.5, CpuLimit: 0, MemoryRequest: gib, MemoryLimit: 0},
+			limitRanges: []corev1.LimitRange{containerLimitRange("defaults", corev1.LimitRangeItem{
+				Default: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("2Gi")},
+			})},
+			want:     recommendation{CpuRequest: 1.5, CpuLimit: 1.5, MemoryRequest: gib, MemoryLimit: 0},
+			wantNote: []string{"cpu limit set to the request", "default limit of LimitRange defaults"},
+		},
+		{
+			name:        "default only applies to empty limits",
+			recommended: recommendation{CpuRequest: 1.5, CpuLimit: 3, MemoryRequest: gib, MemoryLimit: 2 * gib},
+			limitRanges: []corev1.LimitRange{containerLimitRange("defaults", corev1.LimitRangeItem{
+				Default: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("512Mi")},
+			})},
+			want: recommendation{CpuRequest: 1.5, CpuLimit: 3, MemoryRequest: gib, MemoryLimit: 2 * gib},
+		},
+		{
+			name:        "max limit request ratio raises the request",
+			recommended: recommendation{CpuRequest: 0.2, CpuLimit: 2, MemoryRequest: gib, MemoryLimit: gib},
+			limitRanges: []corev1.LimitRange{containerLimitRange("ratio", corev1.LimitRangeItem{
+				MaxLimitRequestRatio: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+			})},
+			want:     recommendation{CpuRequest: 0.5, CpuLimit: 2, MemoryRequest: gib, MemoryLimit: gib},
+			wantNote: []string{"cpu request raised to 0.50", "maxLimitRequestRatio"},
+		},
+		{
+			name:        "pod limits are ignored",
+			recommended: recommendation{CpuRequest: 3, CpuLimit: 4, MemoryRequest: gib, MemoryLimit: gib},
+			limitRanges: []corev1.LimitRange{{
+				ObjectMeta: metav1.ObjectMeta{Name: "pods", Namespace: "default"},
+				Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+					Type: corev1.LimitTypePod,
+					Max:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+				}}},
+			}},
+			want: recommendation{CpuRequest: 3, CpuLimit: 4, MemoryRequest: gib, MemoryLimit: gib},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container := &golang2.KubernetesContainerRightsizingRecommendation{
+				Name: "app",
+				Recommended: &golang2.RightsizingKubernetesContainer{
+					CpuRequest:    tt.recommended.CpuRequest,
+					CpuLimit:      tt.recommended.CpuLimit,
+					MemoryRequest: tt.recommended.MemoryRequest,
+					MemoryLimit:   tt.recommended.MemoryLimit,
+				},
+				Description: "rightsized",
+			}
+			notes := ClampToLimitRanges([]*golang2.KubernetesContainerRightsizingRecommendation{container, nil}, tt.limitRanges)
+
+			assert.InDelta(t, tt.want.CpuRequest, container.Recommended.CpuRequest, 1e-9)
+			assert.InDelta(t, tt.want.CpuLimit, container.Recommended.CpuLimit, 1e-9)
+			assert.InDelta(t, tt.want.MemoryRequest, container.Recommended.MemoryRequest, 1)
+			assert.InDelta(t, tt.want.MemoryLimit, container.Recommended.MemoryLimit, 1)
+			if len(tt.wantNote) == 0 {
+				assert.Empty(t, notes)
+				assert.Equal(t, "rightsized", container.Description)
+				return
+			}
+			for _, part := range tt.wantNote {
+				assert.Contains(t, notes["app"], part)
+			}
+			assert.Equal(t, "rightsized\n"+notes["app"], container.Description)
+		})
+	}
+}
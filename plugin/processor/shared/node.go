@@ -14,5 +14,13 @@ type KubernetesNode struct {
 	AllocatedPod int
 	Pods         []corev1.PodTemplateSpec
 
-	Cost *float64
+	Cost         *float64
+	CapacityType CapacityType
+}
+
+func (n KubernetesNode) capacityType() CapacityType {
+	if n.CapacityType == "" {
+		return CapacityTypeOnDemand
+	}
+	return n.CapacityType
 }
@@ -0,0 +1,204 @@
+package shared
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxPods is the kubelet default max pods, used for catalog instance types that don't set it.
+const DefaultMaxPods = 110
+
+// NoNodePool groups the nodes that have none of the NodePoolLabels. Pool labels are never empty, so it can't be mistaken
+// for a real pool such as the "default" Karpenter NodePool, and it is shown as noNodePoolName.
+const NoNodePool = ""
+
+const noNodePoolName = "(no pool)"
+
+// NodePoolLabels are the labels managed node groups and provisioners put on their nodes, read in this order.
+var NodePoolLabels = []string{
+	"eks.amazonaws.com/nodegroup",
+	"karpenter.sh/nodepool",
+	"cloud.google.com/gke-nodepool",
+	"kubernetes.azure.com/agentpool",
+}
+
+// NodePool returns the node group or node pool of the node, NoNodePool when it has none.
+func NodePool(labels map[string]string) string {
+	for _, label := range NodePoolLabels {
+		if v, ok := labels[label]; ok && v != "" {
+			return v
+		}
+	}
+	return NoNodePool
+}
+
+// NodePoolName is how a node pool is shown.
+func NodePoolName(pool string) string {
+	if pool == NoNodePool {
+		return noNodePoolName
+	}
+	return pool
+}
+
+// ParseNodePoolMinSizes parses the minimum sizes of the node pools, e.g. workers=3,gpu=1.
+func ParseNodePoolMinSizes(minSizes *string) (map[string]int, error) {
+	result := make(map[string]int)
+	if minSizes == nil || strings.TrimSpace(*minSizes) == "" {
+		return result, nil
+	}
+
+	for _, entry := range strings.Split(*minSizes, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pool, size, ok := strings.Cut(entry, "=")
+		minSize, err := strconv.Atoi(strings.TrimSpace(size))
+		if !ok || strings.TrimSpace(pool) == "" || err != nil || minSize < 0 {
+			return nil, fmt.Errorf("invalid node pool min size %s, expected <pool>=<size>", entry)
+		}
+		result[strings.TrimSpace(pool)] = minSize
+	}
+	return result, nil
+}
+
+// nodePoolRows breaks the cluster nodes and the removable ones down by node pool, it shows nothing when no node has a pool label.
+func nodePoolRows(cluster, removableNodes []KubernetesNode) []*golang.ResultSummaryTableRow {
+	pools := map[string][]KubernetesNode{}
+	for _, n := range cluster {
+		pool := NodePool(n.Labels)
+		pools[pool] = append(pools[pool], n)
+	}
+	if _, ok := pools[NoNodePool]; ok && len(pools) == 1 {
+		return nil
+	}
+	var poolNames []string
+	for pool := range pools {
+		poolNames = append(poolNames, pool)
+	}
+	sort.Strings(poolNames)
+
+	var rows []*golang.ResultSummaryTableRow
+	for _, pool := range poolNames {
+		poolNodes := pools[pool]
+		var removed []KubernetesNode
+		for _, n := range removableNodes {
+			if NodePool(n.Labels) == pool {
+				removed = append(removed, n)
+			}
+		}
+		rows = append(rows, &golang.ResultSummaryTableRow{
+			Cells: []string{
+				lipgloss.NewStyle().Foreground(lipgloss.Color("#dddddd")).Render(fmt.Sprintf("Node Pool %s (Nodes)", NodePoolName(pool))),
+				nodeListToString(poolNodes, false),
+				nodeListToString(diff(poolNodes, removed), false),
+				nodeListToString(removed, true),
+				fmt.Sprintf("%.2f%%", -float64(len(removed))/float64(len(poolNodes))*100.0),
+			},
+		})
+	}
+	return rows
+}
+
+// NodeShape is an instance type with its allocatable size, the machine size less the kubelet and system reservation, and
+// its monthly cost. Memory is in GB like KubernetesNode.
+type NodeShape struct {
+	InstanceType string
+	VCores       float64
+	Memory       float64
+	MaxPodCount  int64
+	Cost         float64
+}
+
+// NodePoolProposal is a cheaper mix of instance types for the pods of a node pool once they are rightsized. It is found by a
+// heuristic, not a search of every mix, so a cheaper one can exist.
+type NodePoolProposal struct {
+	Pool     string
+	Nodes    []KubernetesNode
+	Proposed []NodeShape
+}
+
+// CurrentCost is the cost of the nodes of the pool now, ok is false when one of them has no cost.
+func (p NodePoolProposal) CurrentCost() (float64, bool) {
+	total := 0.0
+	for _, n := range p.Nodes {
+		if n.Cost == nil {
+			return 0, false
+		}
+		total += *n.Cost
+	}
+	return total, true
+}
+
+func (p NodePoolProposal) ProposedCost() float64 {
+	total := 0.0
+	for _, s := range p.Proposed {
+		total += s.Cost
+	}
+	return total
+}
+
+// nodeMixString counts the instance types, e.g. "2 x m5.large, 1 x m5.xlarge".
+func nodeMixString(instanceTypes []string) string {
+	counts := map[string]int{}
+	for _, t := range instanceTypes {
+		if t == "" {
+			t = "unknown"
+		}
+		counts[t]++
+	}
+	var names []string
+	for t := range counts {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, t := range names {
+		parts = append(parts, fmt.Sprintf("%d x %s", counts[t], t))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// NodeShapeRows shows, for every node pool, its nodes now and the cheaper instance type mix suggested for it. Proposals are only
+// made when they cost less, so the change is a decrease.
+func NodeShapeRows(proposals []NodePoolProposal) []*golang.ResultSummaryTableRow {
+	var rows []*golang.ResultSummaryTableRow
+	for _, p := range proposals {
+		var current []string
+		for _, n := range p.Nodes {
+			current = append(current, NodeInstanceType(n.Labels))
+		}
+		var proposed []string
+		for _, s := range p.Proposed {
+			proposed = append(proposed, s.InstanceType)
+		}
+
+		proposedCost := p.ProposedCost()
+		currentCell := nodeMixString(current)
+		proposedCell := fmt.Sprintf("%s ($%.2f)", nodeMixString(proposed), proposedCost)
+		netImpact, percentage := "-", "-"
+		if currentCost, ok := p.CurrentCost(); ok {
+			currentCell = fmt.Sprintf("%s ($%.2f)", currentCell, currentCost)
+			netImpact = decreaseStyle.Render(fmt.Sprintf("-$%.2f", currentCost-proposedCost))
+			if currentCost > 0 {
+				percentage = SprintfWithStyle("%+.2f%%", (proposedCost-currentCost)/currentCost*100.0, false)
+			}
+		}
+
+		rows = append(rows, &golang.ResultSummaryTableRow{
+			Cells: []string{
+				lipgloss.NewStyle().Foreground(lipgloss.Color("#dddddd")).Render(fmt.Sprintf("Node Pool %s (Suggested Shape)", NodePoolName(p.Pool))),
+				currentCell,
+				proposedCell,
+				netImpact,
+				percentage,
+			},
+		})
+	}
+	return rows
+}
@@ -0,0 +1,51 @@
+package shared
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNodePool(t *testing.T) {
+	assert.Equal(t, "workers", NodePool(map[string]string{"eks.amazonaws.com/nodegroup": "workers", "karpenter.sh/nodepool": "default"}))
+	assert.Equal(t, "default", NodePool(map[string]string{"karpenter.sh/nodepool": "default"}))
+	assert.Equal(t, NoNodePool, NodePool(map[string]string{"karpenter.sh/nodepool": ""}))
+	assert.Equal(t, NoNodePool, NodePool(nil))
+
+	assert.Equal(t, "default", NodePoolName("default"))
+	assert.Equal(t, "(no pool)", NodePoolName(NoNodePool))
+}
+
+func TestNodePoolRows(t *testing.T) {
+	karpenter := map[string]string{"karpenter.sh/nodepool": "default"}
+
+	t.Run("Karpenter default pool is reported", func(t *testing.T) {
+		rows := nodePoolRows([]KubernetesNode{{Name: "a", Labels: karpenter}, {Name: "b", Labels: karpenter}}, []KubernetesNode{{Name: "b", Labels: karpenter}})
+		if assert.Len(t, rows, 1) {
+			assert.Contains(t, rows[0].Cells[0], "Node Pool default (Nodes)")
+		}
+	})
+
+	t.Run("Nodes without a pool are kept apart from the default pool", func(t *testing.T) {
+		rows := nodePoolRows([]KubernetesNode{{Name: "a", Labels: karpenter}, {Name: "b"}}, nil)
+		if assert.Len(t, rows, 2) {
+			assert.Contains(t, rows[0].Cells[0], "Node Pool (no pool) (Nodes)")
+			assert.Contains(t, rows[1].Cells[0], "Node Pool default (Nodes)")
+		}
+	})
+
+	t.Run("Nothing when no node has a pool", func(t *testing.T) {
+		assert.Empty(t, nodePoolRows([]KubernetesNode{{Name: "a"}, {Name: "b"}}, nil))
+	})
+}
+
+func TestParseNodePoolMinSizes(t *testing.T) {
+	str := func(s string) *string { return &s }
+	minSizes, err := ParseNodePoolMinSizes(str(" workers=3, default=1 "))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"workers": 3, "default": 1}, minSizes)
+
+	for _, invalid := range []string{"workers", "=3", "workers=-1", "workers=three"} {
+		_, err := ParseNodePoolMinSizes(str(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
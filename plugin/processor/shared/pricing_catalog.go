@@ -0,0 +1,252 @@
+package shared
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HoursPerMonth converts hourly prices to the monthly costs the nodes and workloads are shown with.
+const HoursPerMonth = 730
+
+// PricingCatalogEntry prices the nodes of an instance type, or the nodes matching a label selector, by the hour.
+// An instance type with its vcpu and memory is also a shape node pools can be moved to, the selector limits it to the pools it matches.
+type PricingCatalogEntry struct {
+	InstanceType string  `json:"instanceType,omitempty"`
+	Selector     string  `json:"selector,omitempty"`
+	HourlyPrice  float64 `json:"hourlyPrice"`
+	VCores       float64 `json:"vcpu,omitempty"`
+	MemoryGiB    float64 `json:"memoryGiB,omitempty"`
+	MaxPods      int64   `json:"maxPods,omitempty"`
+
+	selector labels.Selector
+}
+
+// PricingCatalog prices the nodes the cost service can't, e.g. on-prem or Hetzner nodes.
+type PricingCatalog struct {
+	Entries []PricingCatalogEntry
+}
+
+// LoadPricingCatalog reads a JSON list of entries, or a CSV with an instanceType,selector,hourlyPrice header and optional
+// vcpu,memoryGiB,maxPods columns when the file ends in .csv. It returns nil when no path is given.
+func LoadPricingCatalog(path *string) (*PricingCatalog, error) {
+	if path == nil || strings.TrimSpace(*path) == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(*path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing catalog: %v", err)
+	}
+
+	var entries []PricingCatalogEntry
+	if strings.EqualFold(filepath.Ext(*path), ".csv") {
+		entries, err = parsePricingCatalogCSV(string(content))
+	} else {
+		err = json.Unmarshal(content, &entries)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pricing catalog %s: %v", *path, err)
+	}
+
+	for i, entry := range entries {
+		if entry.InstanceType == "" && entry.Selector == "" {
+			return nil, fmt.Errorf("pricing catalog entry %d has neither an instanceType nor a selector", i+1)
+		}
+		if entry.HourlyPrice < 0 || entry.VCores < 0 || entry.MemoryGiB < 0 || entry.MaxPods < 0 {
+			return nil, fmt.Errorf("pricing catalog entry %d has a negative value", i+1)
+		}
+		if entry.Selector != "" {
+			entries[i].selector, err = labels.Parse(entry.Selector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector %s in pricing catalog: %v", entry.Selector, err)
+			}
+		}
+	}
+	return &PricingCatalog{Entries: entries}, nil
+}
+
+func parsePricingCatalogCSV(content string) ([]PricingCatalogEntry, error) {
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	priceColumn, ok := columns["hourlyprice"]
+	if !ok {
+		return nil, fmt.Errorf("missing hourlyPrice column")
+	}
+	get := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	getFloat := func(record []string, name string, line int) (float64, error) {
+		v := get(record, name)
+		if v == "" {
+			return 0, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s on line %d: %v", name, line, err)
+		}
+		return f, nil
+	}
+
+	var entries []PricingCatalogEntry
+	for i, record := range records[1:] {
+		line := i + 2
+		if priceColumn >= len(record) {
+			return nil, fmt.Errorf("line %d has no hourlyPrice", line)
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(record[priceColumn]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hourlyPrice on line %d: %v", line, err)
+		}
+		vcpu, err := getFloat(record, "vcpu", line)
+		if err != nil {
+			return nil, err
+		}
+		memory, err := getFloat(record, "memorygib", line)
+		if err != nil {
+			return nil, err
+		}
+		maxPods, err := getFloat(record, "maxpods", line)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, PricingCatalogEntry{
+			InstanceType: get(record, "instancetype"),
+			Selector:     get(record, "selector"),
+			HourlyPrice:  price,
+			VCores:       vcpu,
+			MemoryGiB:    memory,
+			MaxPods:      int64(maxPods),
+		})
+	}
+	return entries, nil
+}
+
+// NodeInstanceType returns the instance type label of the node, empty when it has none.
+func NodeInstanceType(labels map[string]string) string {
+	if v, ok := labels[corev1.LabelInstanceTypeStable]; ok {
+		return v
+	}
+	return labels[corev1.LabelInstanceType]
+}
+
+// NodeCost returns the monthly cost of the node. An entry for its instance type comes before the selectors, which are tried
+// in the order of the catalog.
+func (c *PricingCatalog) NodeCost(node corev1.Node) (float64, bool) {
+	if c == nil {
+		return 0, false
+	}
+	if instanceType := NodeInstanceType(node.Labels); instanceType != "" {
+		for _, entry := range c.Entries {
+			if entry.InstanceType == instanceType && entry.Selector == "" {
+				return entry.HourlyPrice * HoursPerMonth, true
+			}
+		}
+	}
+	for _, entry := range c.Entries {
+		if entry.selector == nil {
+			continue
+		}
+		if entry.InstanceType != "" && entry.InstanceType != NodeInstanceType(node.Labels) {
+			continue
+		}
+		if entry.selector.Matches(labels.Set(node.Labels)) {
+			return entry.HourlyPrice * HoursPerMonth, true
+		}
+	}
+	return 0, false
+}
+
+// NodeShapes returns the instance types of the catalog with a known size that a node pool with these labels can use,
+// cheapest first. The catalog has the size of the machine, the shapes have what is left of it for pods once the kubelet
+// and the system reserved their share.
+func (c *PricingCatalog) NodeShapes(poolLabels map[string]string) []NodeShape {
+	if c == nil {
+		return nil
+	}
+	var shapes []NodeShape
+	seen := map[string]bool{}
+	for _, entry := range c.Entries {
+		if entry.InstanceType == "" || entry.VCores <= 0 || entry.MemoryGiB <= 0 || seen[entry.InstanceType] {
+			continue
+		}
+		if entry.selector != nil && !entry.selector.Matches(labels.Set(poolLabels)) {
+			continue
+		}
+		seen[entry.InstanceType] = true
+
+		maxPods := entry.MaxPods
+		if maxPods == 0 {
+			maxPods = DefaultMaxPods
+		}
+		shapes = append(shapes, NodeShape{
+			InstanceType: entry.InstanceType,
+			VCores:       entry.VCores - reservedCPU(entry.VCores),
+			Memory:       entry.MemoryGiB - reservedMemoryGiB(entry.MemoryGiB),
+			MaxPodCount:  maxPods,
+			Cost:         entry.HourlyPrice * HoursPerMonth,
+		})
+	}
+	sort.SliceStable(shapes, func(i, j int) bool {
+		return shapes[i].Cost < shapes[j].Cost
+	})
+	return shapes
+}
+
+// evictionThresholdGiB is the default memory.available hard eviction threshold of the kubelet, 100Mi.
+const evictionThresholdGiB = 100.0 / 1024
+
+// reservedCPU is the kube-reserved CPU of a node with that many cores, 6% of the first core, 1% of the second, 0.5% of
+// the next two and 0.25% of the rest, as GKE and EKS reserve it.
+func reservedCPU(vcores float64) float64 {
+	return reservedShare(vcores, []reservationTier{{1, 0.06}, {1, 0.01}, {2, 0.005}, {math.Inf(1), 0.0025}})
+}
+
+// reservedMemoryGiB is the kube-reserved memory of a node with that much memory, 25% of the first 4GiB, 20% of the next 4GiB,
+// 10% of the next 8GiB, 6% of the next 112GiB and 2% of the rest as GKE reserves it, plus the eviction threshold.
+func reservedMemoryGiB(memoryGiB float64) float64 {
+	reserved := reservedShare(memoryGiB, []reservationTier{{4, 0.25}, {4, 0.2}, {8, 0.1}, {112, 0.06}, {math.Inf(1), 0.02}})
+	return min(memoryGiB, reserved+evictionThresholdGiB)
+}
+
+// reservationTier reserves share of the next size of a resource.
+type reservationTier struct {
+	size  float64
+	share float64
+}
+
+func reservedShare(total float64, tiers []reservationTier) float64 {
+	reserved := 0.0
+	for _, tier := range tiers {
+		if total <= 0 {
+			break
+		}
+		reserved += min(total, tier.size) * tier.share
+		total -= tier.size
+	}
+	return reserved
+}
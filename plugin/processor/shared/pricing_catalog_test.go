@@ -0,0 +1,246 @@
+package shared
+
+import (
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCatalog(t *testing.T, name, content string) *string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &path
+}
+
+func TestLoadPricingCatalog(t *testing.T) {
+	t.Run("no path", func(t *testing.T) {
+		empty := " "
+		for _, path := range []*string{nil, &empty} {
+			catalog, err := LoadPricingCatalog(path)
+			assert.NoError(t, err)
+			assert.Nil(t, catalog)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		catalog, err := LoadPricingCatalog(writeCatalog(t, "catalog.json", `[
+			{"instanceType": "cx41", "hourlyPrice": 0.025, "vcpu": 8, "memoryGiB": 16, "maxPods": 58},
+			{"selector": "node.kubernetes.io/pool=onprem", "hourlyPrice": 0.1}
+		]`))
+		assert.NoError(t, err)
+		if assert.NotNil(t, catalog) && assert.Len(t, catalog.Entries, 2) {
+			assert.Equal(t, "cx41", catalog.Entries[0].InstanceType)
+			assert.Equal(t, 0.025, catalog.Entries[0].HourlyPrice)
+			assert.Equal(t, 8.0, catalog.Entries[0].VCores)
+			assert.Equal(t, 16.0, catalog.Entries[0].MemoryGiB)
+			assert.Equal(t, int64(58), catalog.Entries[0].MaxPods)
+			assert.Nil(t, catalog.Entries[0].selector)
+			assert.NotNil(t, catalog.Entries[1].selector)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		catalog, err := LoadPricingCatalog(writeCatalog(t, "catalog.CSV", "instanceType,selector,hourlyPrice,vcpu,memoryGiB\n"+
+			"cx41,,0.025,8,16\n"+
+			",node.kubernetes.io/pool=onprem,0.1,,\n"))
+		assert.NoError(t, err)
+		if assert.NotNil(t, catalog) && assert.Len(t, catalog.Entries, 2) {
+			assert.Equal(t, PricingCatalogEntry{InstanceType: "cx41", HourlyPrice: 0.025, VCores: 8, MemoryGiB: 16}, catalog.Entries[0])
+			assert.Equal(t, "node.kubernetes.io/pool=onprem", catalog.Entries[1].Selector)
+			assert.NotNil(t, catalog.Entries[1].selector)
+		}
+	})
+
+	errorTests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "invalid json", file: "catalog.json", content: `{"instanceType": "cx41"}`},
+		{name: "entry without instance type or selector", file: "catalog.json", content: `[{"hourlyPrice": 0.1}]`},
+		{name: "negative price", file: "catalog.json", content: `[{"instanceType": "cx41", "hourlyPrice": -1}]`},
+		{name: "negative size", file: "catalog.json", content: `[{"instanceType": "cx41", "hourlyPrice": 1, "vcpu": -2}]`},
+		{name: "invalid selector", file: "catalog.json", content: `[{"selector": "pool in (", "hourlyPrice": 0.1}]`},
+		{name: "invalid csv", file: "catalog.csv", content: "instanceType,hourlyPrice\n\"cx41,0.025\n"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadPricingCatalog(writeCatalog(t, tt.file, tt.content))
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "missing.json")
+		_, err := LoadPricingCatalog(&path)
+		assert.Error(t, err)
+	})
+}
+
+func TestParsePricingCatalogCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []PricingCatalogEntry
+		wantErr string
+	}{
+		{
+			name:    "empty",
+			content: "",
+			want:    nil,
+		},
+		{
+			name:    "header only",
+			content: "instanceType,hourlyPrice\n",
+			want:    nil,
+		},
+		{
+			name:    "header is case insensitive and in any order",
+			content: " HourlyPrice , INSTANCETYPE, MaxPods\n0.5, cx41, 30\n",
+			want:    []PricingCatalogEntry{{InstanceType: "cx41", HourlyPrice: 0.5, MaxPods: 30}},
+		},
+		{
+			name:    "optional columns can be left out of a line",
+			content: "instanceType,hourlyPrice,vcpu,memoryGiB\ncx41,0.5\n",
+			want:    []PricingCatalogEntry{{InstanceType: "cx41", HourlyPrice: 0.5}},
+		},
+		{
+			name:    "missing hourly price column",
+			content: "instanceType,price\ncx41,0.5\n",
+			wantErr: "missing hourlyPrice column",
+		},
+		{
+			name:    "line without hourly price",
+			content: "instanceType,selector,hourlyPrice\ncx41\n",
+			wantErr: "line 2 has no hourlyPrice",
+		},
+		{
+			name:    "invalid hourly price",
+			content: "instanceType,hourlyPrice\ncx41,0.5\ncx51,cheap\n",
+			wantErr: "invalid hourlyPrice on line 3",
+		},
+		{
+			name:    "invalid vcpu",
+			content: "instanceType,hourlyPrice,vcpu\ncx41,0.5,eight\n",
+			wantErr: "invalid vcpu on line 2",
+		},
+		{
+			name:    "invalid memory",
+			content: "instanceType,hourlyPrice,memoryGiB\ncx41,0.5,16G\n",
+			wantErr: "invalid memorygib on line 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := parsePricingCatalogCSV(tt.content)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, entries)
+		})
+	}
+}
+
+func TestNodeCost(t *testing.T) {
+	catalog, err := LoadPricingCatalog(writeCatalog(t, "catalog.json", `[
+		{"instanceType": "cx31", "selector": "pool=onprem", "hourlyPrice": 0.2},
+		{"selector": "pool=onprem", "hourlyPrice": 0.3},
+		{"selector": "pool=onprem,rack=a", "hourlyPrice": 0.4},
+		{"instanceType": "cx41", "hourlyPrice": 0.1},
+		{"instanceType": "m5.large", "hourlyPrice": 0.05}
+	]`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	node := func(nodeLabels map[string]string) corev1.Node {
+		return corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", Labels: nodeLabels}}
+	}
+	tests := []struct {
+		name   string
+		node   corev1.Node
+		want   float64
+		wantOk bool
+	}{
+		{
+			name:   "instance type match wins over selector match",
+			node:   node(map[string]string{corev1.LabelInstanceTypeStable: "cx41", "pool": "onprem"}),
+			want:   0.1 * HoursPerMonth,
+			wantOk: true,
+		},
+		{
+			name:   "legacy instance type label",
+			node:   node(map[string]string{corev1.LabelInstanceType: "m5.large"}),
+			want:   0.05 * HoursPerMonth,
+			wantOk: true,
+		},
+		{
+			name:   "selector entry with an instance type",
+			node:   node(map[string]string{corev1.LabelInstanceTypeStable: "cx31", "pool": "onprem"}),
+			want:   0.2 * HoursPerMonth,
+			wantOk: true,
+		},
+		{
+			name:   "first matching selector in catalog order, skipping other instance types",
+			node:   node(map[string]string{corev1.LabelInstanceTypeStable: "cx51", "pool": "onprem", "rack": "a"}),
+			want:   0.3 * HoursPerMonth,
+			wantOk: true,
+		},
+		{
+			name:   "no match",
+			node:   node(map[string]string{corev1.LabelInstanceTypeStable: "cx51", "pool": "cloud"}),
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost, ok := catalog.NodeCost(tt.node)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.InDelta(t, tt.want, cost, 1e-9)
+		})
+	}
+
+	t.Run("nil catalog", func(t *testing.T) {
+		var nilCatalog *PricingCatalog
+		_, ok := nilCatalog.NodeCost(node(map[string]string{corev1.LabelInstanceTypeStable: "cx41"}))
+		assert.False(t, ok)
+	})
+}
+
+func TestNodeShapes(t *testing.T) {
+	catalog, err := LoadPricingCatalog(writeCatalog(t, "catalog.json", `[
+		{"instanceType": "large", "hourlyPrice": 0.2, "vcpu": 8, "memoryGiB": 32, "maxPods": 58},
+		{"instanceType": "small", "hourlyPrice": 0.05, "vcpu": 2, "memoryGiB": 4},
+		{"instanceType": "gpu", "selector": "pool=gpu", "hourlyPrice": 0.01, "vcpu": 4, "memoryGiB": 16},
+		{"instanceType": "unsized", "hourlyPrice": 0.01}
+	]`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	shapes := catalog.NodeShapes(map[string]string{"pool": "workers"})
+	if assert.Len(t, shapes, 2, "unsized entries and the ones of other pools are left out") {
+		assert.Equal(t, "small", shapes[0].InstanceType, "cheapest first")
+		assert.InDelta(t, 2-0.07, shapes[0].VCores, 1e-9)
+		assert.InDelta(t, 4-1-evictionThresholdGiB, shapes[0].Memory, 1e-9)
+		assert.Equal(t, int64(DefaultMaxPods), shapes[0].MaxPodCount)
+		assert.InDelta(t, 0.05*HoursPerMonth, shapes[0].Cost, 1e-9)
+
+		assert.Equal(t, "large", shapes[1].InstanceType)
+		assert.InDelta(t, 8-0.09, shapes[1].VCores, 1e-9)
+		assert.InDelta(t, 32-(1+0.8+0.8+16*0.06)-evictionThresholdGiB, shapes[1].Memory, 1e-9)
+		assert.Equal(t, int64(58), shapes[1].MaxPodCount)
+	}
+
+	shapes = catalog.NodeShapes(map[string]string{"pool": "gpu"})
+	if assert.Len(t, shapes, 3) {
+		assert.Equal(t, "gpu", shapes[0].InstanceType)
+	}
+}
@@ -0,0 +1,114 @@
+package shared
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	corev1 "k8s.io/api/core/v1"
+	"sort"
+)
+
+// quotaResources are the quota resources the recommendations change, with the summary change counted against each of them.
+var quotaResources = []struct {
+	name   corev1.ResourceName
+	memory bool
+	change func(s ResourceSummary) float64
+}{
+	{corev1.ResourceRequestsCPU, false, func(s ResourceSummary) float64 { return s.CPURequestUpSizing + s.CPURequestDownSizing }},
+	{corev1.ResourceCPU, false, func(s ResourceSummary) float64 { return s.CPURequestUpSizing + s.CPURequestDownSizing }},
+	{corev1.ResourceLimitsCPU, false, func(s ResourceSummary) float64 { return s.CPULimitUpSizing + s.CPULimitDownSizing }},
+	{corev1.ResourceRequestsMemory, true, func(s ResourceSummary) float64 { return s.MemoryRequestUpSizing + s.MemoryRequestDownSizing }},
+	{corev1.ResourceMemory, true, func(s ResourceSummary) float64 { return s.MemoryRequestUpSizing + s.MemoryRequestDownSizing }},
+	{corev1.ResourceLimitsMemory, true, func(s ResourceSummary) float64 { return s.MemoryLimitUpSizing + s.MemoryLimitDownSizing }},
+}
+
+// addNamespaceSummary adds the per replica item, or the per namespace breakdown of an aggregated summary, to byNamespace.
+func addNamespaceSummary(byNamespace map[string]ResourceSummary, item ResourceSummary) {
+	add := func(namespace string, s ResourceSummary, replicas float64) {
+		ns := byNamespace[namespace]
+		ns.Namespace = namespace
+		ns.ReplicaCount = 1
+		ns.CPURequestDownSizing += s.CPURequestDownSizing * replicas
+		ns.CPURequestUpSizing += s.CPURequestUpSizing * replicas
+		ns.TotalCPURequest += s.TotalCPURequest * replicas
+		ns.CPULimitDownSizing += s.CPULimitDownSizing * replicas
+		ns.CPULimitUpSizing += s.CPULimitUpSizing * replicas
+		ns.TotalCPULimit += s.TotalCPULimit * replicas
+		ns.MemoryRequestDownSizing += s.MemoryRequestDownSizing * replicas
+		ns.MemoryRequestUpSizing += s.MemoryRequestUpSizing * replicas
+		ns.TotalMemoryRequest += s.TotalMemoryRequest * replicas
+		ns.MemoryLimitDownSizing += s.MemoryLimitDownSizing * replicas
+		ns.MemoryLimitUpSizing += s.MemoryLimitUpSizing * replicas
+		ns.TotalMemoryLimit += s.TotalMemoryLimit * replicas
+		byNamespace[namespace] = ns
+	}
+
+	if item.ByNamespace != nil {
+		for namespace, s := range item.ByNamespace {
+			add(namespace, s, float64(s.ReplicaCount))
+		}
+	} else if item.Namespace != "" {
+		add(item.Namespace, item, float64(item.ReplicaCount))
+	}
+}
+
+// resourceQuotaRows shows, for every quota of a namespace with changes, how much of the quota is used now and after the changes.
+// Scoped quotas only count some of the pods of the namespace and are left out.
+func resourceQuotaRows(byNamespace map[string]ResourceSummary, quotas []corev1.ResourceQuota) []*golang.ResultSummaryTableRow {
+	quotas = append([]corev1.ResourceQuota(nil), quotas...)
+	sort.Slice(quotas, func(i, j int) bool {
+		if quotas[i].Namespace != quotas[j].Namespace {
+			return quotas[i].Namespace < quotas[j].Namespace
+		}
+		return quotas[i].Name < quotas[j].Name
+	})
+
+	var rows []*golang.ResultSummaryTableRow
+	for _, quota := range quotas {
+		summary, ok := byNamespace[quota.Namespace]
+		if !ok || len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+			continue
+		}
+		hardLimits := quota.Status.Hard
+		if len(hardLimits) == 0 {
+			hardLimits = quota.Spec.Hard
+		}
+
+		for _, r := range quotaResources {
+			hardQuantity, ok := hardLimits[r.name]
+			if !ok {
+				continue
+			}
+			hard := hardQuantity.AsApproximateFloat64()
+			used := 0.0
+			if q, ok := quota.Status.Used[r.name]; ok {
+				used = q.AsApproximateFloat64()
+			}
+			change := r.change(summary)
+
+			current := fmt.Sprintf("%.2f of %.2f Cores", used, hard)
+			recommended := fmt.Sprintf("%.2f of %.2f Cores", used+change, hard)
+			netImpact := SprintfWithStyle("%+.2f Cores", change, false)
+			if r.memory {
+				current = fmt.Sprintf("%s of %s", SizeByte64(used, false), SizeByte64(hard, false))
+				recommended = fmt.Sprintf("%s of %s", SizeByte64(used+change, false), SizeByte64(hard, false))
+				netImpact = SizeByte64WithStyle(change, true)
+			}
+			headroom := "-"
+			if hard > 0 {
+				headroom = SprintfWithStyle("%+.2f%%", change/hard*100.0, false)
+			}
+
+			rows = append(rows, &golang.ResultSummaryTableRow{
+				Cells: []string{
+					lipgloss.NewStyle().Foreground(lipgloss.Color("#dddddd")).Render(fmt.Sprintf("Quota %s/%s (%s)", quota.Namespace, quota.Name, r.name)),
+					current,
+					recommended,
+					netImpact,
+					headroom,
+				},
+			})
+		}
+	}
+	return rows
+}
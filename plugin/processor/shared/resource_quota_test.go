@@ -0,0 +1,61 @@
+package shared
+
+import (
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestResourceQuotaRows(t *testing.T) {
+	byNamespace := make(map[string]ResourceSummary)
+	addNamespaceSummary(byNamespace, ResourceSummary{
+		Namespace:             "default",
+		ReplicaCount:          2,
+		CPURequestDownSizing:  -0.5,
+		CPURequestUpSizing:    0.25,
+		MemoryLimitDownSizing: -gib,
+	})
+
+	quota := func(namespace, name string, hard, used corev1.ResourceList) corev1.ResourceQuota {
+		return corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       corev1.ResourceQuotaSpec{Hard: hard},
+			Status:     corev1.ResourceQuotaStatus{Hard: hard, Used: used},
+		}
+	}
+	scoped := quota("default", "best-effort", corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("10")}, nil)
+	scoped.Spec.Scopes = []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}
+
+	rows := resourceQuotaRows(byNamespace, []corev1.ResourceQuota{
+		quota("default", "compute",
+			corev1.ResourceList{
+				corev1.ResourceRequestsCPU:  resource.MustParse("10"),
+				corev1.ResourceLimitsMemory: resource.MustParse("20Gi"),
+			},
+			corev1.ResourceList{
+				corev1.ResourceRequestsCPU:  resource.MustParse("6"),
+				corev1.ResourceLimitsMemory: resource.MustParse("12Gi"),
+			}),
+		quota("other", "compute", corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("10")}, nil),
+		scoped,
+	})
+
+	if !assert.Len(t, rows, 2, "quotas of namespaces without changes and scoped quotas are left out") {
+		return
+	}
+
+	cpu := rows[0].Cells
+	assert.Contains(t, cpu[0], "Quota default/compute (requests.cpu)")
+	assert.Contains(t, cpu[1], "6.00 of 10.00 Cores")
+	assert.Contains(t, cpu[2], "5.50 of 10.00 Cores", "two replicas each giving back 0.25 cores")
+	assert.Contains(t, cpu[3], "-0.50 Cores")
+	assert.Contains(t, cpu[4], "-5.00%")
+
+	memory := rows[1].Cells
+	assert.Contains(t, memory[0], "Quota default/compute (limits.memory)")
+	assert.Contains(t, memory[1], "12.0 GB of 20.0 GB")
+	assert.Contains(t, memory[2], "10.0 GB of 20.0 GB")
+	assert.Contains(t, memory[4], "-10.00%")
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
 	"github.com/kaytu-io/kaytu/pkg/utils"
 	v1 "k8s.io/api/core/v1"
+	"sort"
 	"strings"
 )
 
@@ -27,6 +28,10 @@ type ResourceSummary struct {
 	MemoryLimitDownSizing float64
 	MemoryLimitUpSizing   float64
 	TotalMemoryLimit      float64
+
+	// Namespace is set on the summary of an item, ByNamespace on aggregated summaries
+	Namespace   string
+	ByNamespace map[string]ResourceSummary
 }
 
 func GetAggregatedResultsSummary(processorSummary *utils.ConcurrentMap[string, ResourceSummary]) (*golang.ResultSummary, *ResourceSummary) {
@@ -37,7 +42,9 @@ func GetAggregatedResultsSummary(processorSummary *utils.ConcurrentMap[string, R
 		memoryRequestDownSizing, memoryRequestUpSizing,
 		memoryLimitDownSizing, memoryLimitUpSizing float64
 	var totalCpuRequest, totalCpuLimit, totalMemoryRequest, totalMemoryLimit float64
+	byNamespace := make(map[string]ResourceSummary)
 	processorSummary.Range(func(key string, item ResourceSummary) bool {
+		addNamespaceSummary(byNamespace, item)
 		cpuRequestDownSizing += item.CPURequestDownSizing * float64(item.ReplicaCount)
 		cpuRequestUpSizing += item.CPURequestUpSizing * float64(item.ReplicaCount)
 		cpuLimitDownSizing += item.CPULimitDownSizing * float64(item.ReplicaCount)
@@ -68,19 +75,22 @@ func GetAggregatedResultsSummary(processorSummary *utils.ConcurrentMap[string, R
 		MemoryLimitUpSizing:     memoryLimitUpSizing,
 		MemoryLimitDownSizing:   memoryLimitDownSizing,
 		TotalMemoryLimit:        totalMemoryLimit,
+		ByNamespace:             byNamespace,
 	}
 	summary.Message = fmt.Sprintf("Overall changes: CPU request: %.2f of %.2f core, CPU limit: %.2f of %.2f core, Memory request: %s of %s, Memory limit: %s of %s", (cpuRequestUpSizing + cpuRequestDownSizing), totalCpuRequest, (cpuLimitUpSizing + cpuLimitDownSizing), totalCpuLimit, SizeByte64(memoryRequestUpSizing+memoryRequestDownSizing, false), SizeByte64(totalMemoryRequest, false), SizeByte64(memoryLimitUpSizing+memoryLimitDownSizing, false), SizeByte64(totalMemoryLimit, false))
 	return summary, &resourceSummary
 }
 
-func GetAggregatedResultsSummaryTable(processorSummary *utils.ConcurrentMap[string, ResourceSummary], cluster, removableNodes, removableNodesPrev []KubernetesNode) (*golang.ResultSummaryTable, *ResourceSummary) {
+func GetAggregatedResultsSummaryTable(processorSummary *utils.ConcurrentMap[string, ResourceSummary], cluster, removableNodes, removableNodesPrev []KubernetesNode, quotas []v1.ResourceQuota) (*golang.ResultSummaryTable, *ResourceSummary) {
 	summaryTable := &golang.ResultSummaryTable{}
 	var cpuRequestDownSizing, cpuRequestUpSizing,
 		cpuLimitDownSizing, cpuLimitUpSizing,
 		memoryRequestDownSizing, memoryRequestUpSizing,
 		memoryLimitDownSizing, memoryLimitUpSizing float64
 	var totalCpuRequest, totalCpuLimit, totalMemoryRequest, totalMemoryLimit float64
+	byNamespace := make(map[string]ResourceSummary)
 	processorSummary.Range(func(key string, item ResourceSummary) bool {
+		addNamespaceSummary(byNamespace, item)
 		cpuRequestUpSizing += item.CPURequestUpSizing * float64(item.ReplicaCount)
 		cpuRequestDownSizing += item.CPURequestDownSizing * float64(item.ReplicaCount)
 		cpuLimitUpSizing += item.CPULimitUpSizing * float64(item.ReplicaCount)
@@ -134,6 +144,7 @@ func GetAggregatedResultsSummaryTable(processorSummary *utils.ConcurrentMap[stri
 			SprintfWithStyle("%+.2f%%", (memoryLimitUpSizing+memoryLimitDownSizing)/totalMemoryLimit*100.0, false),
 		},
 	})
+	summaryTable.Message = append(summaryTable.Message, resourceQuotaRows(byNamespace, quotas)...)
 	var clusterCPU, clusterMemory, clusterCost, reducedCPU, reducedMemory, reducedCost float64
 	var hasCost = false
 	for _, c := range cluster {
@@ -170,6 +181,7 @@ func GetAggregatedResultsSummaryTable(processorSummary *utils.ConcurrentMap[stri
 					SprintfWithStyle("%.2f%%", -reducedCost/clusterCost*100.0, false),
 				},
 			})
+			summaryTable.Message = append(summaryTable.Message, capacityTypeCostRows(cluster, removableNodes)...)
 			summaryTable.Message = append(summaryTable.Message, &golang.ResultSummaryTableRow{
 				Cells: []string{
 					lipgloss.NewStyle().Foreground(lipgloss.Color("#dddddd")).Render("Cluster (Nodes)"),
@@ -179,11 +191,13 @@ func GetAggregatedResultsSummaryTable(processorSummary *utils.ConcurrentMap[stri
 					fmt.Sprintf("%.2f%%", -float64(len(removableNodes))/float64(len(cluster))*100.0),
 				},
 			})
+			summaryTable.Message = append(summaryTable.Message, nodePoolRows(cluster, removableNodes)...)
+			summaryTable.Message = append(summaryTable.Message, zoneCapacityRows(cluster, removableNodes)...)
 			for _, n := range removableNodesPrev {
 				summaryTable.Message = append(summaryTable.Message, &golang.ResultSummaryTableRow{
 					Cells: []string{
 						removableNodesPrevStyle.Render("Removable Nodes in the Current Configuration"),
-						removableNodesPrevStyle.Render(n.Name),
+						removableNodesPrevStyle.Render(fmt.Sprintf("%s (%s)", n.Name, NodePoolName(NodePool(n.Labels)))),
 						"",
 						"",
 						"",
@@ -195,7 +209,7 @@ func GetAggregatedResultsSummaryTable(processorSummary *utils.ConcurrentMap[stri
 					Cells: []string{
 						removableNodesStyle.Render("Removable Nodes after implementing Optimization"),
 						"",
-						removableNodesStyle.Render(n.Name),
+						removableNodesStyle.Render(fmt.Sprintf("%s (%s)", n.Name, NodePoolName(NodePool(n.Labels)))),
 						"",
 						"",
 					},
@@ -229,11 +243,13 @@ func GetAggregatedResultsSummaryTable(processorSummary *utils.ConcurrentMap[stri
 					fmt.Sprintf("%.2f%%", -float64(len(removableNodes))/float64(len(cluster))*100.0),
 				},
 			})
+			summaryTable.Message = append(summaryTable.Message, nodePoolRows(cluster, removableNodes)...)
+			summaryTable.Message = append(summaryTable.Message, zoneCapacityRows(cluster, removableNodes)...)
 			for _, n := range removableNodesPrev {
 				summaryTable.Message = append(summaryTable.Message, &golang.ResultSummaryTableRow{
 					Cells: []string{
 						removableNodesPrevStyle.Render("Removable Nodes in the Current Configuration"),
-						removableNodesPrevStyle.Render(n.Name),
+						removableNodesPrevStyle.Render(fmt.Sprintf("%s (%s)", n.Name, NodePoolName(NodePool(n.Labels)))),
 						"",
 						"",
 						"",
@@ -245,7 +261,7 @@ func GetAggregatedResultsSummaryTable(processorSummary *utils.ConcurrentMap[stri
 					Cells: []string{
 						removableNodesStyle.Render("Removable Nodes after implementing Optimization"),
 						"",
-						removableNodesStyle.Render(n.Name),
+						removableNodesStyle.Render(fmt.Sprintf("%s (%s)", n.Name, NodePoolName(NodePool(n.Labels)))),
 						"",
 						"",
 					},
@@ -270,6 +286,7 @@ func GetAggregatedResultsSummaryTable(processorSummary *utils.ConcurrentMap[stri
 		MemoryLimitUpSizing:     memoryLimitUpSizing,
 		MemoryLimitDownSizing:   memoryLimitDownSizing,
 		TotalMemoryLimit:        totalMemoryLimit,
+		ByNamespace:             byNamespace,
 	}
 
 	return summaryTable, &resourceSummary
@@ -312,3 +329,47 @@ func nodeListToString(nodes []KubernetesNode, removing bool) string {
 	}
 	return strings.Join(nodePool, " + ")
 }
+
+// GetCrossClusterSummaryTable puts the summary tables of several clusters under a cluster column, followed by the totals of all of them.
+func GetCrossClusterSummaryTable(clusterTables map[string]*golang.ResultSummaryTable, clusterSummaries *utils.ConcurrentMap[string, ResourceSummary]) *golang.ResultSummaryTable {
+	var clusters []string
+	for cluster := range clusterTables {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+
+	summaryTable := &golang.ResultSummaryTable{}
+	for _, cluster := range clusters {
+		table := clusterTables[cluster]
+		if table == nil {
+			continue
+		}
+		if summaryTable.Headers == nil {
+			summaryTable.Headers = append([]string{"Cluster"}, table.Headers...)
+		}
+		for i, row := range table.Message {
+			clusterCell := ""
+			if i == 0 {
+				clusterCell = lipgloss.NewStyle().Bold(true).Render(cluster)
+			}
+			summaryTable.Message = append(summaryTable.Message, &golang.ResultSummaryTableRow{
+				Cells: append([]string{clusterCell}, row.Cells...),
+			})
+		}
+	}
+
+	total, _ := GetAggregatedResultsSummaryTable(clusterSummaries, nil, nil, nil, nil)
+	if summaryTable.Headers == nil {
+		summaryTable.Headers = append([]string{"Cluster"}, total.Headers...)
+	}
+	for i, row := range total.Message {
+		clusterCell := ""
+		if i == 0 {
+			clusterCell = lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("All %d Clusters", len(clusters)))
+		}
+		summaryTable.Message = append(summaryTable.Message, &golang.ResultSummaryTableRow{
+			Cells: append([]string{clusterCell}, row.Cells...),
+		})
+	}
+	return summaryTable
+}
@@ -0,0 +1,72 @@
+package shared
+
+import (
+	"fmt"
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	"math"
+	"sort"
+)
+
+func CpuThrottlingAverageAndP95(dp []kaytuPrometheus.PromDatapoint) (float64, float64) {
+	var values []float64
+	for _, d := range dp {
+		if math.IsNaN(d.Value) || math.IsInf(d.Value, 0) {
+			continue
+		}
+		values = append(values, d.Value)
+	}
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	sort.Float64s(values)
+	idx := int(math.Ceil(0.95*float64(len(values)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+
+	return total / float64(len(values)), values[idx]
+}
+
+func MergePodContainerDatapoints(podMetrics map[string]map[string][]kaytuPrometheus.PromDatapoint) map[string][]kaytuPrometheus.PromDatapoint {
+	result := make(map[string][]kaytuPrometheus.PromDatapoint)
+	for _, containerMetrics := range podMetrics {
+		for containerName, dps := range containerMetrics {
+			result[containerName] = append(result[containerName], dps...)
+		}
+	}
+	return result
+}
+
+// KeepThrottledCpuLimits reverts CPU limit cuts for containers whose p95 throttling ratio is at or above HeavyCpuThrottlingRatio.
+func KeepThrottledCpuLimits(containers []*golang2.KubernetesContainerRightsizingRecommendation, cpuThrottling map[string][]kaytuPrometheus.PromDatapoint) {
+	for _, c := range containers {
+		if c == nil || c.Current == nil || c.Recommended == nil {
+			continue
+		}
+		if c.Current.CpuLimit == 0 || c.Recommended.CpuLimit == 0 || c.Recommended.CpuLimit >= c.Current.CpuLimit {
+			continue
+		}
+
+		_, p95 := CpuThrottlingAverageAndP95(cpuThrottling[c.Name])
+		if p95 < HeavyCpuThrottlingRatio {
+			continue
+		}
+
+		c.Recommended.CpuLimit = c.Current.CpuLimit
+		if c.Recommended.CpuRequest > c.Recommended.CpuLimit {
+			c.Recommended.CpuRequest = c.Recommended.CpuLimit
+		}
+		note := fmt.Sprintf("CPU limit kept at %.2f because the container is throttled in %.0f%% of CFS periods (p95).", c.Current.CpuLimit, p95*100)
+		if c.Description != "" {
+			c.Description = c.Description + "\n" + note
+		} else {
+			c.Description = note
+		}
+	}
+}
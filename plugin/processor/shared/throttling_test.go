@@ -0,0 +1,167 @@
+package shared
+
+import (
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func throttlingDatapoints(values ...float64) []kaytuPrometheus.PromDatapoint {
+	var dps []kaytuPrometheus.PromDatapoint
+	for _, v := range values {
+		dps = append(dps, kaytuPrometheus.PromDatapoint{Value: v})
+	}
+	return dps
+}
+
+func TestCpuThrottlingAverageAndP95(t *testing.T) {
+	tests := []struct {
+		name        string
+		datapoints  []kaytuPrometheus.PromDatapoint
+		wantAverage float64
+		wantP95     float64
+	}{
+		{name: "no datapoints"},
+		{name: "only NaN and Inf", datapoints: throttlingDatapoints(math.NaN(), math.Inf(1), math.Inf(-1))},
+		{name: "single datapoint", datapoints: throttlingDatapoints(0.5), wantAverage: 0.5, wantP95: 0.5},
+		{name: "NaN left out", datapoints: throttlingDatapoints(0.25, math.NaN(), 0.75), wantAverage: 0.5, wantP95: 0.75},
+		{
+			name:        "p95 of twenty",
+			datapoints:  throttlingDatapoints(0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0.5, 1),
+			wantAverage: 0.075,
+			wantP95:     0.5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			average, p95 := CpuThrottlingAverageAndP95(tt.datapoints)
+			assert.InDelta(t, tt.wantAverage, average, 1e-9)
+			assert.Equal(t, tt.wantP95, p95)
+		})
+	}
+}
+
+func TestMergePodContainerDatapoints(t *testing.T) {
+	merged := MergePodContainerDatapoints(map[string]map[string][]kaytuPrometheus.PromDatapoint{
+		"web-1": {"app": throttlingDatapoints(0.1), "sidecar": throttlingDatapoints(0.2)},
+		"web-2": {"app": throttlingDatapoints(0.3, math.NaN())},
+		"web-3": {},
+	})
+	assert.Len(t, merged, 2)
+	assert.Len(t, merged["app"], 3)
+	assert.Equal(t, throttlingDatapoints(0.2), merged["sidecar"])
+
+	assert.Empty(t, MergePodContainerDatapoints(nil))
+}
+
+func TestKeepThrottledCpuLimits(t *testing.T) {
+	tests := []struct {
+		name            string
+		current         recommendation
+		recommended     recommendation
+		description     string
+		throttling      []kaytuPrometheus.PromDatapoint
+		wantRecommended recommendation
+		wantNote        bool
+	}{
+		{
+			name:            "below the ratio",
+			current:         recommendation{CpuRequest: 1, CpuLimit: 2},
+			recommended:     recommendation{CpuRequest: 0.5, CpuLimit: 1},
+			throttling:      throttlingDatapoints(HeavyCpuThrottlingRatio - 0.01),
+			wantRecommended: recommendation{CpuRequest: 0.5, CpuLimit: 1},
+		},
+		{
+			name:            "at the ratio",
+			current:         recommendation{CpuRequest: 1, CpuLimit: 2},
+			recommended:     recommendation{CpuRequest: 0.5, CpuLimit: 1},
+			throttling:      throttlingDatapoints(HeavyCpuThrottlingRatio),
+			wantRecommended: recommendation{CpuRequest: 0.5, CpuLimit: 2},
+			wantNote:        true,
+		},
+		{
+			name:            "above the ratio",
+			current:         recommendation{CpuRequest: 1, CpuLimit: 2},
+			recommended:     recommendation{CpuRequest: 0.5, CpuLimit: 1},
+			throttling:      throttlingDatapoints(0.9, math.NaN()),
+			wantRecommended: recommendation{CpuRequest: 0.5, CpuLimit: 2},
+			wantNote:        true,
+		},
+		{
+			name:            "request capped at the kept limit",
+			current:         recommendation{CpuRequest: 1, CpuLimit: 2},
+			recommended:     recommendation{CpuRequest: 3, CpuLimit: 1},
+			throttling:      throttlingDatapoints(0.5),
+			wantRecommended: recommendation{CpuRequest: 2, CpuLimit: 2},
+			wantNote:        true,
+		},
+		{
+			name:            "note added to the description",
+			current:         recommendation{CpuRequest: 1, CpuLimit: 2},
+			recommended:     recommendation{CpuRequest: 0.5, CpuLimit: 1},
+			description:     "Memory limit raised.",
+			throttling:      throttlingDatapoints(0.5),
+			wantRecommended: recommendation{CpuRequest: 0.5, CpuLimit: 2},
+			wantNote:        true,
+		},
+		{
+			name:            "no datapoints",
+			current:         recommendation{CpuRequest: 1, CpuLimit: 2},
+			recommended:     recommendation{CpuRequest: 0.5, CpuLimit: 1},
+			wantRecommended: recommendation{CpuRequest: 0.5, CpuLimit: 1},
+		},
+		{
+			name:            "only NaN datapoints",
+			current:         recommendation{CpuRequest: 1, CpuLimit: 2},
+			recommended:     recommendation{CpuRequest: 0.5, CpuLimit: 1},
+			throttling:      throttlingDatapoints(math.NaN(), math.NaN()),
+			wantRecommended: recommendation{CpuRequest: 0.5, CpuLimit: 1},
+		},
+		{
+			name:            "limit raised",
+			current:         recommendation{CpuRequest: 1, CpuLimit: 2},
+			recommended:     recommendation{CpuRequest: 1, CpuLimit: 3},
+			throttling:      throttlingDatapoints(0.9),
+			wantRecommended: recommendation{CpuRequest: 1, CpuLimit: 3},
+		},
+		{
+			name:            "no limit set",
+			current:         recommendation{CpuRequest: 1},
+			recommended:     recommendation{CpuRequest: 0.5, CpuLimit: 1},
+			throttling:      throttlingDatapoints(0.9),
+			wantRecommended: recommendation{CpuRequest: 0.5, CpuLimit: 1},
+		},
+		{
+			name:            "limit removed",
+			current:         recommendation{CpuRequest: 1, CpuLimit: 2},
+			recommended:     recommendation{CpuRequest: 0.5},
+			throttling:      throttlingDatapoints(0.9),
+			wantRecommended: recommendation{CpuRequest: 0.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container := &golang2.KubernetesContainerRightsizingRecommendation{
+				Name:        "app",
+				Current:     &golang2.RightsizingKubernetesContainer{CpuRequest: tt.current.CpuRequest, CpuLimit: tt.current.CpuLimit},
+				Recommended: &golang2.RightsizingKubernetesContainer{CpuRequest: tt.recommended.CpuRequest, CpuLimit: tt.recommended.CpuLimit},
+				Description: tt.description,
+			}
+			KeepThrottledCpuLimits([]*golang2.KubernetesContainerRightsizingRecommendation{nil, {Name: "app"}, container},
+				map[string][]kaytuPrometheus.PromDatapoint{"app": tt.throttling})
+
+			assert.Equal(t, tt.wantRecommended.CpuRequest, container.Recommended.CpuRequest)
+			assert.Equal(t, tt.wantRecommended.CpuLimit, container.Recommended.CpuLimit)
+			if !tt.wantNote {
+				assert.Equal(t, tt.description, container.Description)
+				return
+			}
+			assert.Contains(t, container.Description, "CPU limit kept at 2.00")
+			if tt.description != "" {
+				assert.Contains(t, container.Description, tt.description+"\n")
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package shared
+
+import (
+	"fmt"
+	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	vpaUpdateModeAuto    = "Auto"
+	vpaUpdateModeInitial = "Initial"
+)
+
+// AddVerticalPodAutoscalerProperties shows the VPA target and upper bound of the container next to the recommendation.
+func AddVerticalPodAutoscalerProperties(properties *golang.Properties, vpa *kaytuKubernetes.VerticalPodAutoscaler, containerName string) {
+	recommendation := vpa.ContainerRecommendation(containerName)
+	if recommendation == nil {
+		return
+	}
+
+	properties.Properties = append(properties.Properties, &golang.Property{
+		Key:         "VPA CPU",
+		Recommended: fmt.Sprintf("target: %.2f", recommendation.Target.Cpu().AsApproximateFloat64()),
+		Max:         fmt.Sprintf("upper: %.2f", recommendation.UpperBound.Cpu().AsApproximateFloat64()),
+	})
+	properties.Properties = append(properties.Properties, &golang.Property{
+		Key:         "VPA Memory",
+		Recommended: "target: " + SizeByte(recommendation.Target.Memory().AsApproximateFloat64(), false),
+		Max:         "upper: " + SizeByte(recommendation.UpperBound.Memory().AsApproximateFloat64(), false),
+	})
+}
+
+// VerticalPodAutoscalerManifest builds a VPA that keeps every container of the target between its recommended request
+// and its recommended limit, or the observed max when no limit is recommended. The VPA already targeting the workload, if any,
+// keeps its name so applying the manifest updates it. It returns nil when there is no recommendation.
+// The VPA updates the pods in place unless hpa scales the workload on cpu or memory, the two would then fight over the
+// same pods, so it only sets the requests of the pods the HPA creates.
+func VerticalPodAutoscalerManifest(namespace string, targetRef autoscalingv1.CrossVersionObjectReference, existing *kaytuKubernetes.VerticalPodAutoscaler, hpa *autoscalingv2.HorizontalPodAutoscaler, containerResizing []*golang2.KubernetesContainerRightsizingRecommendation) *unstructured.Unstructured {
+	var containerPolicies []interface{}
+	for _, c := range containerResizing {
+		if c == nil || c.Recommended == nil {
+			continue
+		}
+		maxCpu, maxMemory := max(c.Recommended.CpuRequest, c.Recommended.CpuLimit), max(c.Recommended.MemoryRequest, c.Recommended.MemoryLimit)
+		if c.Recommended.CpuLimit == 0 && c.CpuMax != nil {
+			maxCpu = max(maxCpu, c.CpuMax.Value)
+		}
+		if c.Recommended.MemoryLimit == 0 && c.MemoryMax != nil {
+			maxMemory = max(maxMemory, c.MemoryMax.Value)
+		}
+
+		containerPolicies = append(containerPolicies, map[string]interface{}{
+			"containerName":       c.Name,
+			"controlledResources": []interface{}{string(corev1.ResourceCPU), string(corev1.ResourceMemory)},
+			"minAllowed": map[string]interface{}{
+				string(corev1.ResourceCPU):    resource.NewMilliQuantity(int64(c.Recommended.CpuRequest*1000), resource.DecimalSI).String(),
+				string(corev1.ResourceMemory): resource.NewQuantity(int64(c.Recommended.MemoryRequest), resource.BinarySI).String(),
+			},
+			"maxAllowed": map[string]interface{}{
+				string(corev1.ResourceCPU):    resource.NewMilliQuantity(int64(maxCpu*1000), resource.DecimalSI).String(),
+				string(corev1.ResourceMemory): resource.NewQuantity(int64(maxMemory), resource.BinarySI).String(),
+			},
+		})
+	}
+	if len(containerPolicies) == 0 {
+		return nil
+	}
+
+	updateMode := vpaUpdateModeAuto
+	if ScalesOnResources(hpa) {
+		updateMode = vpaUpdateModeInitial
+	}
+
+	name := fmt.Sprintf("%s-vpa", targetRef.Name)
+	if existing != nil {
+		name = existing.Name
+	}
+	vpa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"targetRef": map[string]interface{}{
+				"apiVersion": targetRef.APIVersion,
+				"kind":       targetRef.Kind,
+				"name":       targetRef.Name,
+			},
+			"updatePolicy": map[string]interface{}{
+				"updateMode": updateMode,
+			},
+			"resourcePolicy": map[string]interface{}{
+				"containerPolicies": containerPolicies,
+			},
+		},
+	}}
+	vpa.SetAPIVersion(kaytuKubernetes.VerticalPodAutoscalerGroupVersion.String())
+	vpa.SetKind("VerticalPodAutoscaler")
+	vpa.SetNamespace(namespace)
+	vpa.SetName(name)
+	return vpa
+}
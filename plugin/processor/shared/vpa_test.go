@@ -0,0 +1,128 @@
+package shared
+
+import (
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"testing"
+)
+
+func resourceHPA(name corev1.ResourceName) *autoscalingv2.HorizontalPodAutoscaler {
+	return &autoscalingv2.HorizontalPodAutoscaler{Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+		Metrics: []autoscalingv2.MetricSpec{{
+			Type:     autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{Name: name},
+		}},
+	}}
+}
+
+func TestVerticalPodAutoscalerManifest(t *testing.T) {
+	targetRef := autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}
+	container := func(name string, recommended recommendation, cpuMax, memoryMax float64) *golang2.KubernetesContainerRightsizingRecommendation {
+		return &golang2.KubernetesContainerRightsizingRecommendation{
+			Name: name,
+			Recommended: &golang2.RightsizingKubernetesContainer{
+				CpuRequest:    recommended.CpuRequest,
+				CpuLimit:      recommended.CpuLimit,
+				MemoryRequest: recommended.MemoryRequest,
+				MemoryLimit:   recommended.MemoryLimit,
+			},
+			CpuMax:    wrapperspb.Double(cpuMax),
+			MemoryMax: wrapperspb.Double(memoryMax),
+		}
+	}
+	existing := &kaytuKubernetes.VerticalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "web-autoscaler"}}
+	externalHPA := &autoscalingv2.HorizontalPodAutoscaler{Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+		Metrics: []autoscalingv2.MetricSpec{{Type: autoscalingv2.ExternalMetricSourceType}},
+	}}
+
+	tests := []struct {
+		name           string
+		existing       *kaytuKubernetes.VerticalPodAutoscaler
+		hpa            *autoscalingv2.HorizontalPodAutoscaler
+		containers     []*golang2.KubernetesContainerRightsizingRecommendation
+		wantName       string
+		wantUpdateMode string
+		wantMin        map[string]interface{}
+		wantMax        map[string]interface{}
+	}{
+		{
+			name:           "bounded by the recommended request and limit",
+			containers:     []*golang2.KubernetesContainerRightsizingRecommendation{container("app", recommendation{CpuRequest: 0.25, CpuLimit: 1, MemoryRequest: 256 * 1024 * 1024, MemoryLimit: gib}, 2, 2*gib)},
+			wantName:       "web-vpa",
+			wantUpdateMode: "Auto",
+			wantMin:        map[string]interface{}{"cpu": "250m", "memory": "256Mi"},
+			wantMax:        map[string]interface{}{"cpu": "1", "memory": "1Gi"},
+		},
+		{
+			name:           "observed max without a recommended limit",
+			containers:     []*golang2.KubernetesContainerRightsizingRecommendation{container("app", recommendation{CpuRequest: 0.25, MemoryRequest: 256 * 1024 * 1024}, 0.5, 512*1024*1024)},
+			wantName:       "web-vpa",
+			wantUpdateMode: "Auto",
+			wantMin:        map[string]interface{}{"cpu": "250m", "memory": "256Mi"},
+			wantMax:        map[string]interface{}{"cpu": "500m", "memory": "512Mi"},
+		},
+		{
+			name:           "existing VPA keeps its name",
+			existing:       existing,
+			containers:     []*golang2.KubernetesContainerRightsizingRecommendation{container("app", recommendation{CpuRequest: 0.25, CpuLimit: 1, MemoryRequest: gib, MemoryLimit: gib}, 1, gib)},
+			wantName:       "web-autoscaler",
+			wantUpdateMode: "Auto",
+			wantMin:        map[string]interface{}{"cpu": "250m", "memory": "1Gi"},
+			wantMax:        map[string]interface{}{"cpu": "1", "memory": "1Gi"},
+		},
+		{
+			name:           "HPA on cpu only sets the requests of new pods",
+			hpa:            resourceHPA(corev1.ResourceCPU),
+			containers:     []*golang2.KubernetesContainerRightsizingRecommendation{container("app", recommendation{CpuRequest: 0.25, CpuLimit: 1, MemoryRequest: gib, MemoryLimit: gib}, 1, gib)},
+			wantName:       "web-vpa",
+			wantUpdateMode: "Initial",
+			wantMin:        map[string]interface{}{"cpu": "250m", "memory": "1Gi"},
+			wantMax:        map[string]interface{}{"cpu": "1", "memory": "1Gi"},
+		},
+		{
+			name:           "HPA on external metrics",
+			hpa:            externalHPA,
+			containers:     []*golang2.KubernetesContainerRightsizingRecommendation{container("app", recommendation{CpuRequest: 0.25, CpuLimit: 1, MemoryRequest: gib, MemoryLimit: gib}, 1, gib)},
+			wantName:       "web-vpa",
+			wantUpdateMode: "Auto",
+			wantMin:        map[string]interface{}{"cpu": "250m", "memory": "1Gi"},
+			wantMax:        map[string]interface{}{"cpu": "1", "memory": "1Gi"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vpa := VerticalPodAutoscalerManifest("default", targetRef, tt.existing, tt.hpa, tt.containers)
+			if !assert.NotNil(t, vpa) {
+				return
+			}
+			assert.Equal(t, "VerticalPodAutoscaler", vpa.GetKind())
+			assert.Equal(t, "autoscaling.k8s.io/v1", vpa.GetAPIVersion())
+			assert.Equal(t, "default", vpa.GetNamespace())
+			assert.Equal(t, tt.wantName, vpa.GetName())
+
+			updateMode, _, _ := unstructured.NestedString(vpa.Object, "spec", "updatePolicy", "updateMode")
+			assert.Equal(t, tt.wantUpdateMode, updateMode)
+			targetName, _, _ := unstructured.NestedString(vpa.Object, "spec", "targetRef", "name")
+			assert.Equal(t, "web", targetName)
+
+			policies, _, _ := unstructured.NestedSlice(vpa.Object, "spec", "resourcePolicy", "containerPolicies")
+			if assert.Len(t, policies, 1) {
+				policy := policies[0].(map[string]interface{})
+				assert.Equal(t, "app", policy["containerName"])
+				assert.Equal(t, tt.wantMin, policy["minAllowed"])
+				assert.Equal(t, tt.wantMax, policy["maxAllowed"])
+			}
+		})
+	}
+
+	t.Run("nil without a recommendation", func(t *testing.T) {
+		assert.Nil(t, VerticalPodAutoscalerManifest("default", targetRef, nil, nil, []*golang2.KubernetesContainerRightsizingRecommendation{nil, {Name: "app"}}))
+	})
+}
@@ -0,0 +1,105 @@
+package shared
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	corev1 "k8s.io/api/core/v1"
+	"sort"
+)
+
+// volumeZoneKeys are the node affinity keys zonal volumes are pinned with, the CSI drivers of EKS, GKE and AKS use their own.
+var volumeZoneKeys = []string{
+	corev1.LabelTopologyZone,
+	corev1.LabelFailureDomainBetaZone,
+	"topology.ebs.csi.aws.com/zone",
+	"topology.gke.io/zone",
+	"topology.disk.csi.azure.com/zone",
+}
+
+// NodeZone returns the availability zone of the node, empty when it has none.
+func NodeZone(labels map[string]string) string {
+	return labels[NodeZoneKey(labels)]
+}
+
+// NodeZoneKey returns the label the node carries its zone in, the legacy failure-domain one on older nodes, empty when it has none.
+func NodeZoneKey(labels map[string]string) string {
+	if _, ok := labels[corev1.LabelTopologyZone]; ok {
+		return corev1.LabelTopologyZone
+	}
+	if _, ok := labels[corev1.LabelFailureDomainBetaZone]; ok {
+		return corev1.LabelFailureDomainBetaZone
+	}
+	return ""
+}
+
+// VolumeZone returns the zone a persistent volume is pinned to, from its node affinity or its zone label, empty when it is not zonal.
+func VolumeZone(pv corev1.PersistentVolume) string {
+	if pv.Spec.NodeAffinity != nil && pv.Spec.NodeAffinity.Required != nil {
+		for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+			for _, req := range term.MatchExpressions {
+				if req.Operator != corev1.NodeSelectorOpIn || len(req.Values) != 1 {
+					continue
+				}
+				for _, key := range volumeZoneKeys {
+					if req.Key == key {
+						return req.Values[0]
+					}
+				}
+			}
+		}
+	}
+	return NodeZone(pv.Labels)
+}
+
+// zoneCapacityRows shows the capacity of every zone now and once the removable nodes are gone, it shows nothing when no node has a zone.
+func zoneCapacityRows(cluster, removableNodes []KubernetesNode) []*golang.ResultSummaryTableRow {
+	type capacity struct {
+		cpu, memory               float64
+		removedCPU, removedMemory float64
+	}
+	zones := map[string]*capacity{}
+	removed := map[string]bool{}
+	for _, n := range removableNodes {
+		removed[n.Name] = true
+	}
+	for _, n := range cluster {
+		zone := NodeZone(n.Labels)
+		if zone == "" {
+			continue
+		}
+		if zones[zone] == nil {
+			zones[zone] = &capacity{}
+		}
+		zones[zone].cpu += n.VCores
+		zones[zone].memory += n.Memory * 1024 * 1024 * 1024
+		if removed[n.Name] {
+			zones[zone].removedCPU += n.VCores
+			zones[zone].removedMemory += n.Memory * 1024 * 1024 * 1024
+		}
+	}
+	var zoneNames []string
+	for zone := range zones {
+		zoneNames = append(zoneNames, zone)
+	}
+	sort.Strings(zoneNames)
+
+	var rows []*golang.ResultSummaryTableRow
+	for _, zone := range zoneNames {
+		c := zones[zone]
+		percentage := "-"
+		if c.cpu > 0 {
+			percentage = SprintfWithStyle("%+.2f%%", -c.removedCPU/c.cpu*100.0, false)
+		}
+		rows = append(rows, &golang.ResultSummaryTableRow{
+			Cells: []string{
+				lipgloss.NewStyle().Foreground(lipgloss.Color("#dddddd")).Render(fmt.Sprintf("Zone %s (Capacity)", zone)),
+				fmt.Sprintf("%.2f Cores, %s", c.cpu, SizeByte64(c.memory, false)),
+				fmt.Sprintf("%.2f Cores, %s", c.cpu-c.removedCPU, SizeByte64(c.memory-c.removedMemory, false)),
+				fmt.Sprintf("%s, %s", SprintfWithStyle("%+.2f Cores", -c.removedCPU, false), SizeByte64WithStyle(-c.removedMemory, true)),
+				percentage,
+			},
+		})
+	}
+	return rows
+}
@@ -0,0 +1,34 @@
+package shared
+
+import (
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"testing"
+)
+
+func TestNodeZone(t *testing.T) {
+	assert.Equal(t, "a", NodeZone(map[string]string{corev1.LabelTopologyZone: "a", corev1.LabelFailureDomainBetaZone: "b"}))
+	assert.Equal(t, "b", NodeZone(map[string]string{corev1.LabelFailureDomainBetaZone: "b"}))
+	assert.Equal(t, corev1.LabelFailureDomainBetaZone, NodeZoneKey(map[string]string{corev1.LabelFailureDomainBetaZone: "b"}))
+	assert.Equal(t, "", NodeZone(nil))
+	assert.Equal(t, "", NodeZoneKey(nil))
+}
+
+func TestZoneCapacityRows(t *testing.T) {
+	zone := func(name string) map[string]string {
+		return map[string]string{corev1.LabelTopologyZone: name}
+	}
+	rows := zoneCapacityRows([]KubernetesNode{
+		{Name: "a-1", VCores: 4, Memory: 8, Labels: zone("a")},
+		{Name: "a-2", VCores: 4, Memory: 8, Labels: zone("a")},
+		{Name: "b-1", Labels: zone("b")},
+		{Name: "no-zone", VCores: 4, Memory: 8},
+	}, []KubernetesNode{{Name: "a-2"}})
+
+	if assert.Len(t, rows, 2) {
+		assert.Contains(t, rows[0].Cells[0], "Zone a")
+		assert.Contains(t, rows[0].Cells[4], "-50.00%")
+		assert.Contains(t, rows[1].Cells[0], "Zone b")
+		assert.Equal(t, "-", rows[1].Cells[4], "a zone without capacity has no percentage")
+	}
+}
@@ -0,0 +1,275 @@
+package simulation
+
+import (
+	"fmt"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	appv1 "k8s.io/api/apps/v1"
+	"math"
+	"sort"
+	"strings"
+)
+
+// podRequests is what a pod, or everything that runs on every node of a pool, takes from a node.
+type podRequests struct {
+	cpu    float64
+	memory float64
+	pods   int
+}
+
+func (r podRequests) add(other podRequests) podRequests {
+	return podRequests{cpu: r.cpu + other.cpu, memory: r.memory + other.memory, pods: r.pods + other.pods}
+}
+
+func (r podRequests) fits(capacity podRequests) bool {
+	return r.cpu <= capacity.cpu && r.memory <= capacity.memory && r.pods <= capacity.pods
+}
+
+// weight ranks pods by size the way the scheduler does, a core counts as 4GB.
+func (r podRequests) weight() float64 {
+	return r.cpu*4 + r.memory
+}
+
+// ProposeNodeShapes places the pods the way Simulate does and packs the pods of every node pool on a cheap mix of the
+// instance types of the catalog the pool can use, see proposeNodeMix. Pods keep their pool, DaemonSets and the pods bound to
+// the nodes are counted on every new node and pools keep their minimum size. Pool proposals that don't cost less than the pool
+// does now are left out, and so are the pools a pod that found no node could run on, their proposal would miss that pod.
+func (s *SchedulerService) ProposeNodeShapes(catalog *shared.PricingCatalog) ([]shared.NodePoolProposal, error) {
+	if catalog == nil || len(s.nodes) == 0 {
+		return nil, nil
+	}
+	var nodes []shared.KubernetesNode
+	for _, n := range s.nodes {
+		nodes = append(nodes, n)
+	}
+
+	scheduler, failures := s.schedule(nodes, false)
+
+	pools := map[string][]shared.KubernetesNode{}
+	var poolNames []string
+	for _, n := range scheduler.nodes {
+		pool := shared.NodePool(n.Labels)
+		if _, ok := pools[pool]; !ok {
+			poolNames = append(poolNames, pool)
+		}
+		pools[pool] = append(pools[pool], n)
+	}
+	sort.Strings(poolNames)
+
+	var proposals []shared.NodePoolProposal
+	for _, pool := range poolNames {
+		poolNodes := pools[pool]
+		shapes := catalog.NodeShapes(poolNodes[0].Labels)
+		if len(shapes) == 0 {
+			continue
+		}
+		if missing := missingPods(scheduler, failures, poolNodes[0]); len(missing) > 0 {
+			fmt.Println("no node shapes proposed for node pool", shared.NodePoolName(pool), "due to pods without a node:", strings.Join(missing, ", "))
+			continue
+		}
+
+		var pods []podRequests
+		var overhead podRequests
+		for _, n := range poolNodes {
+			nodeOverhead := s.daemonSetRequests(scheduler, n)
+			for _, pod := range n.Pods {
+				cpu, memory := getPodResourceRequests(pod.Spec)
+				if nodeBoundPod(pod) {
+					nodeOverhead = nodeOverhead.add(podRequests{cpu: cpu, memory: memory, pods: 1})
+					continue
+				}
+				pods = append(pods, podRequests{cpu: cpu, memory: memory, pods: 1})
+			}
+			overhead = podRequests{
+				cpu:    max(overhead.cpu, nodeOverhead.cpu),
+				memory: max(overhead.memory, nodeOverhead.memory),
+				pods:   max(overhead.pods, nodeOverhead.pods),
+			}
+		}
+		if len(pods) == 0 {
+			continue
+		}
+
+		proposed, ok := proposeNodeMix(pods, shapes, overhead)
+		if !ok {
+			continue
+		}
+		for len(proposed) < s.nodePoolMinSizes[pool] {
+			proposed = append(proposed, shapes[0])
+		}
+		for i := range poolNodes {
+			poolNodes[i].Pods = nil
+		}
+		proposal := shared.NodePoolProposal{
+			Pool:     pool,
+			Nodes:    poolNodes,
+			Proposed: proposed,
+		}
+		if cost, ok := proposal.CurrentCost(); ok && proposal.ProposedCost() >= cost {
+			continue
+		}
+		proposals = append(proposals, proposal)
+	}
+	return proposals, nil
+}
+
+// missingPods names the placement failures whose pods could run on the node given room for them.
+func missingPods(scheduler *Scheduler, failures []placementFailure, node shared.KubernetesNode) []string {
+	var missing []string
+	for _, f := range failures {
+		if !scheduler.tolerates(f.spec, node.Taints) {
+			continue
+		}
+		if f.spec.Affinity != nil && f.spec.Affinity.NodeAffinity != nil && !scheduler.satisfiesNodeAffinity(f.spec.Affinity.NodeAffinity, node.Labels) {
+			continue
+		}
+		selected := true
+		for key, value := range f.spec.NodeSelector {
+			if nodeValue, ok := node.Labels[key]; !ok || nodeValue != value {
+				selected = false
+				break
+			}
+		}
+		if selected {
+			missing = append(missing, f.name)
+		}
+	}
+	return missing
+}
+
+// daemonSetRequests sums the requests of the DaemonSets that run on the node.
+func (s *SchedulerService) daemonSetRequests(scheduler *Scheduler, node shared.KubernetesNode) podRequests {
+	empty := node
+	empty.AllocatedCPU, empty.AllocatedMem, empty.AllocatedPod, empty.Pods = 0, 0, 0, nil
+
+	var requests podRequests
+	s.daemonSets.Range(func(_ string, ds appv1.DaemonSet) bool {
+		if ok, _ := scheduler.canScheduleOnNode(ds.Spec.Template.Spec, &empty); ok {
+			cpu, memory := getPodResourceRequests(ds.Spec.Template.Spec)
+			requests = requests.add(podRequests{cpu: cpu, memory: memory, pods: 1})
+		}
+		return true
+	})
+	return requests
+}
+
+// proposeNodeMix looks for a cheap mix of shapes to run the pods on. It packs the pods first fit decreasing on each shape
+// alone, and once more opening every node on the shape that costs the least for the pods it takes, and keeps the cheapest
+// of these packings. It is a heuristic, a cheaper mix can exist. ok is false when no shape fits the largest pod.
+func proposeNodeMix(pods []podRequests, shapes []shared.NodeShape, overhead podRequests) ([]shared.NodeShape, bool) {
+	pods = append([]podRequests(nil), pods...)
+	sort.SliceStable(pods, func(i, j int) bool {
+		return pods[i].weight() > pods[j].weight()
+	})
+
+	var best []shared.NodeShape
+	bestCost := math.Inf(1)
+	consider := func(mix []shared.NodeShape) {
+		cost := 0.0
+		for _, shape := range mix {
+			cost += shape.Cost
+		}
+		if cost < bestCost {
+			bestCost = cost
+			best = mix
+		}
+	}
+
+	for _, shape := range shapes {
+		bins, ok := packPods(pods, shape, overhead)
+		if !ok {
+			continue
+		}
+		mix := make([]shared.NodeShape, len(bins))
+		for i := range mix {
+			mix[i] = shape
+		}
+		consider(mix)
+	}
+	if best == nil {
+		return nil, false
+	}
+	if mix, ok := greedyNodeMix(pods, shapes, overhead); ok {
+		consider(mix)
+	}
+	return best, true
+}
+
+// greedyNodeMix opens one node at a time on the shape with the lowest cost for the weight of the pods it takes, the pods are
+// placed first fit in the order given. ok is false when a pod fits on no shape.
+func greedyNodeMix(pods []podRequests, shapes []shared.NodeShape, overhead podRequests) ([]shared.NodeShape, bool) {
+	var mix []shared.NodeShape
+	for len(pods) > 0 {
+		var bestShape shared.NodeShape
+		var bestLeft []podRequests
+		bestRatio := math.Inf(1)
+		for _, shape := range shapes {
+			capacity := shapeCapacity(shape, overhead)
+			var used podRequests
+			var left []podRequests
+			load, placed := 0.0, 0
+			for _, pod := range pods {
+				if !used.add(pod).fits(capacity) {
+					left = append(left, pod)
+					continue
+				}
+				used = used.add(pod)
+				load += pod.weight()
+				placed++
+			}
+			if placed == 0 {
+				continue
+			}
+			if load == 0 {
+				load = float64(placed)
+			}
+			if ratio := shape.Cost / load; ratio < bestRatio {
+				bestRatio = ratio
+				bestShape = shape
+				bestLeft = left
+			}
+		}
+		if math.IsInf(bestRatio, 1) {
+			return nil, false
+		}
+		mix = append(mix, bestShape)
+		pods = bestLeft
+	}
+	return mix, true
+}
+
+// shapeCapacity is what a node of the shape has for the pods, with the same headroom the scheduler keeps.
+func shapeCapacity(shape shared.NodeShape, overhead podRequests) podRequests {
+	return podRequests{
+		cpu:    shape.VCores*CPUHeadroomFactor - overhead.cpu,
+		memory: shape.Memory*MemoryHeadroomFactor - overhead.memory,
+		pods:   int(float64(shape.MaxPodCount)*PodHeadroomFactor) - overhead.pods,
+	}
+}
+
+// packPods puts every pod on the first node of the shape it fits on, with the same headroom the scheduler keeps, and returns
+// the pods of each node. ok is false when a pod doesn't fit on an empty node.
+func packPods(pods []podRequests, shape shared.NodeShape, overhead podRequests) ([][]podRequests, bool) {
+	capacity := shapeCapacity(shape, overhead)
+
+	var bins [][]podRequests
+	var used []podRequests
+	for _, pod := range pods {
+		if !pod.fits(capacity) {
+			return nil, false
+		}
+		placed := false
+		for i := range bins {
+			if used[i].add(pod).fits(capacity) {
+				bins[i] = append(bins[i], pod)
+				used[i] = used[i].add(pod)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			bins = append(bins, []podRequests{pod})
+			used = append(used, pod)
+		}
+	}
+	return bins, true
+}
@@ -9,32 +9,39 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	"sort"
+	"strings"
 )
 
 type SchedulerService struct {
-	nodes        []shared.KubernetesNode
-	pdbs         []policyv1.PodDisruptionBudget
-	daemonSets   utils.ConcurrentMap[string, appv1.DaemonSet]
-	deployments  utils.ConcurrentMap[string, appv1.Deployment]
-	jobs         utils.ConcurrentMap[string, v1.Job]
-	statefulsets utils.ConcurrentMap[string, appv1.StatefulSet]
-	pods         utils.ConcurrentMap[string, corev1.Pod]
+	nodes         []shared.KubernetesNode
+	pdbs          utils.ConcurrentMap[string, policyv1.PodDisruptionBudget]
+	daemonSets    utils.ConcurrentMap[string, appv1.DaemonSet]
+	deployments   utils.ConcurrentMap[string, appv1.Deployment]
+	jobs          utils.ConcurrentMap[string, v1.Job]
+	statefulsets  utils.ConcurrentMap[string, appv1.StatefulSet]
+	pods          utils.ConcurrentMap[string, corev1.Pod]
+	unmanagedPods utils.ConcurrentMap[string, corev1.Pod]
+
+	volumeZones      utils.ConcurrentMap[string, string]
+	nodePoolMinSizes map[string]int
 }
 
 func NewSchedulerService(nodes []shared.KubernetesNode) *SchedulerService {
 	return &SchedulerService{
-		nodes:        nodes,
-		pdbs:         nil,
-		daemonSets:   utils.NewConcurrentMap[string, appv1.DaemonSet](),
-		deployments:  utils.NewConcurrentMap[string, appv1.Deployment](),
-		jobs:         utils.NewConcurrentMap[string, v1.Job](),
-		statefulsets: utils.NewConcurrentMap[string, appv1.StatefulSet](),
-		pods:         utils.NewConcurrentMap[string, corev1.Pod](),
+		nodes:         nodes,
+		pdbs:          utils.NewConcurrentMap[string, policyv1.PodDisruptionBudget](),
+		daemonSets:    utils.NewConcurrentMap[string, appv1.DaemonSet](),
+		deployments:   utils.NewConcurrentMap[string, appv1.Deployment](),
+		jobs:          utils.NewConcurrentMap[string, v1.Job](),
+		statefulsets:  utils.NewConcurrentMap[string, appv1.StatefulSet](),
+		pods:          utils.NewConcurrentMap[string, corev1.Pod](),
+		unmanagedPods: utils.NewConcurrentMap[string, corev1.Pod](),
+		volumeZones:   utils.NewConcurrentMap[string, string](),
 	}
 }
 
 func (s *SchedulerService) AddPodDisruptionBudget(pdb policyv1.PodDisruptionBudget) {
-	s.pdbs = append(s.pdbs, pdb)
+	s.pdbs.Set(fmt.Sprintf("policyv1.PodDisruptionBudget/%s/%s", pdb.Namespace, pdb.Name), pdb)
 }
 
 func (s *SchedulerService) AddDaemonSet(item appv1.DaemonSet) {
@@ -57,6 +64,16 @@ func (s *SchedulerService) AddPod(item corev1.Pod) {
 	s.pods.Set(fmt.Sprintf("corev1.Pod/%s/%s", item.Namespace, item.Name), item)
 }
 
+// AddUnmanagedPod adds a running pod no processor recommends for, e.g. a kube-system or static pod, it is kept on its node.
+func (s *SchedulerService) AddUnmanagedPod(item corev1.Pod) {
+	s.unmanagedPods.Set(fmt.Sprintf("corev1.Pod/%s/%s", item.Namespace, item.Name), item)
+}
+
+// AddVolumeZone records the zone of the zonal volume bound to the claim, the pods using it stay in that zone.
+func (s *SchedulerService) AddVolumeZone(namespace, claimName, zone string) {
+	s.volumeZones.Set(namespace+"/"+claimName, zone)
+}
+
 func (s *SchedulerService) Simulate() ([]shared.KubernetesNode, error) {
 	var nodes []shared.KubernetesNode
 	for _, n := range s.nodes {
@@ -105,88 +122,166 @@ func (s *SchedulerService) simulate(nodes []shared.KubernetesNode) ([]shared.Kub
 		return nil, nil
 	}
 
+	// simulate stays best effort, the pods that fit on none of the nodes are left out of the removal checks
+	scheduler, _ := s.schedule(nodes, true)
+
+	poolSizes := map[string]int{}
+	for _, n := range nodes {
+		poolSizes[shared.NodePool(n.Labels)]++
+	}
+
+	var removed []shared.KubernetesNode
+	var remaining []shared.KubernetesNode
+	for _, n := range removalOrder(nodes) {
+		ok := false
+
+		// the autoscaler keeps a node pool at its minimum size
+		pool := shared.NodePool(n.Labels)
+		if len(removed) == 0 && poolSizes[pool] > s.nodePoolMinSizes[pool] {
+			var err error
+			ok, err = scheduler.CanRemoveNode(n.Name)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if ok {
+			removed = append(removed, n)
+		} else {
+			remaining = append(remaining, n)
+		}
+	}
+
+	if len(removed) == 0 {
+		return removed, nil
+	}
+
+	for idx, r := range remaining {
+		r.AllocatedCPU = 0
+		r.AllocatedMem = 0
+		r.AllocatedPod = 0
+		r.Pods = nil
+		remaining[idx] = r
+	}
+	res, err := s.simulate(remaining)
+	if err != nil {
+		//cant remove it.
+		return removed, nil
+		//return nil, err
+	}
+
+	return append(removed, res...), nil
+}
+
+// placementFailure is a pod, or a pod of a workload, schedule found no node for.
+type placementFailure struct {
+	name   string
+	spec   corev1.PodSpec
+	reason string
+}
+
+// schedule places the pods of the simulation on the nodes, without daemonSets only the pods that move between nodes are placed.
+// The pods that don't fit anywhere are left out of the nodes and returned as failures.
+func (s *SchedulerService) schedule(nodes []shared.KubernetesNode, withDaemonSets bool) (*Scheduler, []placementFailure) {
 	scheduler := New(nodes)
-	for _, pb := range s.pdbs {
+	s.pdbs.Range(func(_ string, pb policyv1.PodDisruptionBudget) bool {
 		scheduler.AddPodDisruptionBudget(pb)
-	}
+		return true
+	})
+	s.volumeZones.Range(func(claim string, zone string) bool {
+		namespace, claimName, _ := strings.Cut(claim, "/")
+		scheduler.AddVolumeZone(namespace, claimName, zone)
+		return true
+	})
 
 	var resources []simulationResource
+	var failures []placementFailure
+	addFailure := func(kind, name, namespace string, spec corev1.PodSpec, reason string) {
+		failures = append(failures, placementFailure{
+			name:   fmt.Sprintf("%s %s", kind, name+"/"+namespace),
+			spec:   spec,
+			reason: reason,
+		})
+	}
+
+	// unmanaged pods stay where they run, the ones of removed nodes are rescheduled unless they go away with the node
+	s.unmanagedPods.Range(func(_ string, r corev1.Pod) bool {
+		if scheduler.AddRunningPod(r) || nodeBoundPod(corev1.PodTemplateSpec{ObjectMeta: r.ObjectMeta}) {
+			return true
+		}
+		resources = append(resources, simulationResource{
+			Priority: resourcePriority(r.Spec),
+			AddFunc: func() {
+				if ok, reason := scheduler.AddPod(r); !ok {
+					addFailure("pod", r.Name, r.Namespace, r.Spec, reason)
+				}
+			},
+		})
+		return true
+	})
 
-	var err error
 	s.daemonSets.Range(func(_ string, r appv1.DaemonSet) bool {
+		if !withDaemonSets {
+			return true
+		}
 		resources = append(resources, simulationResource{
 			Priority: resourcePriority(r.Spec.Template.Spec),
 			AddFunc: func() {
 				if ok, reason := scheduler.AddDaemonSet(r); !ok {
-					err = fmt.Errorf("failed to add daemonSet %s due to: %s", r.Name+"/"+r.Namespace, reason)
+					addFailure("daemonSet", r.Name, r.Namespace, r.Spec.Template.Spec, reason)
 				}
 			},
 		})
 		return true
 	})
-	if err != nil {
-		return nil, err
-	}
 
 	s.deployments.Range(func(_ string, r appv1.Deployment) bool {
 		resources = append(resources, simulationResource{
 			Priority: resourcePriority(r.Spec.Template.Spec),
 			AddFunc: func() {
 				if ok, reason := scheduler.AddDeployment(r); !ok {
-					err = fmt.Errorf("failed to add deployment %s due to: %s", r.Name+"/"+r.Namespace, reason)
+					addFailure("deployment", r.Name, r.Namespace, r.Spec.Template.Spec, reason)
 				}
 			},
 		})
 		return true
 	})
-	if err != nil {
-		return nil, err
-	}
 
 	s.jobs.Range(func(_ string, r v1.Job) bool {
 		resources = append(resources, simulationResource{
 			Priority: resourcePriority(r.Spec.Template.Spec),
 			AddFunc: func() {
 				if ok, reason := scheduler.AddJob(r); !ok {
-					err = fmt.Errorf("failed to add job %s due to: %s", r.Name+"/"+r.Namespace, reason)
+					addFailure("job", r.Name, r.Namespace, r.Spec.Template.Spec, reason)
 				}
 			},
 		})
 		return true
 	})
-	if err != nil {
-		return nil, err
-	}
 
 	s.statefulsets.Range(func(_ string, r appv1.StatefulSet) bool {
 		resources = append(resources, simulationResource{
 			Priority: resourcePriority(r.Spec.Template.Spec),
 			AddFunc: func() {
 				if ok, reason := scheduler.AddStatefulSet(r); !ok {
-					err = fmt.Errorf("failed to add statefulset %s due to: %s", r.Name+"/"+r.Namespace, reason)
+					addFailure("statefulset", r.Name, r.Namespace, r.Spec.Template.Spec, reason)
 				}
 			},
 		})
 		return true
 	})
-	if err != nil {
-		return nil, err
-	}
 
 	s.pods.Range(func(_ string, r corev1.Pod) bool {
 		resources = append(resources, simulationResource{
 			Priority: resourcePriority(r.Spec),
 			AddFunc: func() {
 				if ok, reason := scheduler.AddPod(r); !ok {
-					err = fmt.Errorf("failed to add pod %s due to: %s", r.Name+"/"+r.Namespace, reason)
+					addFailure("pod", r.Name, r.Namespace, r.Spec, reason)
 				}
 			},
 		})
 		return true
 	})
-	if err != nil {
-		return nil, err
-	}
 
 	sort.Slice(resources, func(i, j int) bool {
 		return resources[i].Priority < resources[j].Priority
@@ -195,46 +290,31 @@ func (s *SchedulerService) simulate(nodes []shared.KubernetesNode) ([]shared.Kub
 		r.AddFunc()
 	}
 
-	var removed []shared.KubernetesNode
-	var remaining []shared.KubernetesNode
-	for _, n := range nodes {
-		ok := false
+	return scheduler, failures
+}
 
-		if len(removed) == 0 {
-			var err error
-			ok, err = scheduler.CanRemoveNode(n.Name)
-			if err != nil {
-				return nil, err
-			}
+// removalOrder tries on-demand nodes before spot ones, and the most expensive nodes of each capacity type first. Nodes
+// without a cost come last in their capacity type.
+func removalOrder(nodes []shared.KubernetesNode) []shared.KubernetesNode {
+	ordered := append([]shared.KubernetesNode(nil), nodes...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		iSpot, jSpot := ordered[i].CapacityType == shared.CapacityTypeSpot, ordered[j].CapacityType == shared.CapacityTypeSpot
+		if iSpot != jSpot {
+			return jSpot
 		}
-
-		if ok {
-			removed = append(removed, n)
-		} else {
-			remaining = append(remaining, n)
+		if ordered[i].Cost == nil || ordered[j].Cost == nil {
+			return ordered[i].Cost != nil && ordered[j].Cost == nil
 		}
-	}
-
-	if len(removed) == 0 {
-		return removed, nil
-	}
-
-	for idx, r := range remaining {
-		r.AllocatedCPU = 0
-		r.AllocatedMem = 0
-		r.AllocatedPod = 0
-		remaining[idx] = r
-	}
-	res, err := s.simulate(remaining)
-	if err != nil {
-		//cant remove it.
-		return removed, nil
-		//return nil, err
-	}
-
-	return append(removed, res...), nil
+		return *ordered[i].Cost > *ordered[j].Cost
+	})
+	return ordered
 }
 
 func (s *SchedulerService) SetNodes(knodes []shared.KubernetesNode) {
 	s.nodes = knodes
 }
+
+// SetNodePoolMinSizes sets the minimum number of nodes of the node pools, by pool name.
+func (s *SchedulerService) SetNodePoolMinSizes(minSizes map[string]int) {
+	s.nodePoolMinSizes = minSizes
+}
@@ -104,3 +104,216 @@ func TestServiceAddDaemonSet(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, removed, 0)
 }
+
+func TestServiceUnmanagedPods(t *testing.T) {
+	newNodes := func() []shared.KubernetesNode {
+		return []shared.KubernetesNode{
+			{Name: "node1", VCores: 4, Memory: 8, MaxPodCount: 110},
+			{Name: "node2", VCores: 4, Memory: 8, MaxPodCount: 110},
+		}
+	}
+
+	t.Run("Unmanaged pods keep their nodes", func(t *testing.T) {
+		scheduler := NewSchedulerService(newNodes())
+		scheduler.AddUnmanagedPod(createRunningPod("coredns-1", "kube-system", "node1", 3, 512))
+		scheduler.AddUnmanagedPod(createRunningPod("coredns-2", "kube-system", "node2", 3, 512))
+
+		removed, err := scheduler.Simulate()
+		assert.NoError(t, err)
+		assert.Len(t, removed, 0)
+	})
+
+	t.Run("Static pods go away with their node", func(t *testing.T) {
+		scheduler := NewSchedulerService(newNodes())
+		scheduler.AddUnmanagedPod(createStaticPod("etcd-node1", "node1", 3, 512))
+		scheduler.AddUnmanagedPod(createStaticPod("etcd-node2", "node2", 3, 512))
+
+		removed, err := scheduler.Simulate()
+		assert.NoError(t, err)
+		assert.Len(t, removed, 1)
+	})
+
+	t.Run("Unmanaged pods take capacity from the workloads", func(t *testing.T) {
+		scheduler := NewSchedulerService(newNodes())
+		scheduler.AddUnmanagedPod(createRunningPod("metrics-server", "kube-system", "node1", 2, 512))
+		scheduler.AddPod(createPod("app", 2, 512))
+
+		removed, err := scheduler.Simulate()
+		assert.NoError(t, err)
+		assert.Len(t, removed, 0)
+	})
+}
+
+func TestServiceRemovesOnDemandNodesFirst(t *testing.T) {
+	cost := func(c float64) *float64 { return &c }
+	scheduler := NewSchedulerService([]shared.KubernetesNode{
+		{Name: "spot-1", VCores: 4, Memory: 8, MaxPodCount: 110, CapacityType: shared.CapacityTypeSpot, Cost: cost(30)},
+		{Name: "spot-2", VCores: 4, Memory: 8, MaxPodCount: 110, CapacityType: shared.CapacityTypeSpot, Cost: cost(30)},
+		{Name: "on-demand-1", VCores: 4, Memory: 8, MaxPodCount: 110, CapacityType: shared.CapacityTypeOnDemand, Cost: cost(100)},
+		{Name: "on-demand-2", VCores: 4, Memory: 8, MaxPodCount: 110, CapacityType: shared.CapacityTypeOnDemand, Cost: cost(120)},
+	})
+	for _, name := range []string{"app-1", "app-2"} {
+		pod := createPod(name, 3, 512)
+		pod.Name = name
+		scheduler.AddPod(pod)
+	}
+
+	removed, err := scheduler.Simulate()
+	assert.NoError(t, err)
+	if assert.Len(t, removed, 2) {
+		assert.Equal(t, "on-demand-2", removed[0].Name, "the most expensive on-demand node goes first")
+		assert.Equal(t, "on-demand-1", removed[1].Name)
+	}
+}
+
+func TestRemovalOrder(t *testing.T) {
+	cost := func(c float64) *float64 { return &c }
+	ordered := removalOrder([]shared.KubernetesNode{
+		{Name: "spot-cheap", CapacityType: shared.CapacityTypeSpot, Cost: cost(10)},
+		{Name: "no-cost"},
+		{Name: "spot-expensive", CapacityType: shared.CapacityTypeSpot, Cost: cost(50)},
+		{Name: "on-demand-cheap", CapacityType: shared.CapacityTypeOnDemand, Cost: cost(20)},
+		{Name: "on-demand-expensive", Cost: cost(200)},
+	})
+
+	var names []string
+	for _, n := range ordered {
+		names = append(names, n.Name)
+	}
+	assert.Equal(t, []string{"on-demand-expensive", "on-demand-cheap", "no-cost", "spot-expensive", "spot-cheap"}, names)
+}
+
+func TestServiceProposeNodeShapes(t *testing.T) {
+	catalog := &shared.PricingCatalog{Entries: []shared.PricingCatalogEntry{
+		{InstanceType: "large", HourlyPrice: 0.045, VCores: 8, MemoryGiB: 32},
+		{InstanceType: "small", HourlyPrice: 0.02, VCores: 2, MemoryGiB: 4},
+	}}
+	newScheduler := func(nodeCost float64) *SchedulerService {
+		var nodes []shared.KubernetesNode
+		for _, name := range []string{"node1", "node2"} {
+			cost := nodeCost
+			nodes = append(nodes, shared.KubernetesNode{
+				Name: name, VCores: 8, Memory: 32, MaxPodCount: 110, Cost: &cost,
+				Labels: map[string]string{"eks.amazonaws.com/nodegroup": "workers", v13.LabelInstanceTypeStable: "m5.2xlarge"},
+			})
+		}
+		scheduler := NewSchedulerService(nodes)
+		for i := 0; i < 10; i++ {
+			pod := createPod("app", 0.8, 512)
+			pod.Name = "app-" + string(rune('a'+i))
+			scheduler.AddPod(pod)
+		}
+		return scheduler
+	}
+
+	t.Run("Cheapest mix of the catalog", func(t *testing.T) {
+		proposals, err := newScheduler(280).ProposeNodeShapes(catalog)
+		assert.NoError(t, err)
+		if assert.Len(t, proposals, 1) {
+			assert.Equal(t, "workers", proposals[0].Pool)
+			assert.Len(t, proposals[0].Nodes, 2)
+			var instanceTypes []string
+			for _, s := range proposals[0].Proposed {
+				instanceTypes = append(instanceTypes, s.InstanceType)
+			}
+			assert.Equal(t, []string{"large", "small"}, instanceTypes)
+			assert.InDelta(t, (0.045+0.02)*shared.HoursPerMonth, proposals[0].ProposedCost(), 0.001)
+		}
+	})
+
+	t.Run("No proposal when the pool is already cheaper", func(t *testing.T) {
+		proposals, err := newScheduler(10).ProposeNodeShapes(catalog)
+		assert.NoError(t, err)
+		assert.Len(t, proposals, 0)
+	})
+
+	t.Run("No proposal when a pod of the pool has no node", func(t *testing.T) {
+		scheduler := newScheduler(280)
+		pod := createPod("huge", 20, 512)
+		pod.Name = "huge"
+		scheduler.AddPod(pod)
+		proposals, err := scheduler.ProposeNodeShapes(catalog)
+		assert.NoError(t, err)
+		assert.Len(t, proposals, 0)
+	})
+
+	t.Run("Pods that can't run in the pool don't block its proposal", func(t *testing.T) {
+		scheduler := newScheduler(280)
+		pod := createPod("gpu", 20, 512)
+		pod.Name = "gpu"
+		pod.Spec.NodeSelector = map[string]string{"eks.amazonaws.com/nodegroup": "gpu"}
+		scheduler.AddPod(pod)
+		proposals, err := scheduler.ProposeNodeShapes(catalog)
+		assert.NoError(t, err)
+		assert.Len(t, proposals, 1)
+	})
+
+	t.Run("No proposal without a catalog", func(t *testing.T) {
+		proposals, err := newScheduler(280).ProposeNodeShapes(nil)
+		assert.NoError(t, err)
+		assert.Len(t, proposals, 0)
+	})
+}
+
+func TestServiceNodePoolMinSizes(t *testing.T) {
+	newScheduler := func() *SchedulerService {
+		pool := func(name string) map[string]string {
+			return map[string]string{"eks.amazonaws.com/nodegroup": name}
+		}
+		return NewSchedulerService([]shared.KubernetesNode{
+			{Name: "workers-1", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: pool("workers")},
+			{Name: "workers-2", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: pool("workers")},
+			{Name: "system-1", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: pool("system")},
+		})
+	}
+
+	t.Run("Without min sizes", func(t *testing.T) {
+		removed, err := newScheduler().Simulate()
+		assert.NoError(t, err)
+		assert.Len(t, removed, 2)
+	})
+
+	t.Run("Pools keep their min size", func(t *testing.T) {
+		scheduler := newScheduler()
+		scheduler.SetNodePoolMinSizes(map[string]int{"workers": 2})
+		removed, err := scheduler.Simulate()
+		assert.NoError(t, err)
+		if assert.Len(t, removed, 1) {
+			assert.Equal(t, "system-1", removed[0].Name)
+		}
+	})
+}
+
+func TestServiceKeepsVolumeZones(t *testing.T) {
+	scheduler := NewSchedulerService([]shared.KubernetesNode{
+		{Name: "a-1", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: map[string]string{v13.LabelTopologyZone: "a"}},
+		{Name: "b-1", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: map[string]string{v13.LabelTopologyZone: "b"}},
+		{Name: "b-2", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: map[string]string{v13.LabelTopologyZone: "b"}},
+	})
+	scheduler.AddVolumeZone("default", "data-db-0", "a")
+	scheduler.AddStatefulSet(createStatefulSetWithVolumeClaims("db", "default", 1, 1, "data"))
+
+	removed, err := scheduler.Simulate()
+	assert.NoError(t, err)
+	assert.Len(t, removed, 2)
+	for _, n := range removed {
+		assert.NotEqual(t, "a-1", n.Name)
+	}
+}
+
+func TestProposeNodeMix(t *testing.T) {
+	big := shared.NodeShape{InstanceType: "big", VCores: 10, Memory: 100, MaxPodCount: 110, Cost: 10}
+	mid := shared.NodeShape{InstanceType: "mid", VCores: 4, Memory: 100, MaxPodCount: 110, Cost: 3.5}
+	pods := []podRequests{{cpu: 3.5, pods: 1}, {cpu: 9, pods: 1}, {cpu: 3.5, pods: 1}}
+
+	mix, ok := proposeNodeMix(pods, []shared.NodeShape{mid, big}, podRequests{})
+	assert.True(t, ok)
+	var instanceTypes []string
+	for _, s := range mix {
+		instanceTypes = append(instanceTypes, s.InstanceType)
+	}
+	assert.ElementsMatch(t, []string{"big", "mid", "mid"}, instanceTypes, "mixing shapes beats two big nodes")
+
+	_, ok = proposeNodeMix([]podRequests{{cpu: 12, pods: 1}}, []shared.NodeShape{mid, big}, podRequests{})
+	assert.False(t, ok, "no shape fits the pod")
+}
@@ -13,12 +13,16 @@ import (
 	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// CPUHeadroomFactor, MemoryHeadroomFactor and PodHeadroomFactor are the share of the node allocatable the simulation fills.
+// Allocatable already leaves out kube-reserved, system-reserved and the eviction thresholds, so they only keep a small
+// margin for usage spikes. They follow the Node*BreathingRoom preferences.
 var (
-	CPUHeadroomFactor    = 0.85
-	MemoryHeadroomFactor = 0.85
-	PodHeadroomFactor    = 0.95
+	CPUHeadroomFactor    = 0.95
+	MemoryHeadroomFactor = 0.95
+	PodHeadroomFactor    = 1.0
 )
 
 const (
@@ -26,8 +30,9 @@ const (
 )
 
 type Scheduler struct {
-	nodes []shared.KubernetesNode
-	pdbs  []policyv1.PodDisruptionBudget
+	nodes       []shared.KubernetesNode
+	pdbs        []policyv1.PodDisruptionBudget
+	volumeZones map[string]string
 }
 
 func New(nodes []shared.KubernetesNode) *Scheduler {
@@ -40,11 +45,20 @@ func (s *Scheduler) AddPodDisruptionBudget(pdb policyv1.PodDisruptionBudget) {
 	s.pdbs = append(s.pdbs, pdb)
 }
 
+// AddVolumeZone records the zone of the zonal volume bound to the claim, the pods using the claim are kept in that zone.
+func (s *Scheduler) AddVolumeZone(namespace, claimName, zone string) {
+	if s.volumeZones == nil {
+		s.volumeZones = make(map[string]string)
+	}
+	s.volumeZones[namespace+"/"+claimName] = zone
+}
+
 func (s *Scheduler) AddDaemonSet(item appv1.DaemonSet) (bool, string) {
+	template := namespacedTemplate(item.Spec.Template, item.Namespace)
 	reasonCount := map[string]int{}
 	for i := range s.nodes {
-		if ok, reason := s.canScheduleOnNode(item.Spec.Template.Spec, &s.nodes[i]); ok {
-			s.schedulePod(item.Spec.Template, &s.nodes[i])
+		if ok, reason := s.canScheduleOnNode(template.Spec, &s.nodes[i]); ok {
+			s.schedulePod(template, &s.nodes[i])
 		} else {
 			reasonCount[reason]++
 		}
@@ -63,8 +77,9 @@ func (s *Scheduler) AddDaemonSet(item appv1.DaemonSet) (bool, string) {
 }
 
 func (s *Scheduler) AddDeployment(item appv1.Deployment) (bool, string) {
+	template := namespacedTemplate(item.Spec.Template, item.Namespace)
 	for i := 0; i < int(*item.Spec.Replicas); i++ {
-		if ok, reason := s.schedulePodWithStrategy(item.Spec.Template); !ok {
+		if ok, reason := s.schedulePodWithStrategy(template); !ok {
 			return false, reason
 		}
 	}
@@ -72,8 +87,9 @@ func (s *Scheduler) AddDeployment(item appv1.Deployment) (bool, string) {
 }
 
 func (s *Scheduler) AddJob(item batchv1.Job) (bool, string) {
+	template := namespacedTemplate(item.Spec.Template, item.Namespace)
 	for i := 0; i < int(*item.Spec.Completions); i++ {
-		if ok, reason := s.schedulePodWithStrategy(item.Spec.Template); !ok {
+		if ok, reason := s.schedulePodWithStrategy(template); !ok {
 			return true, reason
 		}
 	}
@@ -81,8 +97,18 @@ func (s *Scheduler) AddJob(item batchv1.Job) (bool, string) {
 }
 
 func (s *Scheduler) AddStatefulSet(item appv1.StatefulSet) (bool, string) {
+	template := namespacedTemplate(item.Spec.Template, item.Namespace)
+	start := 0
+	if item.Spec.Ordinals != nil {
+		start = int(item.Spec.Ordinals.Start)
+	}
 	for i := 0; i < int(*item.Spec.Replicas); i++ {
-		if ok, reason := s.schedulePodWithStrategy(item.Spec.Template); !ok {
+		// each replica has its own claims, created from the volumeClaimTemplates
+		claimNames := podClaimNames(template.Spec)
+		for _, claimTemplate := range item.Spec.VolumeClaimTemplates {
+			claimNames = append(claimNames, fmt.Sprintf("%s-%s-%d", claimTemplate.Name, item.Name, start+i))
+		}
+		if ok, reason := s.schedulePodWithStrategy(s.pinToVolumeZone(template, claimNames)); !ok {
 			return false, reason
 		}
 	}
@@ -90,10 +116,114 @@ func (s *Scheduler) AddStatefulSet(item appv1.StatefulSet) (bool, string) {
 }
 
 func (s *Scheduler) AddPod(item corev1.Pod) (bool, string) {
-	return s.schedulePodWithStrategy(corev1.PodTemplateSpec{
+	template := corev1.PodTemplateSpec{
 		ObjectMeta: item.ObjectMeta,
 		Spec:       item.Spec,
-	})
+	}
+	return s.schedulePodWithStrategy(s.pinToVolumeZone(template, podClaimNames(item.Spec)))
+}
+
+func podClaimNames(spec corev1.PodSpec) []string {
+	var claimNames []string
+	for _, volume := range spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			claimNames = append(claimNames, volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	return claimNames
+}
+
+// pinToVolumeZone requires the zone of the first zonal volume of the claims on top of the node affinity of the pod, a zonal
+// volume can only be attached to nodes of its zone.
+func (s *Scheduler) pinToVolumeZone(template corev1.PodTemplateSpec, claimNames []string) corev1.PodTemplateSpec {
+	zone := ""
+	for _, claimName := range claimNames {
+		if z, ok := s.volumeZones[template.Namespace+"/"+claimName]; ok {
+			zone = z
+			break
+		}
+	}
+	if zone == "" {
+		return template
+	}
+
+	pinned := *template.DeepCopy()
+	if pinned.Spec.Affinity == nil {
+		pinned.Spec.Affinity = &corev1.Affinity{}
+	}
+	if pinned.Spec.Affinity.NodeAffinity == nil {
+		pinned.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	nodeAffinity := pinned.Spec.Affinity.NodeAffinity
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+	required := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(required.NodeSelectorTerms) == 0 {
+		required.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+	// terms are ORed, the zone has to be added to each of them, once for every label the nodes carry their zone in
+	var terms []corev1.NodeSelectorTerm
+	for _, key := range s.zoneKeys() {
+		for _, term := range required.NodeSelectorTerms {
+			term = *term.DeepCopy()
+			term.MatchExpressions = append(term.MatchExpressions, corev1.NodeSelectorRequirement{
+				Key:      key,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{zone},
+			})
+			terms = append(terms, term)
+		}
+	}
+	required.NodeSelectorTerms = terms
+	return pinned
+}
+
+// zoneKeys returns the labels the nodes carry their zone in, older nodes only have the legacy failure-domain one.
+func (s *Scheduler) zoneKeys() []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, n := range s.nodes {
+		if key := shared.NodeZoneKey(n.Labels); key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return []string{corev1.LabelTopologyZone}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// AddRunningPod places a pod that already runs on its node there, whatever the rules, it returns false when the node is not simulated.
+func (s *Scheduler) AddRunningPod(item corev1.Pod) bool {
+	for i := range s.nodes {
+		if s.nodes[i].Name == item.Spec.NodeName {
+			s.schedulePod(corev1.PodTemplateSpec{
+				ObjectMeta: item.ObjectMeta,
+				Spec:       item.Spec,
+			}, &s.nodes[i])
+			return true
+		}
+	}
+	return false
+}
+
+// nodeBoundPod reports whether the pod goes away with its node instead of being rescheduled, like static pods and DaemonSet pods.
+func nodeBoundPod(pod corev1.PodTemplateSpec) bool {
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return true
+	}
+	ref := metav1.GetControllerOf(&pod)
+	return ref != nil && (ref.Kind == "Node" || ref.Kind == "DaemonSet")
+}
+
+func namespacedTemplate(template corev1.PodTemplateSpec, namespace string) corev1.PodTemplateSpec {
+	if template.Namespace == "" {
+		template.Namespace = namespace
+	}
+	return template
 }
 
 func (s *Scheduler) GetNodeUtilization() map[string]map[string]float64 {
@@ -138,6 +268,9 @@ func (s *Scheduler) CanRemoveNode(nodeName string) (bool, error) {
 
 	// Simulate draining the node
 	for _, pod := range nodeToRemove.Pods {
+		if nodeBoundPod(pod) {
+			continue
+		}
 		if !s.canEvictPod(pod) {
 			return false, nil
 		}
@@ -151,22 +284,34 @@ func (s *Scheduler) CanRemoveNode(nodeName string) (bool, error) {
 }
 
 func (s *Scheduler) schedulePodWithStrategy(podSpec corev1.PodTemplateSpec) (bool, string) {
-	// Sort nodes by most allocated resources
+	preferenceScores := make(map[string]int32, len(s.nodes))
+	for _, node := range s.nodes {
+		preferenceScores[node.Name] = s.preferenceScore(podSpec, node)
+	}
+
+	// Sort nodes by most allocated resources, the soft preferences of the pod break the ties
 	sort.Slice(s.nodes, func(i, j int) bool {
 		allocRatioI := max(s.nodes[i].AllocatedCPU/s.nodes[i].VCores, s.nodes[i].AllocatedMem/s.nodes[i].Memory)
 		allocRatioJ := max(s.nodes[j].AllocatedCPU/s.nodes[j].VCores, s.nodes[j].AllocatedMem/s.nodes[j].Memory)
-		return allocRatioI > allocRatioJ
+		if allocRatioI != allocRatioJ {
+			return allocRatioI > allocRatioJ
+		}
+		return preferenceScores[s.nodes[i].Name] > preferenceScores[s.nodes[j].Name]
 	})
 
 	// Try to schedule on the most allocated node that can accommodate the pod
 	reasonCount := map[string]int{}
 	for i := range s.nodes {
-		if ok, reason := s.canScheduleOnNode(podSpec.Spec, &s.nodes[i]); ok {
-			s.schedulePod(podSpec, &s.nodes[i])
-			return true, ""
-		} else {
+		if ok, reason := s.canScheduleOnNode(podSpec.Spec, &s.nodes[i]); !ok {
 			reasonCount[reason]++
+			continue
+		}
+		if !s.satisfiesTopologySpreadConstraints(podSpec, s.nodes[i]) {
+			reasonCount[SchedulingReason_TopologySpreadNotSatisfied]++
+			continue
 		}
+		s.schedulePod(podSpec, &s.nodes[i])
+		return true, ""
 	}
 
 	var reasons []string
@@ -186,6 +331,7 @@ const (
 	SchedulingReason_AffinityNotSatisfied       = "affinity not satisfied"
 	SchedulingReason_NodeSelectorLabelMismatch  = "node selector label mismatch"
 	SchedulingReason_NodeSelectorLabelNotExists = "node selector label not exists"
+	SchedulingReason_TopologySpreadNotSatisfied = "topology spread constraint not satisfied"
 )
 
 func (s *Scheduler) canScheduleOnNode(podSpec corev1.PodSpec, node *shared.KubernetesNode) (bool, string) {
@@ -278,6 +424,116 @@ func (s *Scheduler) satisfiesPodAffinityTerm(term corev1.PodAffinityTerm, node s
 	return false
 }
 
+// satisfiesTopologySpreadConstraints checks the DoNotSchedule spread constraints of the pod against the pods already placed,
+// ScheduleAnyway constraints don't block scheduling.
+func (s *Scheduler) satisfiesTopologySpreadConstraints(pod corev1.PodTemplateSpec, node shared.KubernetesNode) bool {
+	for _, constraint := range pod.Spec.TopologySpreadConstraints {
+		if constraint.WhenUnsatisfiable != corev1.DoNotSchedule {
+			continue
+		}
+		domain, ok := node.Labels[constraint.TopologyKey]
+		if !ok {
+			return false
+		}
+		selector, err := metav1.LabelSelectorAsSelector(constraint.LabelSelector)
+		if err != nil {
+			continue
+		}
+
+		// matching pods per domain, over the nodes the pod could be placed on
+		domainCounts := map[string]int{}
+		for _, n := range s.nodes {
+			d, ok := n.Labels[constraint.TopologyKey]
+			if !ok || !s.includedInTopologySpread(pod.Spec, constraint, n) {
+				continue
+			}
+			if _, seen := domainCounts[d]; !seen {
+				domainCounts[d] = 0
+			}
+			for _, existingPod := range n.Pods {
+				if sameNamespace(pod.Namespace, existingPod.Namespace) && selector.Matches(labels.Set(existingPod.Labels)) {
+					domainCounts[d]++
+				}
+			}
+		}
+
+		minCount := math.MaxInt
+		for _, count := range domainCounts {
+			minCount = min(minCount, count)
+		}
+		if constraint.MinDomains != nil && len(domainCounts) < int(*constraint.MinDomains) {
+			minCount = 0
+		}
+		selfMatch := 0
+		if selector.Matches(labels.Set(pod.Labels)) {
+			selfMatch = 1
+		}
+		if domainCounts[domain]+selfMatch-minCount > int(constraint.MaxSkew) {
+			return false
+		}
+	}
+	return true
+}
+
+// includedInTopologySpread applies the node inclusion policies of the constraint, by default only the node affinity and selector
+// of the pod are honored.
+func (s *Scheduler) includedInTopologySpread(podSpec corev1.PodSpec, constraint corev1.TopologySpreadConstraint, node shared.KubernetesNode) bool {
+	if constraint.NodeAffinityPolicy == nil || *constraint.NodeAffinityPolicy == corev1.NodeInclusionPolicyHonor {
+		if podSpec.Affinity != nil && podSpec.Affinity.NodeAffinity != nil && !s.satisfiesNodeAffinity(podSpec.Affinity.NodeAffinity, node.Labels) {
+			return false
+		}
+		for key, value := range podSpec.NodeSelector {
+			if node.Labels[key] != value {
+				return false
+			}
+		}
+	}
+	if constraint.NodeTaintsPolicy != nil && *constraint.NodeTaintsPolicy == corev1.NodeInclusionPolicyHonor {
+		if !s.tolerates(podSpec, node.Taints) {
+			return false
+		}
+	}
+	return true
+}
+
+// preferenceScore adds up the weights of the preferred node affinity and pod affinity terms the node satisfies,
+// minus the weights of the preferred pod anti-affinity terms it violates.
+func (s *Scheduler) preferenceScore(pod corev1.PodTemplateSpec, node shared.KubernetesNode) int32 {
+	affinity := pod.Spec.Affinity
+	if affinity == nil {
+		return 0
+	}
+
+	var score int32
+	if affinity.NodeAffinity != nil {
+		for _, term := range affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			if s.matchNodeSelectorTerm(term.Preference, node.Labels) {
+				score += term.Weight
+			}
+		}
+	}
+	if affinity.PodAffinity != nil {
+		for _, term := range affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			if s.satisfiesPodAffinityTerm(term.PodAffinityTerm, node) {
+				score += term.Weight
+			}
+		}
+	}
+	if affinity.PodAntiAffinity != nil {
+		for _, term := range affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			if s.satisfiesPodAffinityTerm(term.PodAffinityTerm, node) {
+				score -= term.Weight
+			}
+		}
+	}
+	return score
+}
+
+// sameNamespace reports whether pods of the two namespaces can select each other, templates without a namespace match any.
+func sameNamespace(namespace, other string) bool {
+	return namespace == "" || other == "" || namespace == other
+}
+
 func (s *Scheduler) hasEnoughResources(podSpec corev1.PodSpec, node *shared.KubernetesNode) (bool, string) {
 	cpuReq, memReq := getPodResourceRequests(podSpec)
 
@@ -294,22 +550,33 @@ func (s *Scheduler) hasEnoughResources(podSpec corev1.PodSpec, node *shared.Kube
 	return true, ""
 }
 
+// getPodResourceRequests returns the effective requests of the pod the way the scheduler counts them:
+// the larger of what its containers and sidecars request together and what any init container needs while it runs.
 func getPodResourceRequests(podSpec corev1.PodSpec) (float64, float64) {
-	cpuReq, memReq := float64(0), float64(0)
+	initCpuReq, initMemReq := float64(0), float64(0)
+	sidecarCpuReq, sidecarMemReq := float64(0), float64(0)
 
-	// Calculate for init containers
+	// Calculate for init containers, each one runs next to the sidecars started before it
 	for _, container := range podSpec.InitContainers {
-		cpuReq = math.Max(cpuReq, float64(container.Resources.Requests.Cpu().MilliValue())/1000)
-		memReq = math.Max(memReq, float64(container.Resources.Requests.Memory().Value())/(GB))
+		cpu := float64(container.Resources.Requests.Cpu().MilliValue()) / 1000
+		mem := float64(container.Resources.Requests.Memory().Value()) / (GB)
+		if shared.IsSidecar(container) {
+			sidecarCpuReq += cpu
+			sidecarMemReq += mem
+			cpu, mem = 0, 0
+		}
+		initCpuReq = math.Max(initCpuReq, sidecarCpuReq+cpu)
+		initMemReq = math.Max(initMemReq, sidecarMemReq+mem)
 	}
 
-	// Calculate for regular containers
+	// Calculate for regular containers, sidecars keep running next to them
+	cpuReq, memReq := sidecarCpuReq, sidecarMemReq
 	for _, container := range podSpec.Containers {
 		cpuReq += float64(container.Resources.Requests.Cpu().MilliValue()) / 1000
 		memReq += float64(container.Resources.Requests.Memory().Value()) / (GB)
 	}
 
-	return cpuReq, memReq
+	return math.Max(cpuReq, initCpuReq), math.Max(memReq, initMemReq)
 }
 
 func (s *Scheduler) tolerates(podSpec corev1.PodSpec, nodeTaints []corev1.Taint) bool {
@@ -381,6 +648,10 @@ func contains(slice []string, s string) bool {
 
 func (s *Scheduler) canEvictPod(pod corev1.PodTemplateSpec) bool {
 	for _, pdb := range s.pdbs {
+		if !pdbAppliesToNamespace(pdb, pod.Namespace) {
+			continue
+		}
+
 		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
 		if err != nil {
 			continue
@@ -388,13 +659,21 @@ func (s *Scheduler) canEvictPod(pod corev1.PodTemplateSpec) bool {
 
 		if selector.Matches(labels.Set(pod.Labels)) {
 			currentHealthy := s.countHealthyPods(pdb)
+			expectedCount := s.getTotalPodCount(pdb)
 			if pdb.Spec.MinAvailable != nil {
-				if currentHealthy <= pdb.Spec.MinAvailable.IntValue() {
+				minAvailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.Spec.MinAvailable, expectedCount, true)
+				if err != nil {
+					continue
+				}
+				if currentHealthy <= minAvailable {
 					return false
 				}
 			} else if pdb.Spec.MaxUnavailable != nil {
-				maxUnavailable := pdb.Spec.MaxUnavailable.IntValue()
-				if currentHealthy-1 < s.getTotalPodCount(pdb)-maxUnavailable {
+				maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.Spec.MaxUnavailable, expectedCount, true)
+				if err != nil {
+					continue
+				}
+				if currentHealthy-1 < expectedCount-maxUnavailable {
 					return false
 				}
 			}
@@ -403,6 +682,12 @@ func (s *Scheduler) canEvictPod(pod corev1.PodTemplateSpec) bool {
 	return true
 }
 
+// pdbAppliesToNamespace reports whether a budget can cover pods of the given namespace.
+// Templates without a namespace are matched against every budget.
+func pdbAppliesToNamespace(pdb policyv1.PodDisruptionBudget, namespace string) bool {
+	return pdb.Namespace == "" || namespace == "" || pdb.Namespace == namespace
+}
+
 func (s *Scheduler) getTotalPodCount(pdb policyv1.PodDisruptionBudget) int {
 	count := 0
 	selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
@@ -412,7 +697,7 @@ func (s *Scheduler) getTotalPodCount(pdb policyv1.PodDisruptionBudget) int {
 
 	for _, node := range s.nodes {
 		for _, pod := range node.Pods {
-			if selector.Matches(labels.Set(pod.Labels)) {
+			if pdbAppliesToNamespace(pdb, pod.Namespace) && selector.Matches(labels.Set(pod.Labels)) {
 				count++
 			}
 		}
@@ -429,7 +714,7 @@ func (s *Scheduler) countHealthyPods(pdb policyv1.PodDisruptionBudget) int {
 
 	for _, node := range s.nodes {
 		for _, pod := range node.Pods {
-			if selector.Matches(labels.Set(pod.Labels)) {
+			if pdbAppliesToNamespace(pdb, pod.Namespace) && selector.Matches(labels.Set(pod.Labels)) {
 				count++
 			}
 		}
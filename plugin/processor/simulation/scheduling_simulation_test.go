@@ -6,6 +6,7 @@ import (
 	v12 "k8s.io/api/batch/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"strings"
 	"testing"
 
 	v1 "k8s.io/api/apps/v1"
@@ -718,6 +719,79 @@ func TestCanRemoveNodeWithPDBs(t *testing.T) {
 	}
 }
 
+func TestCanRemoveNodeWithPercentagePDBs(t *testing.T) {
+	newScheduler := func() *Scheduler {
+		web := map[string]string{"app": "web"}
+		return New([]shared.KubernetesNode{
+			{
+				Name:         "node1",
+				VCores:       4,
+				Memory:       8,
+				MaxPodCount:  110,
+				AllocatedCPU: 0.1,
+				AllocatedMem: 0.125,
+				AllocatedPod: 1,
+				Pods:         []v13.PodTemplateSpec{createPodTemplate("default", web)},
+			},
+			{
+				Name:         "node2",
+				VCores:       4,
+				Memory:       8,
+				MaxPodCount:  110,
+				AllocatedCPU: 0.3,
+				AllocatedMem: 0.375,
+				AllocatedPod: 3,
+				Pods: []v13.PodTemplateSpec{
+					createPodTemplate("default", web),
+					createPodTemplate("default", web),
+					createPodTemplate("default", web),
+				},
+			},
+		})
+	}
+
+	tests := []struct {
+		name           string
+		namespace      string
+		minAvailable   *intstr.IntOrString
+		maxUnavailable *intstr.IntOrString
+		canRemove      bool
+	}{
+		{name: "minAvailable 75%", namespace: "default", minAvailable: &intstr.IntOrString{Type: intstr.String, StrVal: "75%"}, canRemove: true},
+		{name: "minAvailable 100%", namespace: "default", minAvailable: &intstr.IntOrString{Type: intstr.String, StrVal: "100%"}, canRemove: false},
+		{name: "maxUnavailable 10%", namespace: "default", maxUnavailable: &intstr.IntOrString{Type: intstr.String, StrVal: "10%"}, canRemove: true},
+		{name: "maxUnavailable 0%", namespace: "default", maxUnavailable: &intstr.IntOrString{Type: intstr.String, StrVal: "0%"}, canRemove: false},
+		{name: "other namespace", namespace: "other", minAvailable: &intstr.IntOrString{Type: intstr.String, StrVal: "100%"}, canRemove: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := newScheduler()
+			scheduler.AddPodDisruptionBudget(policyv1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "web",
+					Namespace: tt.namespace,
+				},
+				Spec: policyv1.PodDisruptionBudgetSpec{
+					MinAvailable:   tt.minAvailable,
+					MaxUnavailable: tt.maxUnavailable,
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"app": "web"},
+					},
+				},
+			})
+
+			canRemove, err := scheduler.CanRemoveNode("node1")
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if canRemove != tt.canRemove {
+				t.Errorf("Expected CanRemoveNode to return %v, got %v", tt.canRemove, canRemove)
+			}
+		})
+	}
+}
+
 func TestPodScheduling(t *testing.T) {
 	t.Run("Pod with no resource requests", func(t *testing.T) {
 		scheduler, node := setupSchedulerWithOneNode(1, 1024, 10)
@@ -779,7 +853,7 @@ func TestPodScheduling(t *testing.T) {
 		scheduler, node := setupSchedulerWithOneNode(1, 1024, 10)
 		pod1 := createPod("fill-pod-1", 0.4, 400)
 		pod2 := createPod("fill-pod-2", 0.3, 300)
-		pod3 := createPod("fill-pod-3", 0.15, 170)
+		pod3 := createPod("fill-pod-3", 0.25, 270)
 
 		success1, _ := scheduler.AddPod(pod1)
 		success2, _ := scheduler.AddPod(pod2)
@@ -861,7 +935,7 @@ func TestNodeConditions(t *testing.T) {
 	})
 
 	t.Run("Node with exactly one spot left for a pod", func(t *testing.T) {
-		scheduler, node := setupSchedulerWithOneNode(4, 8192, 10)
+		scheduler, node := setupSchedulerWithOneNode(4, 8192, 9)
 
 		// Fill the node leaving space for exactly one more pod
 		for i := 0; i < 8; i++ {
@@ -1260,3 +1334,339 @@ func TestSpecialPodTypes(t *testing.T) {
 		}
 	})
 }
+
+func TestGetPodResourceRequests(t *testing.T) {
+	tests := []struct {
+		name        string
+		pod         v13.Pod
+		expectedCpu float64
+		expectedMem float64
+	}{
+		{
+			name:        "containers only",
+			pod:         createPod("app", 0.5, 512),
+			expectedCpu: 0.5,
+			expectedMem: 0.5,
+		},
+		{
+			name:        "init container smaller than the containers",
+			pod:         createPodWithInitContainers("app", 0.5, 512, createInitContainer("init", 0.25, 256, false)),
+			expectedCpu: 0.5,
+			expectedMem: 0.5,
+		},
+		{
+			name:        "init container larger than the containers",
+			pod:         createPodWithInitContainers("app", 0.5, 512, createInitContainer("init", 2, 2048, false)),
+			expectedCpu: 2,
+			expectedMem: 2,
+		},
+		{
+			name:        "sidecar adds to the containers",
+			pod:         createPodWithInitContainers("app", 0.5, 512, createInitContainer("proxy", 0.25, 256, true)),
+			expectedCpu: 0.75,
+			expectedMem: 0.75,
+		},
+		{
+			name: "init container runs next to the sidecars started before it",
+			pod: createPodWithInitContainers("app", 0.5, 512,
+				createInitContainer("proxy", 0.25, 256, true),
+				createInitContainer("migrate", 1, 1024, false),
+			),
+			expectedCpu: 1.25,
+			expectedMem: 1.25,
+		},
+		{
+			name: "init container started before the sidecars",
+			pod: createPodWithInitContainers("app", 0.5, 512,
+				createInitContainer("migrate", 1, 1024, false),
+				createInitContainer("proxy", 0.25, 256, true),
+			),
+			expectedCpu: 1,
+			expectedMem: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpu, mem := getPodResourceRequests(tt.pod.Spec)
+			if cpu != tt.expectedCpu {
+				t.Errorf("Expected cpu request %f, got %f", tt.expectedCpu, cpu)
+			}
+			if mem != tt.expectedMem {
+				t.Errorf("Expected memory request %f, got %f", tt.expectedMem, mem)
+			}
+		})
+	}
+}
+
+func TestTopologySpreadConstraints(t *testing.T) {
+	podsOnNode := func(scheduler *Scheduler, name string) int {
+		for _, node := range scheduler.nodes {
+			if node.Name == name {
+				return node.AllocatedPod
+			}
+		}
+		return -1
+	}
+
+	t.Run("DoNotSchedule spreads pods over zones", func(t *testing.T) {
+		nodes := []shared.KubernetesNode{
+			{Name: "node1", Labels: map[string]string{"zone": "a"}, VCores: 4, Memory: 8, MaxPodCount: 10},
+			{Name: "node2", Labels: map[string]string{"zone": "b"}, VCores: 4, Memory: 8, MaxPodCount: 10},
+		}
+		scheduler := New(nodes)
+
+		for i := 0; i < 4; i++ {
+			pod := createPodWithTopologySpread(fmt.Sprintf("web-%d", i), map[string]string{"app": "web"}, "zone", 1, v13.DoNotSchedule)
+			if success, reason := scheduler.AddPod(pod); !success {
+				t.Fatalf("Failed to schedule pod %d: %s", i, reason)
+			}
+		}
+
+		if podsOnNode(scheduler, "node1") != 2 || podsOnNode(scheduler, "node2") != 2 {
+			t.Errorf("Expected 2 pods per zone, got %d and %d", podsOnNode(scheduler, "node1"), podsOnNode(scheduler, "node2"))
+		}
+	})
+
+	t.Run("DoNotSchedule skips nodes without the topology key", func(t *testing.T) {
+		nodes := []shared.KubernetesNode{
+			{Name: "node1", VCores: 4, Memory: 8, MaxPodCount: 10},
+			{Name: "node2", Labels: map[string]string{"zone": "a"}, VCores: 4, Memory: 8, MaxPodCount: 10},
+		}
+		scheduler := New(nodes)
+
+		pod := createPodWithTopologySpread("web", map[string]string{"app": "web"}, "zone", 1, v13.DoNotSchedule)
+		if success, reason := scheduler.AddPod(pod); !success {
+			t.Fatalf("Failed to schedule pod: %s", reason)
+		}
+		if podsOnNode(scheduler, "node1") != 0 {
+			t.Errorf("Pod scheduled on a node without the topology key")
+		}
+	})
+
+	t.Run("DoNotSchedule fails when the skew can't be kept", func(t *testing.T) {
+		nodes := []shared.KubernetesNode{
+			{Name: "node1", Labels: map[string]string{"zone": "a"}, VCores: 4, Memory: 8, MaxPodCount: 10},
+			{Name: "node2", Labels: map[string]string{"zone": "b"}, VCores: 4, Memory: 8, MaxPodCount: 1, AllocatedPod: 1},
+		}
+		scheduler := New(nodes)
+
+		scheduler.AddPod(createPodWithTopologySpread("web-1", map[string]string{"app": "web"}, "zone", 1, v13.DoNotSchedule))
+		success, reason := scheduler.AddPod(createPodWithTopologySpread("web-2", map[string]string{"app": "web"}, "zone", 1, v13.DoNotSchedule))
+		if success {
+			t.Errorf("Incorrectly scheduled pod breaking the max skew")
+		}
+		if !strings.Contains(reason, SchedulingReason_TopologySpreadNotSatisfied) {
+			t.Errorf("Expected reason to mention the spread constraint, got %s", reason)
+		}
+	})
+
+	t.Run("ScheduleAnyway doesn't block scheduling", func(t *testing.T) {
+		nodes := []shared.KubernetesNode{
+			{Name: "node1", Labels: map[string]string{"zone": "a"}, VCores: 4, Memory: 8, MaxPodCount: 10},
+			{Name: "node2", Labels: map[string]string{"zone": "b"}, VCores: 4, Memory: 8, MaxPodCount: 1},
+		}
+		scheduler := New(nodes)
+
+		scheduler.AddPod(createPodWithTopologySpread("web-1", map[string]string{"app": "web"}, "zone", 1, v13.ScheduleAnyway))
+		if success, reason := scheduler.AddPod(createPodWithTopologySpread("web-2", map[string]string{"app": "web"}, "zone", 1, v13.ScheduleAnyway)); !success {
+			t.Errorf("Failed to schedule pod with a soft spread constraint: %s", reason)
+		}
+	})
+}
+
+func TestPreferredAffinityTieBreak(t *testing.T) {
+	t.Run("Preferred node affinity picks between equally allocated nodes", func(t *testing.T) {
+		nodes := []shared.KubernetesNode{
+			{Name: "node1", Labels: map[string]string{"disk": "hdd"}, VCores: 4, Memory: 8, MaxPodCount: 10},
+			{Name: "node2", Labels: map[string]string{"disk": "ssd"}, VCores: 4, Memory: 8, MaxPodCount: 10},
+		}
+		scheduler := New(nodes)
+
+		success, _ := scheduler.AddPod(createPodWithPreferredNodeAffinity("test-pod", "disk", "ssd", 10))
+		if !success {
+			t.Fatalf("Failed to schedule pod with preferred node affinity")
+		}
+		for _, node := range scheduler.nodes {
+			if node.Name == "node2" && node.AllocatedPod != 1 {
+				t.Errorf("Expected pod on the preferred node")
+			}
+		}
+	})
+
+	t.Run("Preferred pod anti-affinity moves replicas apart", func(t *testing.T) {
+		nodes := []shared.KubernetesNode{
+			{Name: "node1", Labels: map[string]string{"kubernetes.io/hostname": "node1"}, VCores: 4, Memory: 8, MaxPodCount: 10},
+			{Name: "node2", Labels: map[string]string{"kubernetes.io/hostname": "node2"}, VCores: 4, Memory: 8, MaxPodCount: 10},
+		}
+		scheduler := New(nodes)
+
+		for i := 0; i < 2; i++ {
+			pod := createPodWithPreferredPodAntiAffinity(fmt.Sprintf("web-%d", i), map[string]string{"app": "web"}, "kubernetes.io/hostname", 100)
+			if success, reason := scheduler.AddPod(pod); !success {
+				t.Fatalf("Failed to schedule pod %d: %s", i, reason)
+			}
+		}
+		for _, node := range scheduler.nodes {
+			if node.AllocatedPod != 1 {
+				t.Errorf("Expected one replica on %s, got %d", node.Name, node.AllocatedPod)
+			}
+		}
+	})
+
+	t.Run("Preferences don't override the most allocated node", func(t *testing.T) {
+		nodes := []shared.KubernetesNode{
+			{Name: "node1", Labels: map[string]string{"disk": "hdd"}, VCores: 4, Memory: 8, MaxPodCount: 10, AllocatedCPU: 2, AllocatedMem: 4},
+			{Name: "node2", Labels: map[string]string{"disk": "ssd"}, VCores: 4, Memory: 8, MaxPodCount: 10},
+		}
+		scheduler := New(nodes)
+
+		scheduler.AddPod(createPodWithPreferredNodeAffinity("test-pod", "disk", "ssd", 100))
+		for _, node := range scheduler.nodes {
+			if node.Name == "node1" && node.AllocatedPod != 1 {
+				t.Errorf("Expected pod on the most allocated node")
+			}
+		}
+	})
+}
+
+func TestAddRunningPod(t *testing.T) {
+	nodes := []shared.KubernetesNode{
+		{Name: "node1", VCores: 1, Memory: 1, MaxPodCount: 10},
+		{Name: "node2", VCores: 4, Memory: 8, MaxPodCount: 10},
+	}
+	scheduler := New(nodes)
+
+	// the pod is already running, it is kept on its node even past the headroom
+	if !scheduler.AddRunningPod(createRunningPod("etcd", "kube-system", "node1", 1, 1024)) {
+		t.Fatalf("Failed to place running pod")
+	}
+	if scheduler.nodes[0].AllocatedPod != 1 || scheduler.nodes[0].AllocatedCPU != 1 {
+		t.Errorf("Running pod not placed on its node")
+	}
+	if scheduler.AddRunningPod(createRunningPod("other", "kube-system", "node3", 1, 1024)) {
+		t.Errorf("Placed a pod of a node that is not simulated")
+	}
+}
+
+func TestCanRemoveNodeWithNodeBoundPods(t *testing.T) {
+	nodes := []shared.KubernetesNode{
+		{Name: "node1", VCores: 4, Memory: 8, MaxPodCount: 10},
+		{Name: "node2", VCores: 4, Memory: 8, MaxPodCount: 10},
+	}
+	scheduler := New(nodes)
+
+	controller := true
+	daemonPod := createRunningPod("kube-proxy", "kube-system", "node1", 3, 512)
+	daemonPod.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "kube-proxy", Controller: &controller}}
+	scheduler.AddRunningPod(daemonPod)
+	scheduler.AddRunningPod(createStaticPod("etcd-node1", "node1", 3, 512))
+	scheduler.AddRunningPod(createRunningPod("coredns", "kube-system", "node2", 3, 512))
+
+	canRemove, err := scheduler.CanRemoveNode("node1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !canRemove {
+		t.Errorf("Expected node with only static and DaemonSet pods to be removable")
+	}
+
+	canRemove, err = scheduler.CanRemoveNode("node2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if canRemove {
+		t.Errorf("Expected node to be kept, its pod doesn't fit on the other node")
+	}
+}
+
+func TestStatefulSetVolumeZones(t *testing.T) {
+	zone := func(name string) map[string]string {
+		return map[string]string{v13.LabelTopologyZone: name}
+	}
+
+	t.Run("Replicas stay in the zone of their volume", func(t *testing.T) {
+		scheduler := New([]shared.KubernetesNode{
+			{Name: "b-1", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: zone("b")},
+			{Name: "a-1", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: zone("a")},
+		})
+		scheduler.AddVolumeZone("default", "data-db-0", "a")
+		scheduler.AddVolumeZone("default", "data-db-1", "b")
+
+		ok, reason := scheduler.AddStatefulSet(createStatefulSetWithVolumeClaims("db", "default", 2, 1, "data"))
+		if !ok {
+			t.Fatalf("Failed to add statefulset: %s", reason)
+		}
+		for _, node := range scheduler.nodes {
+			if len(node.Pods) != 1 {
+				t.Errorf("Expected one replica on node %s, got %d", node.Name, len(node.Pods))
+			}
+		}
+	})
+
+	t.Run("Replicas can't move out of the zone of their volume", func(t *testing.T) {
+		scheduler := New([]shared.KubernetesNode{
+			{Name: "a-1", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: zone("a")},
+			{Name: "b-1", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: zone("b")},
+		})
+		scheduler.AddVolumeZone("default", "data-db-0", "a")
+		scheduler.AddStatefulSet(createStatefulSetWithVolumeClaims("db", "default", 1, 1, "data"))
+
+		canRemove, err := scheduler.CanRemoveNode("a-1")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if canRemove {
+			t.Error("Expected a-1 to be kept for the volume of db-0")
+		}
+	})
+
+	t.Run("Pods using a zonal claim stay in its zone", func(t *testing.T) {
+		scheduler := New([]shared.KubernetesNode{
+			{Name: "a-1", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: zone("a")},
+			{Name: "b-1", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: zone("b")},
+		})
+		scheduler.AddVolumeZone("default", "uploads", "b")
+		pod := createPod("app", 1, 128)
+		pod.Namespace = "default"
+		pod.Spec.Volumes = []v13.Volume{{
+			Name:         "uploads",
+			VolumeSource: v13.VolumeSource{PersistentVolumeClaim: &v13.PersistentVolumeClaimVolumeSource{ClaimName: "uploads"}},
+		}}
+
+		ok, reason := scheduler.AddPod(pod)
+		if !ok {
+			t.Fatalf("Failed to add pod: %s", reason)
+		}
+		for _, node := range scheduler.nodes {
+			if node.Name == "b-1" && len(node.Pods) != 1 {
+				t.Errorf("Expected the pod on b-1, got %d pods there", len(node.Pods))
+			}
+		}
+	})
+	t.Run("Nodes with the legacy zone label", func(t *testing.T) {
+		legacyZone := func(name string) map[string]string {
+			return map[string]string{v13.LabelFailureDomainBetaZone: name}
+		}
+		scheduler := New([]shared.KubernetesNode{
+			{Name: "b-1", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: legacyZone("b")},
+			{Name: "a-1", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: legacyZone("a")},
+			{Name: "a-2", VCores: 4, Memory: 8, MaxPodCount: 110, Labels: zone("a")},
+		})
+		scheduler.AddVolumeZone("default", "data-db-0", "a")
+		scheduler.AddVolumeZone("default", "data-db-1", "a")
+
+		ok, reason := scheduler.AddStatefulSet(createStatefulSetWithVolumeClaims("db", "default", 2, 3, "data"))
+		if !ok {
+			t.Fatalf("Failed to add statefulset: %s", reason)
+		}
+		for _, node := range scheduler.nodes {
+			if node.Name == "b-1" && len(node.Pods) != 0 {
+				t.Errorf("Expected no replica on b-1, got %d", len(node.Pods))
+			}
+			if node.Name != "b-1" && len(node.Pods) != 1 {
+				t.Errorf("Expected one replica on node %s, got %d", node.Name, len(node.Pods))
+			}
+		}
+	})
+}
@@ -180,3 +180,119 @@ func createDaemonSetWithTolerations(name string, tolerations []v13.Toleration) v
 		},
 	}
 }
+
+func createPodTemplate(namespace string, labels map[string]string) v13.PodTemplateSpec {
+	return v13.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: createPod("app", 0.1, 128).Spec,
+	}
+}
+
+func createPodWithInitContainers(name string, cpuRequest float64, memoryRequestMB float64, initContainers ...v13.Container) v13.Pod {
+	pod := createPod(name, cpuRequest, memoryRequestMB)
+	pod.Spec.InitContainers = initContainers
+	return pod
+}
+
+func createInitContainer(name string, cpuRequest float64, memoryRequestMB float64, sidecar bool) v13.Container {
+	container := createPod(name, cpuRequest, memoryRequestMB).Spec.Containers[0]
+	if sidecar {
+		restartPolicy := v13.ContainerRestartPolicyAlways
+		container.RestartPolicy = &restartPolicy
+	}
+	return container
+}
+
+func createPodWithTopologySpread(name string, labels map[string]string, topologyKey string, maxSkew int32, whenUnsatisfiable v13.UnsatisfiableConstraintAction) v13.Pod {
+	pod := createPodWithLabels(name, labels)
+	pod.Spec.TopologySpreadConstraints = []v13.TopologySpreadConstraint{
+		{
+			MaxSkew:           maxSkew,
+			TopologyKey:       topologyKey,
+			WhenUnsatisfiable: whenUnsatisfiable,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+		},
+	}
+	return pod
+}
+
+func createPodWithPreferredNodeAffinity(name string, key string, value string, weight int32) v13.Pod {
+	return v13.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v13.PodSpec{
+			Affinity: &v13.Affinity{
+				NodeAffinity: &v13.NodeAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []v13.PreferredSchedulingTerm{
+						{
+							Weight: weight,
+							Preference: v13.NodeSelectorTerm{
+								MatchExpressions: []v13.NodeSelectorRequirement{
+									{
+										Key:      key,
+										Operator: v13.NodeSelectorOpIn,
+										Values:   []string{value},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func createPodWithPreferredPodAntiAffinity(name string, labels map[string]string, topologyKey string, weight int32) v13.Pod {
+	pod := createPodWithLabels(name, labels)
+	pod.Spec.Affinity = &v13.Affinity{
+		PodAntiAffinity: &v13.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []v13.WeightedPodAffinityTerm{
+				{
+					Weight: weight,
+					PodAffinityTerm: v13.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{
+							MatchLabels: labels,
+						},
+						TopologyKey: topologyKey,
+					},
+				},
+			},
+		},
+	}
+	return pod
+}
+
+func createRunningPod(name string, namespace string, nodeName string, cpuRequest float64, memoryRequestMB float64) v13.Pod {
+	pod := createPod(name, cpuRequest, memoryRequestMB)
+	pod.ObjectMeta = metav1.ObjectMeta{Name: name, Namespace: namespace}
+	pod.Spec.NodeName = nodeName
+	pod.Status.Phase = v13.PodRunning
+	return pod
+}
+
+func createStaticPod(name string, nodeName string, cpuRequest float64, memoryRequestMB float64) v13.Pod {
+	pod := createRunningPod(name, "kube-system", nodeName, cpuRequest, memoryRequestMB)
+	pod.Annotations = map[string]string{v13.MirrorPodAnnotationKey: "mirror"}
+	return pod
+}
+
+func createStatefulSetWithVolumeClaims(name string, namespace string, replicas int32, cpuRequest float64, claimTemplates ...string) v1.StatefulSet {
+	statefulSet := v1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1.StatefulSetSpec{
+			Replicas: &replicas,
+			Template: v13.PodTemplateSpec{Spec: createPod(name, cpuRequest, 128).Spec},
+		},
+	}
+	for _, claimTemplate := range claimTemplates {
+		statefulSet.Spec.VolumeClaimTemplates = append(statefulSet.Spec.VolumeClaimTemplates, v13.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: claimTemplate},
+		})
+	}
+	return statefulSet
+}
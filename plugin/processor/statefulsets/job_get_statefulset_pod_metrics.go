@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
 	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
-	"time"
 )
 
 type GetStatefulsetPodMetricsJob struct {
@@ -35,7 +34,11 @@ func (j *GetStatefulsetPodMetricsJob) Run(ctx context.Context) error {
 		return errors.New("statefulset not found in the items list")
 	}
 
-	cpuUsageWithHistory, err := j.processor.prometheusProvider.GetCpuMetricsForPodOwnerPrefix(ctx, statefulset.Namespace, statefulset.Statefulset.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeIncremental)
+	if err := kaytuPrometheus.WaitForHistory(ctx, j.processor.metricsProvider); err != nil {
+		return err
+	}
+
+	cpuUsageWithHistory, err := j.processor.metricsProvider.GetCpuMetricsForPodOwnerPrefix(ctx, statefulset.Namespace, kaytuPrometheus.PodOwnerKindStatefulSet, statefulset.Statefulset.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeIncremental)
 	if err != nil {
 		return err
 	}
@@ -53,7 +56,7 @@ func (j *GetStatefulsetPodMetricsJob) Run(ctx context.Context) error {
 		}
 	}
 
-	cpuThrottlingWithHistory, err := j.processor.prometheusProvider.GetCpuThrottlingMetricsForPodOwnerPrefix(ctx, statefulset.Namespace, statefulset.Statefulset.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeIncremental)
+	cpuThrottlingWithHistory, err := j.processor.metricsProvider.GetCpuThrottlingMetricsForPodOwnerPrefix(ctx, statefulset.Namespace, kaytuPrometheus.PodOwnerKindStatefulSet, statefulset.Statefulset.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeIncremental)
 	if err != nil {
 		return err
 	}
@@ -71,7 +74,7 @@ func (j *GetStatefulsetPodMetricsJob) Run(ctx context.Context) error {
 		}
 	}
 
-	memoryUsageWithHistory, err := j.processor.prometheusProvider.GetMemoryMetricsForPodOwnerPrefix(ctx, statefulset.Namespace, statefulset.Statefulset.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeIncremental)
+	memoryUsageWithHistory, err := j.processor.metricsProvider.GetMemoryMetricsForPodOwnerPrefix(ctx, statefulset.Namespace, kaytuPrometheus.PodOwnerKindStatefulSet, statefulset.Statefulset.Name, j.processor.observabilityDays, kaytuPrometheus.PodSuffixModeIncremental)
 	if err != nil {
 		return err
 	}
@@ -90,7 +93,8 @@ func (j *GetStatefulsetPodMetricsJob) Run(ctx context.Context) error {
 	}
 
 	statefulset.LazyLoadingEnabled = false
-	earliest := time.Now()
+	now := j.processor.kubernetesProvider.Now()
+	earliest := now
 	for _, pm := range []map[string]map[string][]kaytuPrometheus.PromDatapoint{cpuUsageWithHistory, cpuThrottlingWithHistory, memoryUsageWithHistory} {
 		for _, kvs := range pm {
 			for _, v := range kvs {
@@ -102,7 +106,11 @@ func (j *GetStatefulsetPodMetricsJob) Run(ctx context.Context) error {
 			}
 		}
 	}
-	statefulset.ObservabilityDuration = time.Now().Sub(earliest)
+	statefulset.ObservabilityDuration = now.Sub(earliest)
+	if reason := kaytuPrometheus.InsufficientHistory(j.processor.metricsProvider, statefulset.Metrics["cpu_usage"]); reason != "" {
+		statefulset.Skipped = true
+		statefulset.SkipReason = reason
+	}
 
 	j.processor.items.Set(statefulset.GetID(), statefulset)
 	j.processor.publishOptimizationItem(statefulset.ToOptimizationItem())
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/preferences"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	appv1 "k8s.io/api/apps/v1"
 )
 
 type ListStatefulsetsForNamespaceJob struct {
@@ -35,9 +37,22 @@ func (j *ListStatefulsetsForNamespaceJob) Run(ctx context.Context) error {
 		return err
 	}
 
+	hpas, err := j.processor.kubernetesProvider.ListHorizontalPodAutoscalersInNamespace(ctx, j.namespace)
+	if err != nil {
+		// autoscalers only refine the estimates, the statefulsets are analyzed without them
+		fmt.Println("failed to list horizontal pod autoscalers due to", err)
+	}
+
+	vpas, err := j.processor.kubernetesProvider.ListVerticalPodAutoscalersInNamespace(ctx, j.namespace)
+	if err != nil {
+		fmt.Println("failed to list vertical pod autoscalers due to", err)
+	}
+
 	for _, statefulset := range statefulsets {
 		item := StatefulsetItem{
 			Statefulset:         statefulset,
+			Autoscaler:          shared.FindHorizontalPodAutoscaler(hpas, "StatefulSet", statefulset.Name),
+			VerticalAutoscaler:  kaytuKubernetes.FindVerticalPodAutoscaler(vpas, appv1.GroupName, "StatefulSet", statefulset.Name),
 			Namespace:           j.namespace,
 			OptimizationLoading: true,
 			Preferences:         preferences.DefaultKubernetesPreferences,
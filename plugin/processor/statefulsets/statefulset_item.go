@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
 	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
 	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 	appv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	"log"
 	"math"
@@ -26,6 +28,9 @@ type StatefulsetItem struct {
 	LazyLoadingEnabled    bool
 	SkipReason            string
 	Metrics               map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint // Metric -> Pod -> Container -> Datapoints
+	Autoscaler            *autoscalingv2.HorizontalPodAutoscaler
+	VerticalAutoscaler    *kaytuKubernetes.VerticalPodAutoscaler
+	LimitRangeNotes       map[string]string // container -> how its recommendation was adjusted to the namespace LimitRanges
 	Wastage               *golang2.KubernetesStatefulsetOptimizationResponse
 	Nodes                 []shared.KubernetesNode
 	ObservabilityDuration time.Duration
@@ -38,11 +43,19 @@ func (i StatefulsetItem) GetID() string {
 	return fmt.Sprintf("appv1.statefulset/%s/%s", i.Statefulset.Namespace, i.Statefulset.Name)
 }
 
+// HPAEstimate is what the autoscaler of the statefulset is expected to do once the recommendation is applied.
+func (i StatefulsetItem) HPAEstimate() (shared.HPAEstimate, bool) {
+	if i.Wastage == nil || i.Wastage.Rightsizing == nil || i.Statefulset.Spec.Replicas == nil {
+		return shared.HPAEstimate{}, false
+	}
+	return shared.EstimateHPAReplicas(i.Autoscaler, *i.Statefulset.Spec.Replicas, i.Statefulset.Spec.Template.Spec, i.Wastage.Rightsizing.ContainerResizing, i.Metrics)
+}
+
 func (i StatefulsetItem) Devices() ([]*golang.ChartRow, map[string]*golang.Properties) {
 	var rows []*golang.ChartRow
 	props := make(map[string]*golang.Properties)
 
-	for _, container := range i.Statefulset.Spec.Template.Spec.Containers {
+	for _, container := range shared.PodContainers(i.Statefulset.Spec.Template.Spec) {
 		var rightSizing *golang2.KubernetesContainerRightsizingRecommendation
 		if i.Wastage != nil && i.Wastage.Rightsizing != nil {
 			for _, c := range i.Wastage.Rightsizing.ContainerResizing {
@@ -52,7 +65,9 @@ func (i StatefulsetItem) Devices() ([]*golang.ChartRow, map[string]*golang.Prope
 			}
 		}
 
-		row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Statefulset.Namespace, i.Statefulset.Name, nil, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Preferences, i.ObservabilityDuration)
+		row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Statefulset.Namespace, i.Statefulset.Name, nil, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Metrics["cpu_throttling"], i.Preferences, i.ObservabilityDuration)
+		shared.AddLimitRangeProperty(properties, i.LimitRangeNotes[container.Name])
+		shared.AddVerticalPodAutoscalerProperties(properties, i.VerticalAutoscaler, container.Name)
 		rows = append(rows, row)
 		props[row.RowId] = properties
 	}
@@ -64,7 +79,7 @@ func (i StatefulsetItem) Devices() ([]*golang.ChartRow, map[string]*golang.Prope
 		if i.Wastage != nil && i.Wastage.Rightsizing != nil {
 			podRs, podRsFound = i.Wastage.Rightsizing.PodContainerResizing[pod.Name]
 		}
-		for _, container := range pod.Spec.Containers {
+		for _, container := range shared.PodContainers(pod.Spec) {
 			var rightSizing *golang2.KubernetesContainerRightsizingRecommendation
 			if i.Wastage != nil && podRsFound {
 				for _, c := range podRs.ContainerResizing {
@@ -74,7 +89,8 @@ func (i StatefulsetItem) Devices() ([]*golang.ChartRow, map[string]*golang.Prope
 				}
 			}
 
-			row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Statefulset.Namespace, i.Statefulset.Name, &pod.Name, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Preferences, i.ObservabilityDuration)
+			row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Statefulset.Namespace, i.Statefulset.Name, &pod.Name, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Metrics["cpu_throttling"], i.Preferences, i.ObservabilityDuration)
+			shared.AddLimitRangeProperty(properties, i.LimitRangeNotes[container.Name])
 			rows = append(rows, row)
 			props[row.RowId] = properties
 		}
@@ -132,6 +148,10 @@ func (i StatefulsetItem) ToOptimizationItem() *golang.ChartOptimizationItem {
 	} else if i.OptimizationLoading {
 		status = "loading"
 	}
+	description := ""
+	if estimate, ok := i.HPAEstimate(); ok {
+		description = estimate.String()
+	}
 
 	metrics := i.Metrics
 	i.Metrics = nil
@@ -182,7 +202,7 @@ func (i StatefulsetItem) ToOptimizationItem() *golang.ChartOptimizationItem {
 		Skipped:            i.Skipped,
 		SkipReason:         nil,
 		LazyLoadingEnabled: i.LazyLoadingEnabled,
-		Description:        "", // TODO update
+		Description:        description,
 		DevicesChartRows:   deviceRows,
 		DevicesProperties:  deviceProps,
 	}
@@ -0,0 +1,93 @@
+package workloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
+)
+
+type GetWorkloadPodMetricsJob struct {
+	processor *Processor
+	itemId    string
+}
+
+func NewGetWorkloadPodMetricsJob(processor *Processor, itemId string) *GetWorkloadPodMetricsJob {
+	return &GetWorkloadPodMetricsJob{
+		processor: processor,
+		itemId:    itemId,
+	}
+}
+func (j *GetWorkloadPodMetricsJob) Properties() sdk.JobProperties {
+	return sdk.JobProperties{
+		ID:          fmt.Sprintf("get_workload_pod_metrics_for_%s", j.itemId),
+		Description: fmt.Sprintf("Getting metrics for %s (Kubernetes Workloads)", j.itemId),
+		MaxRetry:    5,
+	}
+}
+
+// Run queries the metrics pod by pod, custom controllers name their pods in no way the owner prefix queries can rely on.
+func (j *GetWorkloadPodMetricsJob) Run(ctx context.Context) error {
+	workload, ok := j.processor.items.Get(j.itemId)
+	if !ok {
+		return errors.New("workload not found in the items list")
+	}
+
+	if err := kaytuPrometheus.WaitForHistory(ctx, j.processor.metricsProvider); err != nil {
+		return err
+	}
+
+	workload.Metrics = make(map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint)
+	workload.Metrics["cpu_usage"] = make(map[string]map[string][]kaytuPrometheus.PromDatapoint)
+	workload.Metrics["cpu_throttling"] = make(map[string]map[string][]kaytuPrometheus.PromDatapoint)
+	workload.Metrics["memory_usage"] = make(map[string]map[string][]kaytuPrometheus.PromDatapoint)
+	for _, pod := range workload.Pods {
+		cpuUsage, err := j.processor.metricsProvider.GetCpuMetricsForPod(ctx, pod.Namespace, pod.Name, j.processor.observabilityDays)
+		if err != nil {
+			return err
+		}
+		workload.Metrics["cpu_usage"][pod.Name] = cpuUsage
+
+		cpuThrottling, err := j.processor.metricsProvider.GetCpuThrottlingMetricsForPod(ctx, pod.Namespace, pod.Name, j.processor.observabilityDays)
+		if err != nil {
+			return err
+		}
+		workload.Metrics["cpu_throttling"][pod.Name] = cpuThrottling
+
+		memoryUsage, err := j.processor.metricsProvider.GetMemoryMetricsForPod(ctx, pod.Namespace, pod.Name, j.processor.observabilityDays)
+		if err != nil {
+			return err
+		}
+		workload.Metrics["memory_usage"][pod.Name] = memoryUsage
+	}
+
+	workload.LazyLoadingEnabled = false
+	now := j.processor.kubernetesProvider.Now()
+	earliest := now
+	for _, pm := range workload.Metrics {
+		for _, kvs := range pm {
+			for _, v := range kvs {
+				for _, m := range v {
+					if m.Timestamp.Before(earliest) {
+						earliest = m.Timestamp
+					}
+				}
+			}
+		}
+	}
+	workload.ObservabilityDuration = now.Sub(earliest)
+	if reason := kaytuPrometheus.InsufficientHistory(j.processor.metricsProvider, workload.Metrics["cpu_usage"]); reason != "" {
+		workload.Skipped = true
+		workload.SkipReason = reason
+	}
+
+	j.processor.items.Set(workload.GetID(), workload)
+	j.processor.publishOptimizationItem(workload.ToOptimizationItem())
+	j.processor.UpdateSummary(workload.GetID())
+
+	if !workload.Skipped {
+		j.processor.jobQueue.Push(NewOptimizeWorkloadJob(j.processor, workload.GetID()))
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package workloads
+
+import (
+	"context"
+	"fmt"
+	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/preferences"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
+)
+
+// builtInControllers have processors of their own, pods under them are never grouped here.
+var builtInControllers = map[schema.GroupKind]bool{
+	{Group: "apps", Kind: "Deployment"}:  true,
+	{Group: "apps", Kind: "StatefulSet"}: true,
+	{Group: "apps", Kind: "DaemonSet"}:   true,
+	{Group: "batch", Kind: "Job"}:        true,
+	{Group: "batch", Kind: "CronJob"}:    true,
+	// static pods are run by the kubelet of their node, the pods processor covers them
+	{Group: "", Kind: "Node"}: true,
+}
+
+type ListWorkloadsForNamespaceJob struct {
+	processor *Processor
+	namespace string
+	nodes     []shared.KubernetesNode
+}
+
+func NewListWorkloadsForNamespaceJob(processor *Processor, namespace string, nodes []shared.KubernetesNode) *ListWorkloadsForNamespaceJob {
+	return &ListWorkloadsForNamespaceJob{
+		processor: processor,
+		namespace: namespace,
+		nodes:     nodes,
+	}
+}
+
+func (j *ListWorkloadsForNamespaceJob) Properties() sdk.JobProperties {
+	return sdk.JobProperties{
+		ID:          fmt.Sprintf("list_workloads_for_namespace_kubernetes_%s", j.namespace),
+		Description: fmt.Sprintf("Listing all pods in namespace %s (Kubernetes Workloads)", j.namespace),
+		MaxRetry:    0,
+	}
+}
+
+func isBuiltIn(ref metav1.OwnerReference) bool {
+	gvk := schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind)
+	return builtInControllers[gvk.GroupKind()]
+}
+
+func (j *ListWorkloadsForNamespaceJob) Run(ctx context.Context) error {
+	pods, err := j.processor.kubernetesProvider.ListPodsInNamespace(ctx, j.namespace, j.processor.selector, false)
+	if err != nil {
+		return err
+	}
+
+	vpas, err := j.processor.kubernetesProvider.ListVerticalPodAutoscalersInNamespace(ctx, j.namespace)
+	if err != nil {
+		fmt.Println("failed to list vertical pod autoscalers due to", err)
+	}
+
+	controllers := make(map[types.UID]kaytuKubernetes.Controller)
+	controllerPods := make(map[types.UID][]corev1.Pod)
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		// pods run directly by a built-in controller are covered by its processor whatever owns the controller
+		ref := metav1.GetControllerOf(&pod)
+		if ref == nil || isBuiltIn(*ref) {
+			continue
+		}
+
+		controller := j.processor.kubernetesProvider.ResolveController(ctx, pod)
+		if controller == nil || isBuiltIn(metav1.OwnerReference{APIVersion: controller.APIVersion, Kind: controller.Kind}) {
+			continue
+		}
+		controllers[controller.UID] = *controller
+		controllerPods[controller.UID] = append(controllerPods[controller.UID], pod)
+	}
+
+	var uids []types.UID
+	for uid := range controllers {
+		uids = append(uids, uid)
+	}
+	sort.Slice(uids, func(a, b int) bool {
+		return controllers[uids[a]].Name < controllers[uids[b]].Name
+	})
+
+	for _, uid := range uids {
+		controller := controllers[uid]
+		item := WorkloadItem{
+			Controller:          controller,
+			Pods:                controllerPods[uid],
+			VerticalAutoscaler:  kaytuKubernetes.FindVerticalPodAutoscaler(vpas, controller.GroupVersionKind().Group, controller.Kind, controller.Name),
+			Namespace:           j.namespace,
+			OptimizationLoading: true,
+			Preferences:         preferences.DefaultKubernetesPreferences,
+			Skipped:             false,
+			LazyLoadingEnabled:  false,
+			Nodes:               j.nodes,
+		}
+
+		template, err := controller.PodTemplate(j.processor.templatePaths)
+		if err != nil {
+			fmt.Println(err)
+		}
+		if template != nil {
+			item.Template = *template
+		} else {
+			// the controller could not be read or keeps its template elsewhere, its pods are the best template left
+			item.Template = corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: item.Pods[0].Labels},
+				Spec:       *item.Pods[0].Spec.DeepCopy(),
+			}
+		}
+
+		if j.processor.nodeSelector != "" {
+			if !shared.PodsInNodes(item.Pods, item.Nodes) {
+				item.Skipped = true
+				item.SkipReason = "not in selected nodes"
+			}
+		}
+		j.processor.lazyloadCounter.Add(1)
+		if j.processor.lazyloadCounter.Load() > uint32(j.processor.configuration.KubernetesLazyLoad) {
+			item.LazyLoadingEnabled = true
+			item.OptimizationLoading = false
+		}
+		j.processor.items.Set(item.GetID(), item)
+		j.processor.publishOptimizationItem(item.ToOptimizationItem())
+		j.processor.UpdateSummary(item.GetID())
+
+		if item.LazyLoadingEnabled || !item.OptimizationLoading || item.Skipped {
+			continue
+		}
+		j.processor.jobQueue.Push(NewGetWorkloadPodMetricsJob(j.processor, item.GetID()))
+	}
+
+	return nil
+}
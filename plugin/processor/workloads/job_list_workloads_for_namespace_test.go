@@ -0,0 +1,186 @@
+package workloads
+
+import (
+	"context"
+	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	"github.com/kaytu-io/kaytu/pkg/utils"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/kaytu"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/nodes"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"sort"
+	"sync/atomic"
+	"testing"
+)
+
+var (
+	rolloutGVK    = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}
+	cloneSetGVK   = schema.GroupVersionKind{Group: "apps.kruise.io", Version: "v1alpha1", Kind: "CloneSet"}
+	replicaSetGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}
+	deploymentGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	daemonSetGVK  = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "DaemonSet"}
+	unknownGVK    = schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Unknown"}
+)
+
+func controllerRef(gvk schema.GroupVersionKind, name string, uid types.UID) metav1.OwnerReference {
+	controller := true
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	return metav1.OwnerReference{APIVersion: apiVersion, Kind: kind, Name: name, UID: uid, Controller: &controller}
+}
+
+func ownerObject(gvk schema.GroupVersionKind, name string, uid types.UID, owner *metav1.OwnerReference, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace("shop")
+	obj.SetName(name)
+	obj.SetUID(uid)
+	if owner != nil {
+		obj.SetOwnerReferences([]metav1.OwnerReference{*owner})
+	}
+	return obj
+}
+
+func podTemplate(container string) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": container}},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": container, "image": container + ":1"}},
+		},
+	}
+}
+
+func testPod(name string, phase corev1.PodPhase, owner *metav1.OwnerReference) runtime.Object {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name, Labels: map[string]string{"pod": name}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "worker", Image: "worker:1"}}},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+	if owner != nil {
+		pod.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+	return pod
+}
+
+func TestListWorkloadsForNamespace(t *testing.T) {
+	rolloutRef := controllerRef(rolloutGVK, "web", "r1")
+	webReplicaSetRef := controllerRef(replicaSetGVK, "web-6d4f", "rs1")
+	deploymentRef := controllerRef(deploymentGVK, "api", "d1")
+	apiReplicaSetRef := controllerRef(replicaSetGVK, "api-7c9b", "rs2")
+	cloneSetRef := controllerRef(cloneSetGVK, "cache", "c1")
+	unknownRef := controllerRef(unknownGVK, "batch", "u1")
+	daemonSetRef := controllerRef(daemonSetGVK, "agent", "ds1")
+	nodeRef := controllerRef(schema.GroupVersionKind{Version: "v1", Kind: "Node"}, "node-1", "n1")
+
+	vpa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"targetRef": map[string]interface{}{"apiVersion": "argoproj.io/v1alpha1", "kind": "Rollout", "name": "web"},
+		},
+	}}
+	vpa.SetGroupVersionKind(kaytuKubernetes.VerticalPodAutoscalerGroupVersion.WithKind("VerticalPodAutoscaler"))
+	vpa.SetNamespace("shop")
+	vpa.SetName("web-vpa")
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			kaytuKubernetes.VerticalPodAutoscalerGroupVersion.WithResource("verticalpodautoscalers"): "VerticalPodAutoscalerList",
+		},
+		vpa,
+		ownerObject(rolloutGVK, "web", "r1", nil, map[string]interface{}{"replicas": int64(3), "template": podTemplate("web")}),
+		ownerObject(replicaSetGVK, "web-6d4f", "rs1", &rolloutRef, nil),
+		ownerObject(deploymentGVK, "api", "d1", nil, map[string]interface{}{"template": podTemplate("api")}),
+		ownerObject(replicaSetGVK, "api-7c9b", "rs2", &deploymentRef, nil),
+		ownerObject(cloneSetGVK, "cache", "c1", nil, map[string]interface{}{"workload": map[string]interface{}{"template": podTemplate("cache")}}),
+	)
+	restMapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range []schema.GroupVersionKind{rolloutGVK, cloneSetGVK, replicaSetGVK, deploymentGVK, daemonSetGVK, unknownGVK} {
+		restMapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	clientset := fake.NewSimpleClientset(
+		testPod("web-1", corev1.PodRunning, &webReplicaSetRef),
+		testPod("web-2", corev1.PodRunning, &webReplicaSetRef),
+		testPod("web-3", corev1.PodPending, &webReplicaSetRef),
+		testPod("api-1", corev1.PodRunning, &apiReplicaSetRef),
+		testPod("cache-1", corev1.PodRunning, &cloneSetRef),
+		testPod("batch-1", corev1.PodRunning, &unknownRef),
+		testPod("agent-1", corev1.PodRunning, &daemonSetRef),
+		testPod("kube-apiserver-node-1", corev1.PodRunning, &nodeRef),
+		testPod("bare", corev1.PodRunning, nil),
+	)
+
+	var published []string
+	processor := &Processor{
+		kubernetesProvider: kaytuKubernetes.NewKubernetesFromClients(clientset, dynamicClient, restMapper),
+		items:              utils.NewConcurrentMap[string, WorkloadItem](),
+		publishOptimizationItem: func(item *golang.ChartOptimizationItem) {
+			published = append(published, item.OverviewChartRow.GetRowId())
+		},
+		publishResultSummary:      func(*golang.ResultSummary) {},
+		publishResultSummaryTable: func(*golang.ResultSummaryTable) {},
+		lazyloadCounter:           &atomic.Uint32{},
+		// every item is lazy loaded, so no metrics jobs are queued
+		configuration: &kaytu.Configuration{KubernetesLazyLoad: 0},
+		templatePaths: map[schema.GroupVersionKind]string{cloneSetGVK: "spec.workload.template"},
+		summary:       utils.NewConcurrentMap[string, shared.ResourceSummary](),
+		nodeProcessor: &nodes.Processor{},
+	}
+
+	err := NewListWorkloadsForNamespaceJob(processor, "shop", nil).Run(context.Background())
+	assert.NoError(t, err)
+
+	var ids []string
+	processor.items.Range(func(id string, _ WorkloadItem) bool {
+		ids = append(ids, id)
+		return true
+	})
+	sort.Strings(ids)
+	assert.Equal(t, []string{"cloneset.apps.kruise.io/shop/cache", "rollout.argoproj.io/shop/web", "unknown.example.com/shop/batch"}, ids)
+	assert.ElementsMatch(t, ids, published)
+
+	tests := []struct {
+		id            string
+		wantPods      int
+		wantReplicas  int32
+		wantContainer string
+		wantVPA       string
+	}{
+		// the rollout is found through its replica set and keeps its own replica count
+		{id: "rollout.argoproj.io/shop/web", wantPods: 2, wantReplicas: 3, wantContainer: "web", wantVPA: "web-vpa"},
+		// the clone set keeps its template at the configured path
+		{id: "cloneset.apps.kruise.io/shop/cache", wantPods: 1, wantReplicas: 1, wantContainer: "cache"},
+		// the controller can't be read, its pod is the template
+		{id: "unknown.example.com/shop/batch", wantPods: 1, wantReplicas: 1, wantContainer: "worker"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			item, ok := processor.items.Get(tt.id)
+			if !assert.True(t, ok) {
+				return
+			}
+			assert.Len(t, item.Pods, tt.wantPods)
+			assert.Equal(t, tt.wantReplicas, item.Replicas())
+			if assert.Len(t, item.Template.Spec.Containers, 1) {
+				assert.Equal(t, tt.wantContainer, item.Template.Spec.Containers[0].Name)
+			}
+			if tt.wantVPA == "" {
+				assert.Nil(t, item.VerticalAutoscaler)
+			} else if assert.NotNil(t, item.VerticalAutoscaler) {
+				assert.Equal(t, tt.wantVPA, item.VerticalAutoscaler.Name)
+			}
+			assert.True(t, item.LazyLoadingEnabled)
+
+			deployment := item.TemplateDeployment()
+			assert.Equal(t, tt.wantReplicas, *deployment.Spec.Replicas)
+			assert.Equal(t, tt.id, deployment.Name)
+		})
+	}
+}
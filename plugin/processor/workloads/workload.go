@@ -0,0 +1,270 @@
+package workloads
+
+import (
+	"fmt"
+	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
+	"github.com/kaytu-io/kaytu/pkg/utils"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/kaytu"
+	kaytuAgent "github.com/opengovern/plugin-kubernetes-internal/plugin/kaytu-agent"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/nodes"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/simulation"
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+type Processor struct {
+	identification            map[string]string
+	kubernetesProvider        *kaytuKubernetes.Kubernetes
+	metricsProvider           kaytuPrometheus.MetricsProvider
+	items                     utils.ConcurrentMap[string, WorkloadItem]
+	publishOptimizationItem   func(item *golang.ChartOptimizationItem)
+	publishResultSummary      func(summary *golang.ResultSummary)
+	publishResultSummaryTable func(summary *golang.ResultSummaryTable)
+	kaytuAcccessToken         string
+	jobQueue                  *sdk.JobQueue
+	lazyloadCounter           *atomic.Uint32
+	configuration             *kaytu.Configuration
+	client                    golang2.OptimizationClient
+	kaytuClient               *kaytuAgent.KaytuAgent
+	namespace                 *string
+	selector                  string
+	nodeSelector              string
+	observabilityDays         int
+	requestTimeout            time.Duration
+	defaultPreferences        []*golang.PreferenceItem
+	schedulingSim             *simulation.SchedulerService
+	schedulingSimPrev         *simulation.SchedulerService
+	templatePaths             map[schema.GroupVersionKind]string
+
+	summary       utils.ConcurrentMap[string, shared.ResourceSummary]
+	nodeProcessor *nodes.Processor
+}
+
+func NewProcessor(processorConf shared.Configuration, nodeProcessor *nodes.Processor) *Processor {
+	r := &Processor{
+		identification:            processorConf.Identification,
+		kubernetesProvider:        processorConf.KubernetesProvider,
+		metricsProvider:           processorConf.MetricsProvider,
+		items:                     utils.NewConcurrentMap[string, WorkloadItem](),
+		publishOptimizationItem:   processorConf.PublishOptimizationItem,
+		publishResultSummary:      processorConf.PublishResultSummary,
+		publishResultSummaryTable: processorConf.PublishResultSummaryTable,
+		kaytuAcccessToken:         processorConf.KaytuAcccessToken,
+		jobQueue:                  processorConf.JobQueue,
+		lazyloadCounter:           processorConf.LazyloadCounter,
+		configuration:             processorConf.Configuration,
+		client:                    processorConf.Client,
+		kaytuClient:               processorConf.KaytuClient,
+		namespace:                 processorConf.Namespace,
+		selector:                  processorConf.Selector,
+		nodeSelector:              processorConf.NodeSelector,
+		observabilityDays:         processorConf.ObservabilityDays,
+		requestTimeout:            processorConf.RequestTimeout,
+		defaultPreferences:        processorConf.DefaultPreferences,
+		templatePaths:             processorConf.WorkloadTemplatePaths,
+		nodeProcessor:             nodeProcessor,
+
+		summary: utils.NewConcurrentMap[string, shared.ResourceSummary](),
+	}
+
+	// the kaytu agent reports no custom controllers, their pods stay with the pods processor in that mode
+	if !r.kaytuClient.IsEnabled() && r.kubernetesProvider.CanResolveControllers() {
+		r.jobQueue.Push(NewListAllNamespacesJob(r, nodeProcessor.GetKubernetesNodes()))
+	}
+	return r
+}
+
+func (m *Processor) ReEvaluate(id string, items []*golang.PreferenceItem) {
+	v, _ := m.items.Get(id)
+	v.Preferences = items
+	v.OptimizationLoading = true
+	m.items.Set(id, v)
+	v.LazyLoadingEnabled = false
+	m.publishOptimizationItem(v.ToOptimizationItem())
+	m.jobQueue.Push(NewOptimizeWorkloadJob(m, id))
+}
+
+func (m *Processor) ExportNonInteractive() *golang.NonInteractiveExport {
+	return &golang.NonInteractiveExport{
+		Csv: m.exportCsv(),
+	}
+}
+
+func (m *Processor) exportCsv() []*golang.CSVRow {
+	var rows []*golang.CSVRow
+	rows = append(rows, &golang.CSVRow{Row: shared.ExportCsvHeaders})
+	rows = append(rows, m.ExportCsvRows()...)
+	return rows
+}
+
+func (m *Processor) ExportCsvRows() []*golang.CSVRow {
+	return shared.ExportCsvRows(m.ExportWorkloads())
+}
+
+func (m *Processor) ExportWorkloads() []shared.ExportWorkload {
+	var ids []string
+	m.items.Range(func(id string, _ WorkloadItem) bool {
+		ids = append(ids, id)
+		return true
+	})
+	sort.Strings(ids)
+
+	var workloads []shared.ExportWorkload
+	for _, id := range ids {
+		item, ok := m.items.Get(id)
+		if !ok {
+			continue
+		}
+		var rightSizing []*golang2.KubernetesContainerRightsizingRecommendation
+		if item.Wastage != nil && item.Wastage.Rightsizing != nil {
+			rightSizing = item.Wastage.Rightsizing.ContainerResizing
+		}
+		workloads = append(workloads, shared.ExportWorkload{
+			Kind:                  item.Controller.Kind,
+			Namespace:             item.Controller.Namespace,
+			Name:                  item.Controller.Name,
+			Replicas:              item.Replicas(),
+			Containers:            shared.PodContainers(item.Template.Spec),
+			Rightsizing:           rightSizing,
+			Cost:                  item.Cost,
+			ObservabilityDuration: item.ObservabilityDuration,
+			Skipped:               item.Skipped,
+			SkipReason:            item.SkipReason,
+		})
+	}
+	return workloads
+}
+
+// ExportVerticalPodAutoscalers returns VPA manifests bounded by the recommendations of the optimized workloads.
+func (m *Processor) ExportVerticalPodAutoscalers() []*unstructured.Unstructured {
+	var ids []string
+	m.items.Range(func(id string, _ WorkloadItem) bool {
+		ids = append(ids, id)
+		return true
+	})
+	sort.Strings(ids)
+
+	var vpas []*unstructured.Unstructured
+	for _, id := range ids {
+		item, ok := m.items.Get(id)
+		if !ok || item.Wastage == nil || item.Wastage.Rightsizing == nil {
+			continue
+		}
+		manifest := shared.VerticalPodAutoscalerManifest(item.Controller.Namespace, autoscalingv1.CrossVersionObjectReference{
+			APIVersion: item.Controller.APIVersion,
+			Kind:       item.Controller.Kind,
+			Name:       item.Controller.Name,
+		}, item.VerticalAutoscaler, nil, item.Wastage.Rightsizing.ContainerResizing)
+		if manifest != nil {
+			vpas = append(vpas, manifest)
+		}
+	}
+	return vpas
+}
+
+func (m *Processor) GetSummaryMap() *utils.ConcurrentMap[string, shared.ResourceSummary] {
+	return &m.summary
+}
+
+func (m *Processor) UpdateSummary(itemId string) {
+	var removableNodes, removableNodesPrev []shared.KubernetesNode
+	i, ok := m.items.Get(itemId)
+	if ok && i.Wastage != nil {
+		cpuRequestChange, totalCpuRequest := 0.0, 0.0
+		cpuLimitChange, totalCpuLimit := 0.0, 0.0
+		memoryRequestChange, totalMemoryRequest := 0.0, 0.0
+		memoryLimitChange, totalMemoryLimit := 0.0, 0.0
+		for _, container := range i.Wastage.Rightsizing.ContainerResizing {
+			var pContainer shared.PodContainer
+			for _, podContainer := range shared.PodContainers(i.Template.Spec) {
+				if podContainer.Name == container.Name {
+					pContainer = podContainer
+				}
+			}
+			if pContainer.Type == shared.ContainerTypeInit {
+				// init containers are done before the pod runs, only the simulation accounts for them
+				continue
+			}
+			cpuRequest, cpuLimit, memoryRequest, memoryLimit := shared.GetContainerRequestLimits(pContainer.Container)
+			if container.Current != nil && container.Recommended != nil {
+				if cpuRequest != nil {
+					totalCpuRequest += container.Current.CpuRequest
+					cpuRequestChange += container.Recommended.CpuRequest - container.Current.CpuRequest
+				}
+				if cpuLimit != nil {
+					totalCpuLimit += container.Current.CpuLimit
+					cpuLimitChange += container.Recommended.CpuLimit - container.Current.CpuLimit
+				}
+				if memoryRequest != nil {
+					totalMemoryRequest += container.Current.MemoryRequest
+					memoryRequestChange += container.Recommended.MemoryRequest - container.Current.MemoryRequest
+				}
+				if memoryLimit != nil {
+					totalMemoryLimit += container.Current.MemoryLimit
+					memoryLimitChange += container.Recommended.MemoryLimit - container.Current.MemoryLimit
+				}
+			}
+		}
+
+		ds := shared.ResourceSummary{
+			Namespace:               i.Namespace,
+			ReplicaCount:            1,
+			CPURequestDownSizing:    min(0, cpuRequestChange),
+			CPURequestUpSizing:      max(0, cpuRequestChange),
+			TotalCPURequest:         totalCpuRequest,
+			CPULimitDownSizing:      min(0, cpuLimitChange),
+			CPULimitUpSizing:        max(0, cpuLimitChange),
+			TotalCPULimit:           totalCpuLimit,
+			MemoryRequestUpSizing:   max(0, memoryRequestChange),
+			MemoryRequestDownSizing: min(0, memoryRequestChange),
+			TotalMemoryRequest:      totalMemoryRequest,
+			MemoryLimitUpSizing:     max(0, memoryLimitChange),
+			MemoryLimitDownSizing:   min(0, memoryLimitChange),
+			TotalMemoryLimit:        totalMemoryLimit,
+		}
+		ds.ReplicaCount = i.Replicas()
+
+		m.summary.Set(i.GetID(), ds)
+		if m.schedulingSimPrev != nil {
+			m.schedulingSimPrev.AddDeployment(i.TemplateDeployment())
+			nodes, err := m.schedulingSimPrev.Simulate()
+			if err != nil {
+				fmt.Println("failed to simulate due to", err)
+			} else {
+				removableNodesPrev = nodes
+			}
+		}
+
+		if m.schedulingSim != nil {
+			deployment := i.TemplateDeployment()
+			shared.SetRecommendedResources(&deployment.Spec.Template.Spec, i.Wastage.Rightsizing.ContainerResizing)
+
+			m.schedulingSim.AddDeployment(deployment)
+			nodes, err := m.schedulingSim.Simulate()
+			if err != nil {
+				fmt.Println("failed to simulate due to", err)
+			} else {
+				removableNodes = nodes
+			}
+		}
+
+	}
+	rs, _ := shared.GetAggregatedResultsSummary(&m.summary)
+	m.publishResultSummary(rs)
+	rst, _ := shared.GetAggregatedResultsSummaryTable(&m.summary, m.nodeProcessor.GetKubernetesNodes(), removableNodes, removableNodesPrev, m.nodeProcessor.GetResourceQuotas())
+	m.publishResultSummaryTable(rst)
+}
+
+func (m *Processor) SetSchedulingSim(sim, simPrev *simulation.SchedulerService) {
+	m.schedulingSim = sim
+	m.schedulingSimPrev = simPrev
+}
@@ -0,0 +1,270 @@
+package workloads
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
+	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	appv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorkloadItem is the top-level controller of pods that none of the built-in workload processors cover,
+// e.g. an Argo Rollout or an OpenKruise CloneSet.
+type WorkloadItem struct {
+	Controller            kaytuKubernetes.Controller
+	Template              corev1.PodTemplateSpec
+	Pods                  []corev1.Pod
+	Namespace             string
+	OptimizationLoading   bool
+	Preferences           []*golang.PreferenceItem
+	Skipped               bool
+	LazyLoadingEnabled    bool
+	SkipReason            string
+	Metrics               map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint // Metric -> Pod -> Container -> Datapoints
+	VerticalAutoscaler    *kaytuKubernetes.VerticalPodAutoscaler
+	LimitRangeNotes       map[string]string // container -> how its recommendation was adjusted to the namespace LimitRanges
+	Wastage               *golang2.KubernetesDeploymentOptimizationResponse
+	Nodes                 []shared.KubernetesNode
+	ObservabilityDuration time.Duration
+	Cost                  float64
+	VCpuHoursInPeriod     map[string]map[string]float64 // Pod -> Container -> VCpuHours
+	MemoryGBHoursInPeriod map[string]map[string]float64 // Pod -> Container -> MemoryGBHours
+}
+
+func (i WorkloadItem) GetID() string {
+	return fmt.Sprintf("%s/%s/%s", strings.ToLower(i.Controller.GroupVersionKind().GroupKind().String()), i.Controller.Namespace, i.Controller.Name)
+}
+
+// Replicas is the replica count of the controller, or the number of its running pods when it has none.
+func (i WorkloadItem) Replicas() int32 {
+	if replicas, ok := i.Controller.Replicas(); ok {
+		return replicas
+	}
+	return int32(len(i.Pods))
+}
+
+// TemplateDeployment is a deployment running the controller's pods, used to place the workload in the scheduling simulation.
+func (i WorkloadItem) TemplateDeployment() appv1.Deployment {
+	replicas := i.Replicas()
+	return appv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      i.GetID(),
+			Namespace: i.Controller.Namespace,
+		},
+		Spec: appv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: *i.Template.DeepCopy(),
+		},
+	}
+}
+
+func (i WorkloadItem) Devices() ([]*golang.ChartRow, map[string]*golang.Properties) {
+	var rows []*golang.ChartRow
+	props := make(map[string]*golang.Properties)
+
+	for _, container := range shared.PodContainers(i.Template.Spec) {
+		var rightSizing *golang2.KubernetesContainerRightsizingRecommendation
+		if i.Wastage != nil && i.Wastage.Rightsizing != nil {
+			for _, c := range i.Wastage.Rightsizing.ContainerResizing {
+				if c.Name == container.Name {
+					rightSizing = c
+				}
+			}
+		}
+
+		row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Controller.Namespace, i.Controller.Name, nil, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Metrics["cpu_throttling"], i.Preferences, i.ObservabilityDuration)
+		shared.AddLimitRangeProperty(properties, i.LimitRangeNotes[container.Name])
+		shared.AddVerticalPodAutoscalerProperties(properties, i.VerticalAutoscaler, container.Name)
+		rows = append(rows, row)
+		props[row.RowId] = properties
+	}
+
+	for _, pod := range i.Pods {
+		pod := pod
+		var podRs *golang2.KubernetesPodRightsizingRecommendation
+		var podRsFound = false
+		if i.Wastage != nil && i.Wastage.Rightsizing != nil {
+			podRs, podRsFound = i.Wastage.Rightsizing.PodContainerResizing[pod.Name]
+		}
+		for _, container := range shared.PodContainers(pod.Spec) {
+			var rightSizing *golang2.KubernetesContainerRightsizingRecommendation
+			if i.Wastage != nil && podRsFound {
+				for _, c := range podRs.ContainerResizing {
+					if c.Name == container.Name {
+						rightSizing = c
+					}
+				}
+			}
+
+			row, properties := shared.GetContainerDeviceRowAndProperties(container, rightSizing, i.Controller.Namespace, i.Controller.Name, &pod.Name, i.VCpuHoursInPeriod, i.MemoryGBHoursInPeriod, i.Metrics["cpu_throttling"], i.Preferences, i.ObservabilityDuration)
+			shared.AddLimitRangeProperty(properties, i.LimitRangeNotes[container.Name])
+			rows = append(rows, row)
+			props[row.RowId] = properties
+		}
+	}
+
+	return rows, props
+}
+
+func (i WorkloadItem) ToOptimizationItem() *golang.ChartOptimizationItem {
+	var cpuRequest, cpuLimit, memoryRequest, memoryLimit *float64
+	cpuRequestNotConfigured, cpuLimitNotConfigured, memoryRequestNotConfigured, memoryLimitNotConfigured := false, false, false, false
+	for _, container := range i.Template.Spec.Containers {
+		cReq, cLim, mReq, mLim := shared.GetContainerRequestLimits(container)
+		if cReq != nil {
+			if cpuRequest != nil {
+				*cReq = *cpuRequest + *cReq
+			}
+			cpuRequest = cReq
+		} else {
+			cpuRequestNotConfigured = true
+		}
+		if cLim != nil {
+			if cpuLimit != nil {
+				*cLim = *cpuLimit + *cLim
+			}
+			cpuLimit = cLim
+		} else {
+			cpuLimitNotConfigured = true
+		}
+		if mReq != nil {
+			if memoryRequest != nil {
+				*mReq = *memoryRequest + *mReq
+			}
+			memoryRequest = mReq
+		} else {
+			memoryRequestNotConfigured = true
+		}
+		if mLim != nil {
+			if memoryLimit != nil {
+				*mLim = *memoryLimit + *mLim
+			}
+			memoryLimit = mLim
+		} else {
+			memoryLimitNotConfigured = true
+		}
+	}
+
+	deviceRows, deviceProps := i.Devices()
+
+	status := ""
+	if i.Skipped {
+		status = fmt.Sprintf("skipped - %s", i.SkipReason)
+	} else if i.LazyLoadingEnabled && !i.OptimizationLoading {
+		status = "press enter to load"
+	} else if i.OptimizationLoading {
+		status = "loading"
+	}
+
+	metrics := i.Metrics
+	i.Metrics = nil
+	cost := i.Cost
+	if math.IsNaN(cost) {
+		i.Cost = 0
+	}
+	kaytuJson, err := json.Marshal(i)
+	if err != nil {
+		log.Printf("failed to marshal kaytu json: %v", err)
+	}
+	i.Cost = cost
+	i.Metrics = metrics
+	oi := &golang.ChartOptimizationItem{
+		OverviewChartRow: &golang.ChartRow{
+			RowId: i.GetID(),
+			Values: map[string]*golang.ChartRowItem{
+				"x_kaytu_right_arrow": {
+					Value: "→",
+				},
+				"namespace": {
+					Value: i.Controller.Namespace,
+				},
+				"name": {
+					Value: i.Controller.Name,
+				},
+				"kubernetes_type": {
+					Value:     i.Controller.Kind,
+					SortValue: 7,
+				},
+				"x_kaytu_status": {
+					Value: status,
+				},
+				"x_kaytu_loading": {
+					Value: strconv.FormatBool(i.OptimizationLoading),
+				},
+				"x_kaytu_raw_json": {
+					Value: string(kaytuJson),
+				},
+				"pod_count": {
+					Value:     strconv.Itoa(len(i.Pods)),
+					SortValue: float64(len(i.Pods)),
+				},
+			},
+		},
+		Preferences:        i.Preferences,
+		Loading:            i.OptimizationLoading,
+		Skipped:            i.Skipped,
+		SkipReason:         nil,
+		LazyLoadingEnabled: i.LazyLoadingEnabled,
+		Description:        "", // TODO update
+		DevicesChartRows:   deviceRows,
+		DevicesProperties:  deviceProps,
+	}
+	if i.Pods == nil {
+		oi.OverviewChartRow.Values["pod_count"] = &golang.ChartRowItem{
+			Value: "N/A",
+		}
+	}
+	if i.SkipReason != "" {
+		oi.SkipReason = &wrapperspb.StringValue{Value: i.SkipReason}
+	}
+
+	if i.Wastage != nil {
+		cpuRequestChange := 0.0
+		cpuLimitChange := 0.0
+		memoryRequestChange := 0.0
+		memoryLimitChange := 0.0
+		for _, container := range i.Wastage.Rightsizing.ContainerResizing {
+			if container.Current != nil && container.Recommended != nil {
+				cpuRequestChange += container.Recommended.CpuRequest - container.Current.CpuRequest
+				cpuLimitChange += container.Recommended.CpuLimit - container.Current.CpuLimit
+				memoryRequestChange += container.Recommended.MemoryRequest - container.Current.MemoryRequest
+				memoryLimitChange += container.Recommended.MemoryLimit - container.Current.MemoryLimit
+			}
+		}
+		cpuRequestChange = cpuRequestChange * float64(i.Replicas())
+		cpuLimitChange = cpuLimitChange * float64(i.Replicas())
+		memoryRequestChange = memoryRequestChange * float64(i.Replicas())
+		memoryLimitChange = memoryLimitChange * float64(i.Replicas())
+
+		cpuRequestReductionString := shared.SprintfWithStyle("request: %+.2f core", cpuRequestChange, cpuRequestNotConfigured)
+		cpuLimitReductionString := shared.SprintfWithStyle("limit: %+.2f core", cpuLimitChange, cpuLimitNotConfigured)
+		memoryRequestReductionString := shared.SprintfWithStyle(fmt.Sprintf("request: %s", shared.SizeByte(memoryRequestChange, true)), memoryRequestChange, memoryRequestNotConfigured)
+		memoryLimitReductionString := shared.SprintfWithStyle(fmt.Sprintf("limit: %s", shared.SizeByte(memoryLimitChange, true)), memoryLimitChange, memoryLimitNotConfigured)
+
+		oi.OverviewChartRow.Values["cpu_change"] = &golang.ChartRowItem{
+			Value:     cpuRequestReductionString + ", " + cpuLimitReductionString,
+			SortValue: cpuRequestChange,
+		}
+		oi.OverviewChartRow.Values["memory_change"] = &golang.ChartRowItem{
+			Value:     memoryRequestReductionString + ", " + memoryLimitReductionString,
+			SortValue: memoryRequestChange,
+		}
+		oi.OverviewChartRow.Values["cost"] = &golang.ChartRowItem{
+			Value:     fmt.Sprintf("$%0.2f", i.Cost),
+			SortValue: i.Cost,
+		}
+	}
+
+	return oi
+}
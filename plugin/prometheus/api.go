@@ -2,19 +2,29 @@ package prometheus
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	promapi "github.com/prometheus/client_golang/api"
 	prometheus "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
+	"log"
 	"math"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	defaultScrapeInterval = time.Minute
+
+	DefaultQueryConcurrency = 4
+	// DefaultBulkQueryTimeout bounds a namespace-wide fetch over the whole observability window
+	DefaultBulkQueryTimeout = 10 * time.Minute
 )
 
 var (
@@ -37,6 +47,16 @@ type Prometheus struct {
 	client         promapi.Client
 	api            prometheus.API
 	scrapeInterval time.Duration
+
+	// ownerMetricsAvailable is set when kube-state-metrics series are present, so pods can be matched to their owner exactly
+	ownerMetricsAvailable bool
+
+	cache *Cache
+
+	namespaceResultsLock sync.Mutex
+	// namespaceResults holds the bulk query results by namespace then query, namespaceOrder is the namespaces from least to most recently used
+	namespaceResults map[string]map[string]*namespaceQueryResult
+	namespaceOrder   []string
 }
 
 type PromDimension interface {
@@ -67,6 +87,30 @@ type PromDatapoint struct {
 	Value     float64
 }
 
+// promDatapointJSON keeps the value as a string since json can't encode the NaNs Prometheus returns for 0/0.
+type promDatapointJSON struct {
+	Timestamp int64  `json:"t"`
+	Value     string `json:"v"`
+}
+
+func (d PromDatapoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(promDatapointJSON{
+		Timestamp: d.Timestamp.UnixMilli(),
+		Value:     strconv.FormatFloat(d.Value, 'g', -1, 64),
+	})
+}
+
+func (d *PromDatapoint) UnmarshalJSON(b []byte) error {
+	var v promDatapointJSON
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+	d.Timestamp = time.UnixMilli(v.Timestamp)
+	d.Value, err = strconv.ParseFloat(v.Value, 64)
+	return err
+}
+
 type PromDatapoints struct {
 	Values []PromDatapoint
 }
@@ -90,6 +134,9 @@ type PodSuffixMode int
 const (
 	PodSuffixModeRandom PodSuffixMode = iota
 	PodSuffixModeIncremental
+	// PodSuffixModeCronJob matches the pods of every job a cronjob spawned, <cronjob>-<scheduled time>-<random>.
+	// The dash before the random part is optional since long job names get truncated.
+	PodSuffixModeCronJob
 )
 
 func (p PodSuffixMode) Regex() string {
@@ -98,11 +145,22 @@ func (p PodSuffixMode) Regex() string {
 		return "[a-zA-Z0-9]{5}"
 	case PodSuffixModeIncremental:
 		return "[0-9]+"
+	case PodSuffixModeCronJob:
+		return "[0-9]+-?[a-zA-Z0-9]{5}"
 	default:
 		return ""
 	}
 }
 
+// Owner kinds as reported by the owner_kind label of kube_pod_owner.
+const (
+	PodOwnerKindReplicaSet  = "ReplicaSet"
+	PodOwnerKindStatefulSet = "StatefulSet"
+	PodOwnerKindDaemonSet   = "DaemonSet"
+	PodOwnerKindJob         = "Job"
+	PodOwnerKindCronJob     = "CronJob" // never a pod owner, selects the pods of every job the cronjob spawned
+)
+
 func NewPrometheus(ctx context.Context, cfg *Config) (*Prometheus, error) {
 	promCfg := promapi.Config{
 		Address:      cfg.Address,
@@ -131,6 +189,15 @@ func NewPrometheus(ctx context.Context, cfg *Config) (*Prometheus, error) {
 		client:         promClient,
 		api:            promApi,
 		scrapeInterval: defaultScrapeInterval,
+
+		namespaceResults: make(map[string]map[string]*namespaceQueryResult),
+	}
+
+	if cfg.Cache != nil && !cfg.Cache.Disabled {
+		prom.cache, err = NewCache(*cfg.Cache)
+		if err != nil {
+			log.Printf("failed to open metrics cache on %s, continuing without it: %v", cfg.Cache.Dir, err)
+		}
 	}
 
 	scrapeInterval, err := prom.calculateScrapeInterval(ctx)
@@ -138,9 +205,25 @@ func NewPrometheus(ctx context.Context, cfg *Config) (*Prometheus, error) {
 		prom.scrapeInterval = scrapeInterval
 	}
 
+	ownerMetricsAvailable, err := prom.hasOwnerMetrics(ctx)
+	if err == nil {
+		prom.ownerMetricsAvailable = ownerMetricsAvailable
+	}
+
 	return &prom, nil
 }
 
+func (p *Prometheus) hasOwnerMetrics(ctx context.Context) (bool, error) {
+	value, _, err := p.api.Query(ctx, "count(kube_pod_owner)", time.Now())
+	if err != nil {
+		return false, err
+	}
+	if value.Type() != model.ValVector {
+		return false, fmt.Errorf("unexpected response type: %s", value.Type())
+	}
+	return len(value.(model.Vector)) > 0, nil
+}
+
 func (p *Prometheus) calculateScrapeInterval(ctx context.Context) (time.Duration, error) {
 	sampleDuration := 24 * time.Hour
 	minV := math.MaxInt
@@ -292,7 +375,7 @@ func (p *Prometheus) parseMultiDimensionalQueryRange(ctx context.Context, query
 	p.cfg.reconnectWait.Lock()
 	p.cfg.reconnectWait.Unlock()
 
-	if rangeEnd.Sub(rangeStart) < time.Hour*24 {
+	if p.cache == nil && rangeEnd.Sub(rangeStart) < time.Hour*24 {
 		value, _, err := p.api.QueryRange(ctx, query, prometheus.Range{
 			Start: rangeStart,
 			End:   rangeEnd,
@@ -304,36 +387,109 @@ func (p *Prometheus) parseMultiDimensionalQueryRange(ctx context.Context, query
 		return parseMultiDimensionalGroupedPrometheusResponse(value, groupBys...)
 	}
 
-	var result *PromDimension
-	for rangeStart.Before(rangeEnd) {
-		rangeEndStep := rangeStart.Add(time.Hour * 24)
-		if rangeEndStep.After(rangeEnd) {
-			rangeEndStep = rangeEnd
-		}
-		value, _, err := p.api.QueryRange(ctx, query, prometheus.Range{
-			Start: rangeStart,
-			End:   rangeEndStep,
-			Step:  step,
-		})
-		if err != nil {
-			return nil, err
+	type chunk struct {
+		start, end time.Time
+		// bucket is set for chunks covering a whole past cache bucket
+		bucket *time.Time
+		dim    PromDimension
+		err    error
+	}
+	var chunks []*chunk
+	if p.cache != nil {
+		now := time.Now()
+		for bucketStart := rangeStart.Truncate(cacheBucketSize); bucketStart.Before(rangeEnd); bucketStart = bucketStart.Add(cacheBucketSize) {
+			bucketEnd := bucketStart.Add(cacheBucketSize)
+			if bucketEnd.After(now) {
+				start := bucketStart
+				if start.Before(rangeStart) {
+					start = rangeStart
+				}
+				chunks = append(chunks, &chunk{start: start, end: rangeEnd})
+				continue
+			}
+			bucket := bucketStart
+			chunks = append(chunks, &chunk{start: bucketStart, end: bucketEnd, bucket: &bucket})
+		}
+	} else {
+		for rangeStart.Before(rangeEnd) {
+			rangeEndStep := rangeStart.Add(time.Hour * 24)
+			if rangeEndStep.After(rangeEnd) {
+				rangeEndStep = rangeEnd
+			}
+			chunks = append(chunks, &chunk{start: rangeStart, end: rangeEndStep})
+			rangeStart = rangeEndStep
 		}
+	}
 
-		dim, err := parseMultiDimensionalGroupedPrometheusResponse(value, groupBys...)
-		if err != nil {
-			return nil, err
-		}
+	concurrency := p.cfg.QueryConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultQueryConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+chunks:
+	for _, c := range chunks {
+		c := c
+		select {
+		case <-ctx.Done():
+			break chunks
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := ctx.Err(); err != nil {
+				c.err = err
+				return
+			}
+			if c.bucket != nil {
+				if dim, ok := p.cache.Get(query, step, *c.bucket, groupBys...); ok {
+					c.dim = trimDimension(dim, rangeStart, rangeEnd)
+					return
+				}
+			}
+			value, _, err := p.api.QueryRange(ctx, query, prometheus.Range{
+				Start: c.start,
+				End:   c.end,
+				Step:  step,
+			})
+			if err != nil {
+				c.err = err
+				return
+			}
+			c.dim, c.err = parseMultiDimensionalGroupedPrometheusResponse(value, groupBys...)
+			if c.err != nil || c.bucket == nil {
+				return
+			}
+			if err := p.cache.Set(query, step, *c.bucket, c.dim, groupBys...); err != nil {
+				log.Printf("failed to cache prometheus query result: %v", err)
+			}
+			c.dim = trimDimension(c.dim, rangeStart, rangeEnd)
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
+	var result *PromDimension
+	for _, c := range chunks {
+		if c.err != nil {
+			return nil, c.err
+		}
+		dim := c.dim
 		if result == nil {
 			result = &dim
 		} else {
+			var err error
 			*result, err = p.mergeMultiDimensionalGroupedPrometheusResponse(*result, dim, groupBys...)
 			if err != nil {
 				return nil, err
 			}
 		}
-
-		rangeStart = rangeEndStep
 	}
 
 	if result == nil {
@@ -346,11 +502,19 @@ func (p *Prometheus) GetCpuMetricsForPod(ctx context.Context, namespace, podName
 	p.cfg.reconnectWait.Lock()
 	p.cfg.reconnectWait.Unlock()
 
-	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, p.itemTimeout())
 	defer cancel()
 
 	step := time.Duration(math.Max(float64(time.Minute), float64(4*p.scrapeInterval)))
 
+	if p.cfg.BulkNamespaceQueries {
+		var queries []string
+		for _, metric := range cpuUsageMetrics {
+			queries = append(queries, cpuNamespaceQuery(metric, namespace, step))
+		}
+		return p.namespacePodMetrics(ctx, queries, namespace, podName, observabilityDays, step)
+	}
+
 	var result map[string][]PromDatapoint
 	for _, metric := range cpuUsageMetrics {
 		query := fmt.Sprintf(`sum(rate(%s{namespace="%s", pod="%s", container!=""}[%s])) by (container)`, metric, namespace, podName, model.Duration(step).String())
@@ -384,66 +548,71 @@ func (p *Prometheus) GetCpuMetricsForPod(ctx context.Context, namespace, podName
 	return result, nil
 }
 
-func (p *Prometheus) GetCpuMetricsForPodOwnerPrefix(ctx context.Context, namespace, podOwnerPrefix string, observabilityDays int, suffixMode PodSuffixMode) (map[string]map[string][]PromDatapoint, error) {
+func (p *Prometheus) GetCpuMetricsForPodOwnerPrefix(ctx context.Context, namespace, ownerKind, podOwnerPrefix string, observabilityDays int, suffixMode PodSuffixMode) (map[string]map[string][]PromDatapoint, error) {
 	p.cfg.reconnectWait.Lock()
 	p.cfg.reconnectWait.Unlock()
 
-	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, p.itemTimeout())
 	defer cancel()
 
 	step := time.Duration(math.Max(float64(time.Minute), float64(4*p.scrapeInterval)))
 
-	var result map[string]map[string][]PromDatapoint
-	for _, metric := range cpuUsageMetrics {
-		pattern := podOwnerPrefix + "-"
-		if len(pattern) > 58 {
-			pattern = pattern[:58]
+	if p.ownerMetricsAvailable && ownerKind != "" {
+		var result map[string]map[string][]PromDatapoint
+		var err error
+		if p.cfg.BulkNamespaceQueries {
+			var queries []string
+			for _, metric := range cpuUsageMetrics {
+				queries = append(queries, cpuNamespaceOwnerQuery(metric, namespace, step))
+			}
+			result, err = p.namespaceOwnerMetrics(ctx, queries, namespace, ownerKind, podOwnerPrefix, observabilityDays, step)
+		} else {
+			var queries []string
+			for _, metric := range cpuUsageMetrics {
+				queries = append(queries, fmt.Sprintf(`sum(rate(%s{namespace="%s", container!=""}[%s]) * on(namespace, pod) group_left() %s) by (pod, container)`, metric, namespace, model.Duration(step).String(), podOwnerSelector(namespace, ownerKind, podOwnerPrefix)))
+			}
+			result, err = p.queryPodContainerMetrics(ctx, queries, observabilityDays, step)
 		}
-		query := fmt.Sprintf(`sum(rate(%s{namespace="%s", pod=~"%s%s$", container!=""}[%s])) by (pod, container)`, metric, namespace, pattern, suffixMode.Regex(), model.Duration(step).String())
-		promDims, err := p.parseMultiDimensionalQueryRange(ctx, query,
-			time.Now().Add(time.Duration(observabilityDays)*-24*time.Hour).Truncate(step),
-			time.Now().Truncate(step),
-			step, "pod", "container")
 		if err != nil {
 			return nil, err
 		}
-		if promDims.promDimensionType() != PromDimensionTypeGroupedDimension {
-			return nil, fmt.Errorf("unexpected dimension type: %d", promDims.promDimensionType())
-		}
-		result = make(map[string]map[string][]PromDatapoint)
-		hasData := false
-		for podName, promPodDim := range promDims.(PromGroupedDimension).Values {
-			if promPodDim.promDimensionType() != PromDimensionTypeGroupedDimension {
-				return nil, fmt.Errorf("unexpected dimension type: %d", promPodDim.promDimensionType())
-			}
-			result[podName] = make(map[string][]PromDatapoint)
-			for containerName, promContainerDim := range promPodDim.(PromGroupedDimension).Values {
-				if promContainerDim.promDimensionType() != PromDimensionTypeDatapoint {
-					return nil, fmt.Errorf("unexpected dimension type: %d", promContainerDim.promDimensionType())
-				}
-				if len(promContainerDim.(PromDatapoints).Values) > 0 {
-					hasData = true
-				}
-				result[podName][containerName] = promContainerDim.(PromDatapoints).Values
-			}
+		if hasPodContainerData(result) {
+			return result, nil
 		}
-		if hasData {
-			break
+	}
+
+	var queries []string
+	if p.cfg.BulkNamespaceQueries {
+		for _, metric := range cpuUsageMetrics {
+			queries = append(queries, cpuNamespaceQuery(metric, namespace, step))
 		}
+		return p.namespacePrefixMetrics(ctx, queries, namespace, podOwnerPrefix, suffixMode, observabilityDays, step)
 	}
 
-	return result, nil
+	pattern := podOwnerPrefixPattern(podOwnerPrefix)
+	for _, metric := range cpuUsageMetrics {
+		queries = append(queries, fmt.Sprintf(`sum(rate(%s{namespace="%s", pod=~"%s%s$", container!=""}[%s])) by (pod, container)`, metric, namespace, pattern, suffixMode.Regex(), model.Duration(step).String()))
+	}
+	return p.queryPodContainerMetrics(ctx, queries, observabilityDays, step)
 }
 
 func (p *Prometheus) GetMemoryMetricsForPod(ctx context.Context, namespace, podName string, observabilityDays int) (map[string][]PromDatapoint, error) {
 	p.cfg.reconnectWait.Lock()
 	p.cfg.reconnectWait.Unlock()
 
-	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, p.itemTimeout())
 	defer cancel()
 
 	step := time.Duration(math.Max(float64(time.Minute), float64(4*p.scrapeInterval)))
 
+	if p.cfg.BulkNamespaceQueries {
+		var queries []string
+		for _, metric := range memoryUsageMetrics {
+			queries = append(queries, memoryNamespaceQuery(metric, namespace))
+		}
+		return p.namespacePodMetrics(ctx, queries, namespace, podName, observabilityDays, step)
+	}
+
 	var result map[string][]PromDatapoint
 	for _, metric := range memoryUsageMetrics {
 		query := fmt.Sprintf(`max(%s{namespace="%s", pod="%s", container!=""}) by (container)`, metric, namespace, podName)
@@ -476,66 +645,71 @@ func (p *Prometheus) GetMemoryMetricsForPod(ctx context.Context, namespace, podN
 	return result, nil
 }
 
-func (p *Prometheus) GetMemoryMetricsForPodOwnerPrefix(ctx context.Context, namespace, podPrefix string, observabilityDays int, suffixMode PodSuffixMode) (map[string]map[string][]PromDatapoint, error) {
+func (p *Prometheus) GetMemoryMetricsForPodOwnerPrefix(ctx context.Context, namespace, ownerKind, podPrefix string, observabilityDays int, suffixMode PodSuffixMode) (map[string]map[string][]PromDatapoint, error) {
 	p.cfg.reconnectWait.Lock()
 	p.cfg.reconnectWait.Unlock()
 
-	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, p.itemTimeout())
 	defer cancel()
 
 	step := time.Duration(math.Max(float64(time.Minute), float64(4*p.scrapeInterval)))
 
-	var result map[string]map[string][]PromDatapoint
-	for _, metric := range memoryUsageMetrics {
-		pattern := podPrefix + "-"
-		if len(pattern) > 58 {
-			pattern = pattern[:58]
+	if p.ownerMetricsAvailable && ownerKind != "" {
+		var result map[string]map[string][]PromDatapoint
+		var err error
+		if p.cfg.BulkNamespaceQueries {
+			var queries []string
+			for _, metric := range memoryUsageMetrics {
+				queries = append(queries, memoryNamespaceOwnerQuery(metric, namespace))
+			}
+			result, err = p.namespaceOwnerMetrics(ctx, queries, namespace, ownerKind, podPrefix, observabilityDays, step)
+		} else {
+			var queries []string
+			for _, metric := range memoryUsageMetrics {
+				queries = append(queries, fmt.Sprintf(`max(%s{namespace="%s", container!=""} * on(namespace, pod) group_left() %s) by (pod, container)`, metric, namespace, podOwnerSelector(namespace, ownerKind, podPrefix)))
+			}
+			result, err = p.queryPodContainerMetrics(ctx, queries, observabilityDays, step)
 		}
-
-		query := fmt.Sprintf(`max(%s{namespace="%s", pod=~"%s%s$", container!=""}) by (pod, container)`, metric, namespace, pattern, suffixMode.Regex())
-		promDims, err := p.parseMultiDimensionalQueryRange(ctx, query,
-			time.Now().Add(time.Duration(observabilityDays)*-24*time.Hour).Truncate(step),
-			time.Now().Truncate(step),
-			step, "pod", "container")
 		if err != nil {
 			return nil, err
 		}
-		if promDims.promDimensionType() != PromDimensionTypeGroupedDimension {
-			return nil, fmt.Errorf("unexpected dimension type: %d", promDims.promDimensionType())
+		if hasPodContainerData(result) {
+			return result, nil
 		}
-		result = make(map[string]map[string][]PromDatapoint)
-		hasData := false
-		for podName, promPodDim := range promDims.(PromGroupedDimension).Values {
-			if promPodDim.promDimensionType() != PromDimensionTypeGroupedDimension {
-				return nil, fmt.Errorf("unexpected dimension type: %d", promPodDim.promDimensionType())
-			}
-			result[podName] = make(map[string][]PromDatapoint)
-			for containerName, promContainerDim := range promPodDim.(PromGroupedDimension).Values {
-				if promContainerDim.promDimensionType() != PromDimensionTypeDatapoint {
-					return nil, fmt.Errorf("unexpected dimension type: %d", promContainerDim.promDimensionType())
-				}
-				if len(promContainerDim.(PromDatapoints).Values) > 0 {
-					hasData = true
-				}
-				result[podName][containerName] = promContainerDim.(PromDatapoints).Values
-			}
-		}
-		if hasData {
-			break
+	}
+
+	var queries []string
+	if p.cfg.BulkNamespaceQueries {
+		for _, metric := range memoryUsageMetrics {
+			queries = append(queries, memoryNamespaceQuery(metric, namespace))
 		}
+		return p.namespacePrefixMetrics(ctx, queries, namespace, podPrefix, suffixMode, observabilityDays, step)
 	}
-	return result, nil
+
+	pattern := podOwnerPrefixPattern(podPrefix)
+	for _, metric := range memoryUsageMetrics {
+		queries = append(queries, fmt.Sprintf(`max(%s{namespace="%s", pod=~"%s%s$", container!=""}) by (pod, container)`, metric, namespace, pattern, suffixMode.Regex()))
+	}
+	return p.queryPodContainerMetrics(ctx, queries, observabilityDays, step)
 }
 
 func (p *Prometheus) GetCpuThrottlingMetricsForPod(ctx context.Context, namespace, podName string, observabilityDays int) (map[string][]PromDatapoint, error) {
 	p.cfg.reconnectWait.Lock()
 	p.cfg.reconnectWait.Unlock()
 
-	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, p.itemTimeout())
 	defer cancel()
 
 	step := time.Duration(math.Max(float64(time.Minute), float64(4*p.scrapeInterval)))
 
+	if p.cfg.BulkNamespaceQueries {
+		var queries []string
+		for i, metric := range cpuThrottlingThrottledPeriodsMetrics {
+			queries = append(queries, cpuThrottlingNamespaceQuery(metric, cpuThrottlingPeriodsMetrics[i], namespace, step))
+		}
+		return p.namespacePodMetrics(ctx, queries, namespace, podName, observabilityDays, step)
+	}
+
 	var result map[string][]PromDatapoint
 	for i, metric := range cpuThrottlingThrottledPeriodsMetrics {
 		query := fmt.Sprintf(`sum(increase(%[4]s{namespace="%[1]s", pod="%[2]s", container!=""}[%[3]s])) by (container) / sum(increase(%[5]s{namespace="%[1]s", pod="%[2]s", container!=""}[%[3]s])) by (container)`, namespace, podName, model.Duration(step).String(), metric, cpuThrottlingPeriodsMetrics[i])
@@ -568,22 +742,59 @@ func (p *Prometheus) GetCpuThrottlingMetricsForPod(ctx context.Context, namespac
 	return result, nil
 }
 
-func (p *Prometheus) GetCpuThrottlingMetricsForPodOwnerPrefix(ctx context.Context, namespace, podPrefix string, observabilityDays int, suffixMode PodSuffixMode) (map[string]map[string][]PromDatapoint, error) {
+func (p *Prometheus) GetCpuThrottlingMetricsForPodOwnerPrefix(ctx context.Context, namespace, ownerKind, podPrefix string, observabilityDays int, suffixMode PodSuffixMode) (map[string]map[string][]PromDatapoint, error) {
 	p.cfg.reconnectWait.Lock()
 	p.cfg.reconnectWait.Unlock()
 
-	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, p.itemTimeout())
 	defer cancel()
 
 	step := time.Duration(math.Max(float64(time.Minute), float64(4*p.scrapeInterval)))
-	var result map[string]map[string][]PromDatapoint
-	for i, metric := range cpuThrottlingThrottledPeriodsMetrics {
-		pattern := podPrefix + "-"
-		if len(pattern) > 58 {
-			pattern = pattern[:58]
+
+	if p.ownerMetricsAvailable && ownerKind != "" {
+		var result map[string]map[string][]PromDatapoint
+		var err error
+		if p.cfg.BulkNamespaceQueries {
+			var queries []string
+			for i, metric := range cpuThrottlingThrottledPeriodsMetrics {
+				queries = append(queries, cpuThrottlingNamespaceOwnerQuery(metric, cpuThrottlingPeriodsMetrics[i], namespace, step))
+			}
+			result, err = p.namespaceOwnerMetrics(ctx, queries, namespace, ownerKind, podPrefix, observabilityDays, step)
+		} else {
+			owner := podOwnerSelector(namespace, ownerKind, podPrefix)
+			var queries []string
+			for i, metric := range cpuThrottlingThrottledPeriodsMetrics {
+				queries = append(queries, fmt.Sprintf(`sum(increase(%[3]s{namespace="%[1]s", container!=""}[%[2]s]) * on(namespace, pod) group_left() %[5]s) by (pod, container) / sum(increase(%[4]s{namespace="%[1]s", container!=""}[%[2]s]) * on(namespace, pod) group_left() %[5]s) by (pod, container)`, namespace, model.Duration(step).String(), metric, cpuThrottlingPeriodsMetrics[i], owner))
+			}
+			result, err = p.queryPodContainerMetrics(ctx, queries, observabilityDays, step)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hasPodContainerData(result) {
+			return result, nil
+		}
+	}
+
+	var queries []string
+	if p.cfg.BulkNamespaceQueries {
+		for i, metric := range cpuThrottlingThrottledPeriodsMetrics {
+			queries = append(queries, cpuThrottlingNamespaceQuery(metric, cpuThrottlingPeriodsMetrics[i], namespace, step))
 		}
+		return p.namespacePrefixMetrics(ctx, queries, namespace, podPrefix, suffixMode, observabilityDays, step)
+	}
+
+	pattern := podOwnerPrefixPattern(podPrefix)
+	for i, metric := range cpuThrottlingThrottledPeriodsMetrics {
+		queries = append(queries, fmt.Sprintf(`sum(increase(%[5]s{namespace="%[1]s", pod=~"%[2]s%[3]s$", container!=""}[%[4]s])) by (pod, container) / sum(increase(%[6]s{namespace="%[1]s", pod=~"%[2]s%[3]s", container!=""}[%[4]s])) by (pod, container)`, namespace, pattern, suffixMode.Regex(), model.Duration(step).String(), metric, cpuThrottlingPeriodsMetrics[i]))
+	}
+	return p.queryPodContainerMetrics(ctx, queries, observabilityDays, step)
+}
 
-		query := fmt.Sprintf(`sum(increase(%[5]s{namespace="%[1]s", pod=~"%[2]s%[3]s$", container!=""}[%[4]s])) by (pod, container) / sum(increase(%[6]s{namespace="%[1]s", pod=~"%[2]s%[3]s", container!=""}[%[4]s])) by (pod, container)`, namespace, pattern, suffixMode.Regex(), model.Duration(step).String(), metric, cpuThrottlingPeriodsMetrics[i])
+// queryPodContainerMetrics runs the queries in order and returns the first result that has any datapoints.
+func (p *Prometheus) queryPodContainerMetrics(ctx context.Context, queries []string, observabilityDays int, step time.Duration) (map[string]map[string][]PromDatapoint, error) {
+	var result map[string]map[string][]PromDatapoint
+	for _, query := range queries {
 		promDims, err := p.parseMultiDimensionalQueryRange(ctx, query,
 			time.Now().Add(time.Duration(observabilityDays)*-24*time.Hour).Truncate(step),
 			time.Now().Truncate(step),
@@ -591,33 +802,51 @@ func (p *Prometheus) GetCpuThrottlingMetricsForPodOwnerPrefix(ctx context.Contex
 		if err != nil {
 			return nil, err
 		}
-		if promDims.promDimensionType() != PromDimensionTypeGroupedDimension {
-			return nil, fmt.Errorf("unexpected dimension type: %d", promDims.promDimensionType())
-		}
-		result = make(map[string]map[string][]PromDatapoint)
-		hasData := false
-		for podName, promPodDim := range promDims.(PromGroupedDimension).Values {
-			if promPodDim.promDimensionType() != PromDimensionTypeGroupedDimension {
-				return nil, fmt.Errorf("unexpected dimension type: %d", promPodDim.promDimensionType())
-			}
-			result[podName] = make(map[string][]PromDatapoint)
-			for containerName, promContainerDim := range promPodDim.(PromGroupedDimension).Values {
-				if promContainerDim.promDimensionType() != PromDimensionTypeDatapoint {
-					return nil, fmt.Errorf("unexpected dimension type: %d", promContainerDim.promDimensionType())
-				}
-				if len(promContainerDim.(PromDatapoints).Values) > 0 {
-					hasData = true
-				}
-				result[podName][containerName] = promContainerDim.(PromDatapoints).Values
-			}
+		result, err = podContainerDatapoints(promDims, nil)
+		if err != nil {
+			return nil, err
 		}
-		if hasData {
+		if hasPodContainerData(result) {
 			break
 		}
 	}
 	return result, nil
 }
 
+func hasPodContainerData(result map[string]map[string][]PromDatapoint) bool {
+	for _, containers := range result {
+		for _, datapoints := range containers {
+			if len(datapoints) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func podOwnerPrefixPattern(podOwnerPrefix string) string {
+	pattern := podOwnerPrefix + "-"
+	if len(pattern) > 58 {
+		pattern = pattern[:58]
+	}
+	return pattern
+}
+
+// podOwnerSelector is a 1-valued series per pod owned by the given controller, to be joined with cAdvisor series on (namespace, pod).
+func podOwnerSelector(namespace, ownerKind, ownerName string) string {
+	if ownerKind == PodOwnerKindCronJob {
+		// kube_pod_owner only knows the jobs, match every job the cronjob spawned by name
+		// the pattern is in a double quoted PromQL string, where the backslashes quoting the regex have to be escaped
+		return fmt.Sprintf(`max by (namespace, pod) (kube_pod_owner{namespace="%s", owner_kind="%s", owner_name=~"%s"})`, namespace, PodOwnerKindJob, strings.ReplaceAll(cronJobJobNamePattern(ownerName), `\`, `\\`))
+	}
+	return fmt.Sprintf(`max by (namespace, pod) (kube_pod_owner{namespace="%s", owner_kind="%s", owner_name="%s"})`, namespace, ownerKind, ownerName)
+}
+
+// cronJobJobNamePattern matches the names of the jobs spawned by the cronjob, <cronjob>-<scheduled time>.
+func cronJobJobNamePattern(cronJobName string) string {
+	return regexp.QuoteMeta(cronJobName) + "-[0-9]+"
+}
+
 func (p *Prometheus) Ping(ctx context.Context) error {
 	_, _, err := p.api.Query(ctx, "up", time.Now())
 	return err
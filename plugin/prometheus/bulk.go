@@ -0,0 +1,285 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"github.com/prometheus/common/model"
+	"regexp"
+	"time"
+)
+
+// namespaceQueryResult is a namespace-wide range query shared between every item of the namespace. The first item that needs
+// it starts the fetch and every item waits for done to be closed, result and err are set by then.
+type namespaceQueryResult struct {
+	done   chan struct{}
+	result PromDimension
+	err    error
+}
+
+func (r *namespaceQueryResult) running() bool {
+	select {
+	case <-r.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func cpuNamespaceQuery(metric, namespace string, step time.Duration) string {
+	return fmt.Sprintf(`sum(rate(%s{namespace="%s", container!=""}[%s])) by (pod, container)`, metric, namespace, model.Duration(step).String())
+}
+
+func memoryNamespaceQuery(metric, namespace string) string {
+	return fmt.Sprintf(`max(%s{namespace="%s", container!=""}) by (pod, container)`, metric, namespace)
+}
+
+func cpuThrottlingNamespaceQuery(throttledMetric, periodsMetric, namespace string, step time.Duration) string {
+	return fmt.Sprintf(`sum(increase(%[3]s{namespace="%[1]s", container!=""}[%[2]s])) by (pod, container) / sum(increase(%[4]s{namespace="%[1]s", container!=""}[%[2]s])) by (pod, container)`, namespace, model.Duration(step).String(), throttledMetric, periodsMetric)
+}
+
+func namespaceOwnersSelector(namespace string) string {
+	return fmt.Sprintf(`max by (namespace, pod, owner_kind, owner_name) (kube_pod_owner{namespace="%s"})`, namespace)
+}
+
+func cpuNamespaceOwnerQuery(metric, namespace string, step time.Duration) string {
+	return fmt.Sprintf(`sum(rate(%s{namespace="%s", container!=""}[%s]) * on(namespace, pod) group_left(owner_kind, owner_name) %s) by (owner_kind, owner_name, pod, container)`, metric, namespace, model.Duration(step).String(), namespaceOwnersSelector(namespace))
+}
+
+func memoryNamespaceOwnerQuery(metric, namespace string) string {
+	return fmt.Sprintf(`max(%s{namespace="%s", container!=""} * on(namespace, pod) group_left(owner_kind, owner_name) %s) by (owner_kind, owner_name, pod, container)`, metric, namespace, namespaceOwnersSelector(namespace))
+}
+
+func cpuThrottlingNamespaceOwnerQuery(throttledMetric, periodsMetric, namespace string, step time.Duration) string {
+	return fmt.Sprintf(`sum(increase(%[3]s{namespace="%[1]s", container!=""}[%[2]s]) * on(namespace, pod) group_left(owner_kind, owner_name) %[5]s) by (owner_kind, owner_name, pod, container) / sum(increase(%[4]s{namespace="%[1]s", container!=""}[%[2]s]) * on(namespace, pod) group_left(owner_kind, owner_name) %[5]s) by (owner_kind, owner_name, pod, container)`, namespace, model.Duration(step).String(), throttledMetric, periodsMetric, namespaceOwnersSelector(namespace))
+}
+
+// maxBulkNamespaces is how many namespaces keep their bulk query results around.
+// Items are mostly processed namespace by namespace, so only the most recently used namespaces are kept and the others are
+// fetched again if needed. Namespaces with a fetch still running are never evicted, so there can be more of them for a while.
+const maxBulkNamespaces = 8
+
+// itemTimeout bounds the queries of a single item, with bulk queries it includes the wait for the namespace-wide fetch.
+func (p *Prometheus) itemTimeout() time.Duration {
+	if p.cfg.BulkNamespaceQueries {
+		return p.bulkQueryTimeout()
+	}
+	return time.Minute
+}
+
+func (p *Prometheus) bulkQueryTimeout() time.Duration {
+	if p.cfg.BulkQueryTimeout > 0 {
+		return p.cfg.BulkQueryTimeout
+	}
+	return DefaultBulkQueryTimeout
+}
+
+// namespaceQueryRange runs query once per observability window, later callers get the already fetched result.
+// The fetch runs under its own bulkQueryTimeout deadline rather than the context of the item that started it, callers stop
+// waiting for it when their context is done. Failed queries are not remembered so the next item retries them.
+func (p *Prometheus) namespaceQueryRange(ctx context.Context, namespace, query string, observabilityDays int, step time.Duration, groupBys ...model.LabelName) (PromDimension, error) {
+	key := fmt.Sprintf("%d/%s", observabilityDays, query)
+	entry := p.namespaceResult(namespace, key, func(fetchCtx context.Context) (PromDimension, error) {
+		return p.parseMultiDimensionalQueryRange(fetchCtx, query,
+			time.Now().Add(time.Duration(observabilityDays)*-24*time.Hour).Truncate(step),
+			time.Now().Truncate(step),
+			step, groupBys...)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-entry.done:
+		return entry.result, entry.err
+	}
+}
+
+// namespaceResult returns the result entry of key in namespace, starting fetch when there is none or the last one failed.
+// It marks namespace as the most recently used and evicts the least recently used namespaces without a running fetch.
+func (p *Prometheus) namespaceResult(namespace, key string, fetch func(ctx context.Context) (PromDimension, error)) *namespaceQueryResult {
+	p.namespaceResultsLock.Lock()
+	defer p.namespaceResultsLock.Unlock()
+
+	results, ok := p.namespaceResults[namespace]
+	if !ok {
+		results = make(map[string]*namespaceQueryResult)
+		p.namespaceResults[namespace] = results
+	}
+	for i, n := range p.namespaceOrder {
+		if n == namespace {
+			p.namespaceOrder = append(p.namespaceOrder[:i], p.namespaceOrder[i+1:]...)
+			break
+		}
+	}
+	p.namespaceOrder = append(p.namespaceOrder, namespace)
+
+	entry, ok := results[key]
+	if !ok || (!entry.running() && entry.err != nil) {
+		entry = &namespaceQueryResult{done: make(chan struct{})}
+		results[key] = entry
+		go func() {
+			fetchCtx, cancel := context.WithTimeout(context.Background(), p.bulkQueryTimeout())
+			defer cancel()
+			entry.result, entry.err = fetch(fetchCtx)
+			close(entry.done)
+		}()
+	}
+
+	for i := 0; len(p.namespaceOrder) > maxBulkNamespaces && i < len(p.namespaceOrder)-1; {
+		evicted := p.namespaceOrder[i]
+		if p.namespaceRunning(evicted) {
+			i++
+			continue
+		}
+		delete(p.namespaceResults, evicted)
+		p.namespaceOrder = append(p.namespaceOrder[:i], p.namespaceOrder[i+1:]...)
+	}
+	return entry
+}
+
+// namespaceRunning reports whether a fetch of the namespace is still running, namespaceResultsLock must be held.
+func (p *Prometheus) namespaceRunning(namespace string) bool {
+	for _, entry := range p.namespaceResults[namespace] {
+		if entry.running() {
+			return true
+		}
+	}
+	return false
+}
+
+// namespacePodMetrics picks a single pod out of namespace-wide (pod, container) queries, trying the queries in order until one has data.
+func (p *Prometheus) namespacePodMetrics(ctx context.Context, queries []string, namespace, podName string, observabilityDays int, step time.Duration) (map[string][]PromDatapoint, error) {
+	var result map[string][]PromDatapoint
+	for _, query := range queries {
+		promDims, err := p.namespaceQueryRange(ctx, namespace, query, observabilityDays, step, "pod", "container")
+		if err != nil {
+			return nil, err
+		}
+		pods, err := podContainerDatapoints(promDims, func(pod string) bool {
+			return pod == podName
+		})
+		if err != nil {
+			return nil, err
+		}
+		result = pods[podName]
+		if result == nil {
+			result = make(map[string][]PromDatapoint)
+		}
+		if hasPodContainerData(pods) {
+			break
+		}
+	}
+	return result, nil
+}
+
+// namespacePrefixMetrics picks the pods matching the owner prefix out of namespace-wide (pod, container) queries.
+func (p *Prometheus) namespacePrefixMetrics(ctx context.Context, queries []string, namespace, podOwnerPrefix string, suffixMode PodSuffixMode, observabilityDays int, step time.Duration) (map[string]map[string][]PromDatapoint, error) {
+	re, err := regexp.Compile("^" + regexp.QuoteMeta(podOwnerPrefixPattern(podOwnerPrefix)) + suffixMode.Regex() + "$")
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]map[string][]PromDatapoint
+	for _, query := range queries {
+		promDims, err := p.namespaceQueryRange(ctx, namespace, query, observabilityDays, step, "pod", "container")
+		if err != nil {
+			return nil, err
+		}
+		result, err = podContainerDatapoints(promDims, re.MatchString)
+		if err != nil {
+			return nil, err
+		}
+		if hasPodContainerData(result) {
+			break
+		}
+	}
+	return result, nil
+}
+
+// namespaceOwnerMetrics picks the pods of a single owner out of namespace-wide (owner_kind, owner_name, pod, container) queries.
+func (p *Prometheus) namespaceOwnerMetrics(ctx context.Context, queries []string, namespace, ownerKind, ownerName string, observabilityDays int, step time.Duration) (map[string]map[string][]PromDatapoint, error) {
+	var result map[string]map[string][]PromDatapoint
+	for _, query := range queries {
+		promDims, err := p.namespaceQueryRange(ctx, namespace, query, observabilityDays, step, "owner_kind", "owner_name", "pod", "container")
+		if err != nil {
+			return nil, err
+		}
+		if promDims.promDimensionType() != PromDimensionTypeGroupedDimension {
+			return nil, fmt.Errorf("unexpected dimension type: %d", promDims.promDimensionType())
+		}
+		result = make(map[string]map[string][]PromDatapoint)
+		if ownerKind == PodOwnerKindCronJob {
+			result, err = cronJobOwnerMetrics(promDims, ownerName)
+			if err != nil {
+				return nil, err
+			}
+		} else if kindDim, ok := promDims.(PromGroupedDimension).Values[ownerKind]; ok {
+			if kindDim.promDimensionType() != PromDimensionTypeGroupedDimension {
+				return nil, fmt.Errorf("unexpected dimension type: %d", kindDim.promDimensionType())
+			}
+			if ownerDim, ok := kindDim.(PromGroupedDimension).Values[ownerName]; ok {
+				result, err = podContainerDatapoints(ownerDim, nil)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		if hasPodContainerData(result) {
+			break
+		}
+	}
+	return result, nil
+}
+
+// cronJobOwnerMetrics merges the pods of every job spawned by the cronjob out of an (owner_kind, owner_name, pod, container) dimension.
+func cronJobOwnerMetrics(promDims PromDimension, cronJobName string) (map[string]map[string][]PromDatapoint, error) {
+	re, err := regexp.Compile("^" + cronJobJobNamePattern(cronJobName) + "$")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]map[string][]PromDatapoint)
+	kindDim, ok := promDims.(PromGroupedDimension).Values[PodOwnerKindJob]
+	if !ok {
+		return result, nil
+	}
+	if kindDim.promDimensionType() != PromDimensionTypeGroupedDimension {
+		return nil, fmt.Errorf("unexpected dimension type: %d", kindDim.promDimensionType())
+	}
+	for jobName, ownerDim := range kindDim.(PromGroupedDimension).Values {
+		if !re.MatchString(jobName) {
+			continue
+		}
+		pods, err := podContainerDatapoints(ownerDim, nil)
+		if err != nil {
+			return nil, err
+		}
+		for podName, containers := range pods {
+			result[podName] = containers
+		}
+	}
+	return result, nil
+}
+
+// podContainerDatapoints flattens a (pod, container) grouped dimension, keeping only the pods accepted by podFilter when it is set.
+func podContainerDatapoints(promDims PromDimension, podFilter func(pod string) bool) (map[string]map[string][]PromDatapoint, error) {
+	if promDims.promDimensionType() != PromDimensionTypeGroupedDimension {
+		return nil, fmt.Errorf("unexpected dimension type: %d", promDims.promDimensionType())
+	}
+	result := make(map[string]map[string][]PromDatapoint)
+	for podName, promPodDim := range promDims.(PromGroupedDimension).Values {
+		if podFilter != nil && !podFilter(podName) {
+			continue
+		}
+		if promPodDim.promDimensionType() != PromDimensionTypeGroupedDimension {
+			return nil, fmt.Errorf("unexpected dimension type: %d", promPodDim.promDimensionType())
+		}
+		result[podName] = make(map[string][]PromDatapoint)
+		for containerName, promContainerDim := range promPodDim.(PromGroupedDimension).Values {
+			if promContainerDim.promDimensionType() != PromDimensionTypeDatapoint {
+				return nil, fmt.Errorf("unexpected dimension type: %d", promContainerDim.promDimensionType())
+			}
+			result[podName][containerName] = promContainerDim.(PromDatapoints).Values
+		}
+	}
+	return result, nil
+}
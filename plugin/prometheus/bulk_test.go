@@ -0,0 +1,291 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	prometheus "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingAPI counts the range queries it gets and answers them with an empty matrix.
+type countingAPI struct {
+	prometheus.API
+	queries atomic.Int32
+}
+
+func (a *countingAPI) QueryRange(ctx context.Context, query string, r prometheus.Range, opts ...prometheus.Option) (model.Value, prometheus.Warnings, error) {
+	a.queries.Add(1)
+	return model.Matrix{}, nil, nil
+}
+
+func datapoints(values ...float64) PromDatapoints {
+	var result PromDatapoints
+	for i, v := range values {
+		result.Values = append(result.Values, PromDatapoint{Timestamp: time.Unix(int64(i*60), 0), Value: v})
+	}
+	return result
+}
+
+func grouped(values map[string]PromDimension) PromGroupedDimension {
+	return PromGroupedDimension{Values: values}
+}
+
+// podsDimension is a (pod, container) dimension with a single "app" container per pod.
+func podsDimension(pods ...string) PromGroupedDimension {
+	result := grouped(map[string]PromDimension{})
+	for i, pod := range pods {
+		result.Values[pod] = grouped(map[string]PromDimension{"app": datapoints(float64(i + 1))})
+	}
+	return result
+}
+
+// bulkPrometheus is a Prometheus whose namespace-wide results are already fetched, so no query reaches the api.
+func bulkPrometheus(namespace string, observabilityDays int, results map[string]PromDimension) (*Prometheus, *countingAPI) {
+	api := &countingAPI{}
+	p := &Prometheus{
+		cfg:              &Config{QueryConcurrency: DefaultQueryConcurrency},
+		api:              api,
+		scrapeInterval:   defaultScrapeInterval,
+		namespaceResults: make(map[string]map[string]*namespaceQueryResult),
+	}
+	for query, result := range results {
+		fetchedResult(p, namespace, fmt.Sprintf("%d/%s", observabilityDays, query), result)
+	}
+	return p, api
+}
+
+// fetchedResult stores result as the namespace-wide result of key in namespace and waits for it to be fetched.
+func fetchedResult(p *Prometheus, namespace, key string, result PromDimension) *namespaceQueryResult {
+	entry := p.namespaceResult(namespace, key, func(context.Context) (PromDimension, error) {
+		return result, nil
+	})
+	<-entry.done
+	return entry
+}
+
+func TestPodContainerDatapoints(t *testing.T) {
+	dims := podsDimension("web-1", "web-2", "db-0")
+
+	all, err := podContainerDatapoints(dims, nil)
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+	assert.Equal(t, datapoints(3).Values, all["db-0"]["app"])
+
+	filtered, err := podContainerDatapoints(dims, func(pod string) bool {
+		return pod == "web-2"
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string][]PromDatapoint{"web-2": {"app": datapoints(2).Values}}, filtered)
+
+	_, err = podContainerDatapoints(datapoints(1), nil)
+	assert.Error(t, err, "a datapoints dimension has no pods")
+
+	_, err = podContainerDatapoints(grouped(map[string]PromDimension{"web-1": datapoints(1)}), nil)
+	assert.Error(t, err, "pods have to be grouped by container")
+
+	_, err = podContainerDatapoints(grouped(map[string]PromDimension{"web-1": grouped(map[string]PromDimension{"app": grouped(nil)})}), nil)
+	assert.Error(t, err, "containers have to hold datapoints")
+}
+
+func TestNamespacePodMetrics(t *testing.T) {
+	empty := grouped(map[string]PromDimension{"web-1": grouped(map[string]PromDimension{"app": PromDatapoints{}})})
+	p, api := bulkPrometheus("default", 7, map[string]PromDimension{
+		"first":  empty,
+		"second": podsDimension("web-1", "web-2"),
+	})
+
+	result, err := p.namespacePodMetrics(context.Background(), []string{"first", "second"}, "default", "web-2", 7, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]PromDatapoint{"app": datapoints(2).Values}, result, "falls through to the next query when the first has no data")
+
+	result, err = p.namespacePodMetrics(context.Background(), []string{"second"}, "default", "missing", 7, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+	assert.NotNil(t, result)
+
+	assert.Zero(t, api.queries.Load())
+}
+
+func TestNamespacePrefixMetrics(t *testing.T) {
+	p, _ := bulkPrometheus("default", 7, map[string]PromDimension{
+		"cpu": podsDimension("web-7d9f8-abcde", "web-7d9f8-fghij", "web-api-7d9f8-klmno", "web-0", "cron-28391040-pqrst"),
+	})
+
+	tests := []struct {
+		name       string
+		prefix     string
+		suffixMode PodSuffixMode
+		want       []string
+	}{
+		{name: "replicaset pods", prefix: "web-7d9f8", suffixMode: PodSuffixModeRandom, want: []string{"web-7d9f8-abcde", "web-7d9f8-fghij"}},
+		{name: "statefulset pods", prefix: "web", suffixMode: PodSuffixModeIncremental, want: []string{"web-0"}},
+		{name: "cronjob pods", prefix: "cron", suffixMode: PodSuffixModeCronJob, want: []string{"cron-28391040-pqrst"}},
+		{name: "no match", prefix: "db", suffixMode: PodSuffixModeRandom, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := p.namespacePrefixMetrics(context.Background(), []string{"cpu"}, "default", tt.prefix, tt.suffixMode, 7, time.Minute)
+			assert.NoError(t, err)
+			var pods []string
+			for pod := range result {
+				pods = append(pods, pod)
+			}
+			assert.ElementsMatch(t, tt.want, pods)
+		})
+	}
+}
+
+func TestNamespaceOwnerMetrics(t *testing.T) {
+	owners := grouped(map[string]PromDimension{
+		PodOwnerKindReplicaSet: grouped(map[string]PromDimension{
+			"web-7d9f8": podsDimension("web-7d9f8-abcde"),
+			"api-5c6b7": podsDimension("api-5c6b7-fghij"),
+		}),
+		PodOwnerKindJob: grouped(map[string]PromDimension{
+			"cron-28391040":  podsDimension("cron-28391040-klmno"),
+			"cron-28391100":  podsDimension("cron-28391100-pqrst"),
+			"cronjob-manual": podsDimension("cronjob-manual-uvwxy"),
+			"cron.v2-283910": podsDimension("cron.v2-283910-abcde"),
+			"cronxv2-283910": podsDimension("cronxv2-283910-fghij"),
+		}),
+	})
+	p, _ := bulkPrometheus("default", 7, map[string]PromDimension{"cpu": owners})
+
+	tests := []struct {
+		name      string
+		ownerKind string
+		ownerName string
+		want      []string
+	}{
+		{name: "exact owner", ownerKind: PodOwnerKindReplicaSet, ownerName: "web-7d9f8", want: []string{"web-7d9f8-abcde"}},
+		{name: "cronjob merges its jobs", ownerKind: PodOwnerKindCronJob, ownerName: "cron", want: []string{"cron-28391040-klmno", "cron-28391100-pqrst"}},
+		{name: "cronjob name is matched literally", ownerKind: PodOwnerKindCronJob, ownerName: "cron.v2", want: []string{"cron.v2-283910-abcde"}},
+		{name: "unknown owner", ownerKind: PodOwnerKindReplicaSet, ownerName: "db-1a2b3", want: nil},
+		{name: "unknown kind", ownerKind: PodOwnerKindStatefulSet, ownerName: "web", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := p.namespaceOwnerMetrics(context.Background(), []string{"cpu"}, "default", tt.ownerKind, tt.ownerName, 7, time.Minute)
+			assert.NoError(t, err)
+			var pods []string
+			for pod := range result {
+				pods = append(pods, pod)
+			}
+			assert.ElementsMatch(t, tt.want, pods)
+		})
+	}
+}
+
+func TestNamespaceResultsEviction(t *testing.T) {
+	p, _ := bulkPrometheus("ns-0", 7, map[string]PromDimension{"cpu": podsDimension("web-0")})
+	for i := 1; i < maxBulkNamespaces; i++ {
+		fetchedResult(p, fmt.Sprintf("ns-%d", i), "7/cpu", podsDimension())
+	}
+	assert.Len(t, p.namespaceResults, maxBulkNamespaces)
+	assert.NotNil(t, fetchedResult(p, "ns-0", "7/cpu", nil).result, "results are kept up to maxBulkNamespaces namespaces")
+
+	// ns-0 was just used, so ns-1 is the least recently used one
+	fetchedResult(p, "new", "7/cpu", podsDimension())
+	assert.Len(t, p.namespaceResults, maxBulkNamespaces)
+	assert.Len(t, p.namespaceOrder, maxBulkNamespaces)
+	assert.NotContains(t, p.namespaceResults, "ns-1")
+	assert.Contains(t, p.namespaceResults, "ns-0")
+	assert.Contains(t, p.namespaceResults, "new")
+}
+
+func TestNamespaceResultsKeepRunningFetches(t *testing.T) {
+	p, _ := bulkPrometheus("default", 7, nil)
+	release := make(chan struct{})
+	running := p.namespaceResult("running", "7/cpu", func(context.Context) (PromDimension, error) {
+		<-release
+		return podsDimension("web-0"), nil
+	})
+	for i := 0; i < maxBulkNamespaces; i++ {
+		fetchedResult(p, fmt.Sprintf("ns-%d", i), "7/cpu", podsDimension())
+	}
+	assert.Contains(t, p.namespaceResults, "running", "a namespace is not evicted while its fetch runs")
+
+	close(release)
+	<-running.done
+	fetchedResult(p, "new", "7/cpu", podsDimension())
+	assert.NotContains(t, p.namespaceResults, "running")
+	assert.Len(t, p.namespaceResults, maxBulkNamespaces)
+}
+
+func TestNamespaceResultsSingleFlight(t *testing.T) {
+	p, _ := bulkPrometheus("default", 7, nil)
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	fetch := func(context.Context) (PromDimension, error) {
+		fetches.Add(1)
+		<-release
+		return podsDimension("web-0"), nil
+	}
+
+	first := p.namespaceResult("default", "7/cpu", fetch)
+	second := p.namespaceResult("default", "7/cpu", fetch)
+	assert.Same(t, first, second, "callers share the running fetch")
+	close(release)
+	<-first.done
+	assert.Equal(t, int32(1), fetches.Load())
+
+	failed := p.namespaceResult("default", "7/memory", func(context.Context) (PromDimension, error) {
+		return nil, context.DeadlineExceeded
+	})
+	<-failed.done
+	retried := fetchedResult(p, "default", "7/memory", podsDimension("web-0"))
+	assert.NotSame(t, failed, retried, "failed fetches are not remembered")
+	assert.NoError(t, retried.err)
+}
+
+// blockingAPI answers range queries with an empty matrix once release is closed.
+type blockingAPI struct {
+	prometheus.API
+	release chan struct{}
+}
+
+func (a *blockingAPI) QueryRange(ctx context.Context, query string, r prometheus.Range, opts ...prometheus.Option) (model.Value, prometheus.Warnings, error) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case <-a.release:
+		return model.Matrix{}, nil, nil
+	}
+}
+
+func TestNamespaceQueryRangeWaitersGiveUp(t *testing.T) {
+	p, _ := bulkPrometheus("default", 1, nil)
+	api := &blockingAPI{release: make(chan struct{})}
+	p.api = api
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := p.namespaceQueryRange(ctx, "default", "cpu", 1, time.Minute, "pod", "container")
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the caller stops waiting when its context is done")
+
+	close(api.release)
+	result, err := p.namespaceQueryRange(context.Background(), "default", "cpu", 1, time.Minute, "pod", "container")
+	assert.NoError(t, err, "the fetch outlives the caller that started it")
+	assert.NotNil(t, result)
+}
+
+func TestParseMultiDimensionalQueryRangeCancelled(t *testing.T) {
+	p, api := bulkPrometheus("default", 7, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	end := time.Now()
+	_, err := p.parseMultiDimensionalQueryRange(ctx, "cpu", end.Add(-3*24*time.Hour), end, time.Minute, "pod", "container")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, api.queries.Load(), "no chunk is queried once the context is done")
+}
+
+func TestPodOwnerSelectorCronJob(t *testing.T) {
+	assert.Equal(t,
+		`max by (namespace, pod) (kube_pod_owner{namespace="default", owner_kind="Job", owner_name=~"cron\\.v2-[0-9]+"})`,
+		podOwnerSelector("default", PodOwnerKindCronJob, "cron.v2"))
+}
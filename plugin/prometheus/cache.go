@@ -0,0 +1,269 @@
+package prometheus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/prometheus/common/model"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCacheTTL       = 7 * 24 * time.Hour
+	DefaultCacheMaxSizeMB = 512
+
+	cacheBucketSize = 24 * time.Hour
+)
+
+type CacheConfig struct {
+	Disabled  bool          `json:"disabled"`
+	Dir       string        `json:"dir"`
+	TTL       time.Duration `json:"ttl"`
+	MaxSizeMB int64         `json:"maxSizeMB"`
+	// Identity is the cluster and Prometheus the results come from, entries are only ever served to the same identity
+	Identity string `json:"identity"`
+}
+
+func GetCacheConfig(noCache, dir, ttl, maxSizeMB *string) (*CacheConfig, error) {
+	cfg := CacheConfig{
+		TTL:       DefaultCacheTTL,
+		MaxSizeMB: DefaultCacheMaxSizeMB,
+	}
+
+	var err error
+	if noCache != nil && *noCache != "" {
+		cfg.Disabled, err = strconv.ParseBool(*noCache)
+		if err != nil {
+			return nil, fmt.Errorf("invalid no-cache value %s: %v", *noCache, err)
+		}
+		if cfg.Disabled {
+			return &cfg, nil
+		}
+	}
+	if dir != nil && *dir != "" {
+		cfg.Dir = *dir
+	} else {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find a cache directory, set one with cache-dir or disable the cache with no-cache: %v", err)
+		}
+		cfg.Dir = filepath.Join(userCacheDir, "kaytu", "plugin-kubernetes", "prometheus")
+	}
+	if ttl != nil && *ttl != "" {
+		cfg.TTL, err = time.ParseDuration(*ttl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache ttl %s: %v", *ttl, err)
+		}
+		if cfg.TTL <= 0 {
+			return nil, fmt.Errorf("invalid cache ttl %s: must be positive", *ttl)
+		}
+	}
+	if maxSizeMB != nil && *maxSizeMB != "" {
+		cfg.MaxSizeMB, err = strconv.ParseInt(*maxSizeMB, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache max size %s: %v", *maxSizeMB, err)
+		}
+		if cfg.MaxSizeMB <= 0 {
+			return nil, fmt.Errorf("invalid cache max size %s: must be positive", *maxSizeMB)
+		}
+	}
+
+	return &cfg, nil
+}
+
+// Cache keeps the parsed result of complete, past 24h buckets of range queries on disk.
+// Entries are keyed by identity, query, group by labels, step and bucket start, so a later run only has to fetch the buckets it has not seen yet plus the current one.
+type Cache struct {
+	cfg  CacheConfig
+	lock sync.Mutex
+	// size is the total size of the entries as of the last eviction plus everything written since
+	size int64
+}
+
+func NewCache(cfg CacheConfig) (*Cache, error) {
+	err := os.MkdirAll(cfg.Dir, 0o700)
+	if err != nil {
+		return nil, err
+	}
+	c := Cache{cfg: cfg}
+	c.evict()
+	return &c, nil
+}
+
+type cachedDimension struct {
+	Datapoints []PromDatapoint             `json:"datapoints,omitempty"`
+	Groups     map[string]*cachedDimension `json:"groups,omitempty"`
+}
+
+func toCachedDimension(dim PromDimension) *cachedDimension {
+	switch dim.promDimensionType() {
+	case PromDimensionTypeDatapoint:
+		return &cachedDimension{Datapoints: dim.(PromDatapoints).Values}
+	default:
+		groups := make(map[string]*cachedDimension)
+		for k, v := range dim.(PromGroupedDimension).Values {
+			groups[k] = toCachedDimension(v)
+		}
+		return &cachedDimension{Groups: groups}
+	}
+}
+
+func (d *cachedDimension) toPromDimension(depth int) PromDimension {
+	if depth == 0 {
+		return PromDatapoints{Values: d.Datapoints}
+	}
+	values := make(map[string]PromDimension)
+	for k, v := range d.Groups {
+		values[k] = v.toPromDimension(depth - 1)
+	}
+	return PromGroupedDimension{Values: values}
+}
+
+func (c *Cache) path(query string, step time.Duration, bucket time.Time, groupBys []model.LabelName) string {
+	labels := make([]string, 0, len(groupBys))
+	for _, l := range groupBys {
+		labels = append(labels, string(l))
+	}
+	h := sha256.Sum256([]byte(strings.Join([]string{c.cfg.Identity, query, strings.Join(labels, ","), step.String(), strconv.FormatInt(bucket.Unix(), 10)}, "\n")))
+	return filepath.Join(c.cfg.Dir, hex.EncodeToString(h[:])+".json")
+}
+
+func (c *Cache) Get(query string, step time.Duration, bucket time.Time, groupBys ...model.LabelName) (PromDimension, bool) {
+	path := c.path(query, step, bucket, groupBys)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if time.Since(info.ModTime()) > c.cfg.TTL {
+		os.Remove(path)
+		return nil, false
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var dim cachedDimension
+	err = json.Unmarshal(content, &dim)
+	if err != nil {
+		os.Remove(path)
+		return nil, false
+	}
+	return dim.toPromDimension(len(groupBys)), true
+}
+
+func (c *Cache) Set(query string, step time.Duration, bucket time.Time, dim PromDimension, groupBys ...model.LabelName) error {
+	content, err := json.Marshal(toCachedDimension(dim))
+	if err != nil {
+		return err
+	}
+
+	path := c.path(query, step, bucket, groupBys)
+	tmp, err := os.CreateTemp(c.cfg.Dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	c.lock.Lock()
+	c.size += int64(len(content))
+	overLimit := c.size > c.cfg.MaxSizeMB*1024*1024
+	c.lock.Unlock()
+	if overLimit {
+		c.evict()
+	}
+	return nil
+}
+
+// evict removes expired entries, then the oldest ones until the cache fits in its size limit.
+func (c *Cache) evict() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entries, err := os.ReadDir(c.cfg.Dir)
+	if err != nil {
+		return
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []file
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(c.cfg.Dir, entry.Name())
+		if time.Since(info.ModTime()) > c.cfg.TTL {
+			os.Remove(path)
+			continue
+		}
+		files = append(files, file{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+	}
+
+	maxSize := c.cfg.MaxSizeMB * 1024 * 1024
+	c.size = total
+	if total <= maxSize {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, f := range files {
+		if total <= maxSize {
+			break
+		}
+		if os.Remove(f.path) == nil {
+			total -= f.size
+		}
+	}
+	c.size = total
+}
+
+// trimDimension drops the datapoints outside [start, end], cached buckets are wider than the range that asked for them.
+func trimDimension(dim PromDimension, start, end time.Time) PromDimension {
+	switch dim.promDimensionType() {
+	case PromDimensionTypeDatapoint:
+		var values []PromDatapoint
+		for _, v := range dim.(PromDatapoints).Values {
+			if v.Timestamp.Before(start) || v.Timestamp.After(end) {
+				continue
+			}
+			values = append(values, v)
+		}
+		return PromDatapoints{Values: values}
+	default:
+		values := make(map[string]PromDimension)
+		for k, v := range dim.(PromGroupedDimension).Values {
+			values[k] = trimDimension(v, start, end)
+		}
+		return PromGroupedDimension{Values: values}
+	}
+}
@@ -0,0 +1,125 @@
+package prometheus
+
+import (
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func testCache(t *testing.T, dir, identity string, ttl time.Duration, maxSizeMB int64) *Cache {
+	c, err := NewCache(CacheConfig{Dir: dir, TTL: ttl, MaxSizeMB: maxSizeMB, Identity: identity})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestGetCacheConfig(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name      string
+		noCache   *string
+		ttl       *string
+		maxSizeMB *string
+		wantErr   bool
+	}{
+		{name: "defaults"},
+		{name: "disabled skips the rest", noCache: str("true"), ttl: str("-1h")},
+		{name: "custom values", ttl: str("1h"), maxSizeMB: str("10")},
+		{name: "zero ttl", ttl: str("0s"), wantErr: true},
+		{name: "negative ttl", ttl: str("-1h"), wantErr: true},
+		{name: "zero max size", maxSizeMB: str("0"), wantErr: true},
+		{name: "negative max size", maxSizeMB: str("-5"), wantErr: true},
+		{name: "invalid ttl", ttl: str("week"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := GetCacheConfig(tt.noCache, str(t.TempDir()), tt.ttl, tt.maxSizeMB)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if !cfg.Disabled {
+				assert.Positive(t, cfg.TTL)
+				assert.Positive(t, cfg.MaxSizeMB)
+			}
+		})
+	}
+}
+
+func TestCacheIdentityIsolation(t *testing.T) {
+	dir := t.TempDir()
+	bucket := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	groupBys := []model.LabelName{"container"}
+
+	clusterA := testCache(t, dir, "cluster-a\nmonitoring/prometheus", DefaultCacheTTL, DefaultCacheMaxSizeMB)
+	clusterB := testCache(t, dir, "cluster-b\nmonitoring/prometheus", DefaultCacheTTL, DefaultCacheMaxSizeMB)
+	otherProm := testCache(t, dir, "cluster-a\nhttp://thanos:9090", DefaultCacheTTL, DefaultCacheMaxSizeMB)
+
+	dim := grouped(map[string]PromDimension{"app": datapoints(1, 2, 3)})
+	assert.NoError(t, clusterA.Set("cpu", time.Minute, bucket, dim, groupBys...))
+
+	cached, ok := clusterA.Get("cpu", time.Minute, bucket, groupBys...)
+	assert.True(t, ok)
+	assert.Equal(t, len(datapoints(1, 2, 3).Values), len(cached.(PromGroupedDimension).Values["app"].(PromDatapoints).Values))
+
+	_, ok = clusterB.Get("cpu", time.Minute, bucket, groupBys...)
+	assert.False(t, ok, "another cluster with the same query doesn't see the entry")
+	_, ok = otherProm.Get("cpu", time.Minute, bucket, groupBys...)
+	assert.False(t, ok, "another prometheus of the same cluster doesn't see the entry")
+	_, ok = clusterA.Get("cpu", 2*time.Minute, bucket, groupBys...)
+	assert.False(t, ok, "another step doesn't see the entry")
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := testCache(t, t.TempDir(), "cluster", time.Hour, DefaultCacheMaxSizeMB)
+	bucket := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, c.Set("cpu", time.Minute, bucket, datapoints(1)))
+	_, ok := c.Get("cpu", time.Minute, bucket)
+	assert.True(t, ok)
+
+	path := c.path("cpu", time.Minute, bucket, nil)
+	expired := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, os.Chtimes(path, expired, expired))
+
+	_, ok = c.Get("cpu", time.Minute, bucket)
+	assert.False(t, ok, "entries older than the ttl are not served")
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "expired entries are removed")
+}
+
+func TestCacheSizeEviction(t *testing.T) {
+	dir := t.TempDir()
+	c := testCache(t, dir, "cluster", DefaultCacheTTL, 1)
+
+	// ~900KB per entry, so only one fits in 1MB
+	values := make([]float64, 30000)
+	for i := range values {
+		values[i] = float64(i)
+	}
+	big := datapoints(values...)
+
+	buckets := []time.Time{
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+	}
+	for i, bucket := range buckets {
+		assert.NoError(t, c.Set("cpu", time.Minute, bucket, big))
+		// make the write order visible to eviction regardless of the file system's time resolution
+		modTime := time.Now().Add(time.Duration(i-len(buckets)) * time.Minute)
+		assert.NoError(t, os.Chtimes(c.path("cpu", time.Minute, bucket, nil), modTime, modTime))
+	}
+
+	_, ok := c.Get("cpu", time.Minute, buckets[0])
+	assert.False(t, ok, "oldest entry is evicted")
+	_, ok = c.Get("cpu", time.Minute, buckets[1])
+	assert.False(t, ok)
+	_, ok = c.Get("cpu", time.Minute, buckets[2])
+	assert.True(t, ok, "newest entry is kept")
+	assert.LessOrEqual(t, c.size, int64(1024*1024))
+}
@@ -2,7 +2,9 @@ package prometheus
 
 import (
 	"context"
+	"fmt"
 	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -17,6 +19,8 @@ const (
 
 type Config struct {
 	Address string `json:"address"`
+	// Source identifies the Prometheus across runs, it is the address, or the namespace/name of the service when it was discovered and port forwarded
+	Source string `json:"source"`
 
 	AuthType PromAuthType `json:"authType"`
 
@@ -30,26 +34,65 @@ type Config struct {
 	OAuth2TokenURL     string   `json:"oAuth2TokenURL"`
 	OAuth2Scopes       []string `json:"oAuth2Scopes"`
 
+	// QueryConcurrency is the number of 24h chunks of a range query fetched in parallel
+	QueryConcurrency int `json:"queryConcurrency"`
+	// BulkNamespaceQueries fetches each metric once per namespace and shares it between all items of the namespace
+	BulkNamespaceQueries bool `json:"bulkNamespaceQueries"`
+	// BulkQueryTimeout bounds each namespace-wide fetch, and how long the items of the namespace wait for it
+	BulkQueryTimeout time.Duration `json:"bulkQueryTimeout"`
+
+	Cache *CacheConfig `json:"cache"`
+
 	reconnectWait sync.Mutex
 }
 
-func GetConfig(ctx context.Context, address, basicUsername, basicPassword, oAuth2ClientID, oAuth2ClientSecret, oAuth2TokenURL *string, oAuth2Scopes []string, client *kaytuKubernetes.Kubernetes) (*Config, error) {
+func GetConfig(ctx context.Context, address, basicUsername, basicPassword, oAuth2ClientID, oAuth2ClientSecret, oAuth2TokenURL *string, oAuth2Scopes []string, queryConcurrency, bulkNamespaceQueries, bulkQueryTimeout *string, client *kaytuKubernetes.Kubernetes) (*Config, error) {
 	cfg := Config{
-		AuthType:      PromAuthTypeNone,
-		reconnectWait: sync.Mutex{},
+		AuthType:         PromAuthTypeNone,
+		QueryConcurrency: DefaultQueryConcurrency,
+		BulkQueryTimeout: DefaultBulkQueryTimeout,
+		reconnectWait:    sync.Mutex{},
+	}
+
+	var err error
+	if queryConcurrency != nil && *queryConcurrency != "" {
+		cfg.QueryConcurrency, err = strconv.Atoi(*queryConcurrency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prometheus query concurrency %s: %v", *queryConcurrency, err)
+		}
+		if cfg.QueryConcurrency < 1 {
+			return nil, fmt.Errorf("invalid prometheus query concurrency %s: must be at least 1", *queryConcurrency)
+		}
+	}
+	if bulkNamespaceQueries != nil && *bulkNamespaceQueries != "" {
+		cfg.BulkNamespaceQueries, err = strconv.ParseBool(*bulkNamespaceQueries)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prometheus bulk namespace queries value %s: %v", *bulkNamespaceQueries, err)
+		}
+	}
+	if bulkQueryTimeout != nil && *bulkQueryTimeout != "" {
+		cfg.BulkQueryTimeout, err = time.ParseDuration(*bulkQueryTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prometheus bulk query timeout %s: %v", *bulkQueryTimeout, err)
+		}
+		if cfg.BulkQueryTimeout <= 0 {
+			return nil, fmt.Errorf("invalid prometheus bulk query timeout %s: must be positive", *bulkQueryTimeout)
+		}
 	}
 
 	if address != nil {
 		cfg.Address = *address
 	}
 
+	cfg.Source = cfg.Address
 	if cfg.Address == "" {
-		_, addr, err := client.DiscoverAndPortForwardPrometheusCompatible(ctx, &cfg.reconnectWait)
+		_, addr, source, err := client.DiscoverAndPortForwardPrometheusCompatible(ctx, &cfg.reconnectWait)
 		if err != nil {
 			return nil, err
 		}
 		time.Sleep(1 * time.Second)
 		cfg.Address = addr
+		cfg.Source = source
 	}
 
 	if basicUsername != nil && basicPassword != nil {
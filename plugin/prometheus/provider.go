@@ -0,0 +1,43 @@
+package prometheus
+
+import "context"
+
+// MetricsProvider is a source of container usage metrics. Results are keyed by container name, or by pod then container name for the OwnerPrefix variants.
+// ownerKind is the kind of the controller named by the prefix, providers that can attribute pods to their owner exactly may use it instead of the prefix.
+type MetricsProvider interface {
+	GetCpuMetricsForPod(ctx context.Context, namespace, podName string, observabilityDays int) (map[string][]PromDatapoint, error)
+	GetCpuMetricsForPodOwnerPrefix(ctx context.Context, namespace, ownerKind, podOwnerPrefix string, observabilityDays int, suffixMode PodSuffixMode) (map[string]map[string][]PromDatapoint, error)
+	GetMemoryMetricsForPod(ctx context.Context, namespace, podName string, observabilityDays int) (map[string][]PromDatapoint, error)
+	GetMemoryMetricsForPodOwnerPrefix(ctx context.Context, namespace, ownerKind, podPrefix string, observabilityDays int, suffixMode PodSuffixMode) (map[string]map[string][]PromDatapoint, error)
+	GetCpuThrottlingMetricsForPod(ctx context.Context, namespace, podName string, observabilityDays int) (map[string][]PromDatapoint, error)
+	GetCpuThrottlingMetricsForPodOwnerPrefix(ctx context.Context, namespace, ownerKind, podPrefix string, observabilityDays int, suffixMode PodSuffixMode) (map[string]map[string][]PromDatapoint, error)
+	Ping(ctx context.Context) error
+}
+
+var _ MetricsProvider = (*Prometheus)(nil)
+
+// HistoryLimitedProvider is implemented by providers that only know the usage observed since the plugin started.
+type HistoryLimitedProvider interface {
+	// InsufficientHistory returns why the usage, keyed by pod then container name, is too short to rightsize from, or "" when it is not.
+	InsufficientHistory(usage map[string]map[string][]PromDatapoint) string
+	// WaitForHistory blocks until the provider has observed long enough for the items running since it started to be rightsized.
+	WaitForHistory(ctx context.Context) error
+}
+
+// WaitForHistory blocks until a provider that only knows recent usage has observed enough of it, it returns right away for
+// the others. The metrics jobs wait on it so their items are not all skipped for a history that is still being collected.
+func WaitForHistory(ctx context.Context, provider MetricsProvider) error {
+	if p, ok := provider.(HistoryLimitedProvider); ok {
+		return p.WaitForHistory(ctx)
+	}
+	return nil
+}
+
+// InsufficientHistory returns why the usage an item got from provider is too short to rightsize from, or "" when it is not.
+// Items it returns a reason for are skipped instead of being presented as rightsized over their full history.
+func InsufficientHistory(provider MetricsProvider, usage map[string]map[string][]PromDatapoint) string {
+	if p, ok := provider.(HistoryLimitedProvider); ok {
+		return p.InsufficientHistory(usage)
+	}
+	return ""
+}
@@ -2,15 +2,18 @@ package plugin
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/kaytu-io/kaytu/pkg/plugin/proto/src/golang"
 	"github.com/kaytu-io/kaytu/pkg/plugin/sdk"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/kaytu"
-	kaytuAgent "github.com/opengovern/plugin-kubernetes-internal/plugin/kaytu-agent"
 	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/optimizer"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/preferences"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/all"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/clusters"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/cronjobs"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/daemonsets"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/deployments"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/jobs"
@@ -18,20 +21,159 @@ import (
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/pods"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/shared"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/processor/statefulsets"
-	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
 	golang2 "github.com/opengovern/plugin-kubernetes-internal/plugin/proto/src/golang"
+	"github.com/opengovern/plugin-kubernetes-internal/plugin/snapshot"
 	"github.com/opengovern/plugin-kubernetes-internal/plugin/version"
-	"golang.org/x/oauth2"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/credentials/oauth"
 	"log"
 	"math"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 )
 
+var podOverviewColumns = []*golang.ChartColumnItem{
+	{
+		Id:    "name",
+		Name:  "Name",
+		Width: 20,
+	},
+	{
+		Id:    "namespace",
+		Name:  "Namespace",
+		Width: 15,
+	},
+	{
+		Id:       "cpu_change",
+		Name:     "CPU Change",
+		Width:    40,
+		Sortable: true,
+	},
+	{
+		Id:       "memory_change",
+		Name:     "Memory Change",
+		Width:    40,
+		Sortable: true,
+	},
+	{
+		Id:       "cost",
+		Name:     "Monthly Cost",
+		Width:    10,
+		Sortable: true,
+	},
+	{
+		Id:    "x_kaytu_status",
+		Name:  "Status",
+		Width: 21,
+	},
+	{
+		Id:    "x_kaytu_right_arrow",
+		Name:  " ",
+		Width: 1,
+	},
+}
+
+var workloadOverviewColumns = []*golang.ChartColumnItem{
+	{
+		Id:    "name",
+		Name:  "Name",
+		Width: 20,
+	},
+	{
+		Id:    "namespace",
+		Name:  "Namespace",
+		Width: 15,
+	},
+	{
+		Id:       "pod_count",
+		Name:     "# Pods",
+		Width:    6,
+		Sortable: true,
+	},
+	{
+		Id:       "cpu_change",
+		Name:     "CPU Change (x Replicas)",
+		Width:    40,
+		Sortable: true,
+	},
+	{
+		Id:       "memory_change",
+		Name:     "Memory Change (x Replicas)",
+		Width:    40,
+		Sortable: true,
+	},
+	{
+		Id:       "cost",
+		Name:     "Monthly Cost",
+		Width:    10,
+		Sortable: true,
+	},
+	{
+		Id:    "x_kaytu_status",
+		Name:  "Status",
+		Width: 21,
+	},
+	{
+		Id:    "x_kaytu_right_arrow",
+		Name:  " ",
+		Width: 1,
+	},
+}
+
+var allOverviewColumns = []*golang.ChartColumnItem{
+	{
+		Id:    "name",
+		Name:  "Name",
+		Width: 20,
+	},
+	{
+		Id:    "namespace",
+		Name:  "Namespace",
+		Width: 15,
+	},
+	{
+		Id:       "kubernetes_type",
+		Name:     "Kube Type",
+		Width:    11,
+		Sortable: true,
+	},
+	{
+		Id:       "pod_count",
+		Name:     "# Pods",
+		Width:    6,
+		Sortable: true,
+	},
+	{
+		Id:       "cpu_change",
+		Name:     "CPU Change (x Replicas)",
+		Width:    40,
+		Sortable: true,
+	},
+	{
+		Id:       "memory_change",
+		Name:     "Memory Change (x Replicas)",
+		Width:    40,
+		Sortable: true,
+	},
+	{
+		Id:       "cost",
+		Name:     "Monthly Cost",
+		Width:    10,
+		Sortable: true,
+	},
+	{
+		Id:    "x_kaytu_status",
+		Name:  "Status",
+		Width: 21,
+	},
+	{
+		Id:    "x_kaytu_right_arrow",
+		Name:  " ",
+		Width: 1,
+	},
+}
+
 type KubernetesPlugin struct {
 	stream    *sdk.StreamController
 	processor processor.Processor
@@ -49,6 +191,24 @@ func (p *KubernetesPlugin) GetConfig(_ context.Context) golang.RegisterConfig {
 			Description: "Kubectl context name",
 			Required:    false,
 		},
+		{
+			Name:        "contexts",
+			Default:     "",
+			Description: "Comma separated kubectl context names to analyze together",
+			Required:    false,
+		},
+		{
+			Name:        "all-contexts",
+			Default:     "false",
+			Description: "Analyze every context of the kubeconfig together",
+			Required:    false,
+		},
+		{
+			Name:        "kubeconfig",
+			Default:     "",
+			Description: "Path to the kubeconfig file (defaults to the merged KUBECONFIG files or ~/.kube/config)",
+			Required:    false,
+		},
 		{
 			Name:        "observabilityDays",
 			Default:     "1",
@@ -73,6 +233,24 @@ func (p *KubernetesPlugin) GetConfig(_ context.Context) golang.RegisterConfig {
 			Description: "Node selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)",
 			Required:    false,
 		},
+		{
+			Name:        "workload-template-paths",
+			Default:     "",
+			Description: "Pod template paths of custom controllers, default spec.template (e.g. apps.kruise.io/v1alpha1/CloneSet=spec.template,example.com/v1/App=spec.workload.template)",
+			Required:    false,
+		},
+		{
+			Name:        "pricing-catalog",
+			Default:     "",
+			Description: "JSON or CSV file of hourly node prices by instanceType or label selector, used for nodes the cost service can't price. Instance types with vcpu and memoryGiB are also proposed as node shapes",
+			Required:    false,
+		},
+		{
+			Name:        "node-pool-min-sizes",
+			Default:     "",
+			Description: "Minimum node count of node groups and node pools, kept when removing nodes (e.g. workers=3,gpu=1)",
+			Required:    false,
+		},
 		{
 			Name:        "prom-address",
 			Default:     "",
@@ -115,6 +293,48 @@ func (p *KubernetesPlugin) GetConfig(_ context.Context) golang.RegisterConfig {
 			Description: "Prometheus OAuth2 comma seperated scopes",
 			Required:    false,
 		},
+		{
+			Name:        "prom-query-concurrency",
+			Default:     "4",
+			Description: "Number of Prometheus range query chunks fetched in parallel",
+			Required:    false,
+		},
+		{
+			Name:        "prom-bulk-namespace",
+			Default:     "false",
+			Description: "Fetch Prometheus metrics once per namespace and share them between the workloads of the namespace",
+			Required:    false,
+		},
+		{
+			Name:        "prom-bulk-timeout",
+			Default:     "10m",
+			Description: "Timeout of each namespace-wide Prometheus fetch when --prom-bulk-namespace is set",
+			Required:    false,
+		},
+		{
+			Name:        "no-cache",
+			Default:     "false",
+			Description: "Always fetch metrics from Prometheus instead of reusing the ones cached by previous runs",
+			Required:    false,
+		},
+		{
+			Name:        "cache-dir",
+			Default:     "",
+			Description: "Directory of the Prometheus metrics cache (defaults to the user cache directory)",
+			Required:    false,
+		},
+		{
+			Name:        "cache-ttl",
+			Default:     "168h",
+			Description: "How long cached Prometheus metrics are kept",
+			Required:    false,
+		},
+		{
+			Name:        "cache-max-size",
+			Default:     "512",
+			Description: "Maximum size of the Prometheus metrics cache in MB",
+			Required:    false,
+		},
 		{
 			Name:        "agent-address",
 			Default:     "",
@@ -127,13 +347,87 @@ func (p *KubernetesPlugin) GetConfig(_ context.Context) golang.RegisterConfig {
 			Description: "Disable agent",
 			Required:    false,
 		},
+		{
+			Name:        "offline",
+			Default:     "false",
+			Description: "Compute recommendations locally without connecting to the optimization backend",
+			Required:    false,
+		},
+		{
+			Name:        "optimizer-address",
+			Default:     "",
+			Description: "Optimization backend gRPC address (defaults to gapi.kaytu.io:443)",
+			Required:    false,
+		},
+		{
+			Name:        "api-url",
+			Default:     "",
+			Description: "Optimization backend REST url (defaults to https://app.kaytu.io)",
+			Required:    false,
+		},
+		{
+			Name:        "ca-file",
+			Default:     "",
+			Description: "Path to a PEM CA bundle to trust for the optimization backend",
+			Required:    false,
+		},
+		{
+			Name:        "insecure",
+			Default:     "false",
+			Description: "Skip TLS certificate verification for the optimization backend",
+			Required:    false,
+		},
+		{
+			Name:        "plaintext",
+			Default:     "false",
+			Description: "Connect to the optimization backend without TLS",
+			Required:    false,
+		},
+		{
+			Name:        "proxy",
+			Default:     "",
+			Description: "HTTP(S) proxy url used to reach the optimization backend",
+			Required:    false,
+		},
+		{
+			Name:        "request-timeout",
+			Default:     "1m",
+			Description: "Deadline of each optimization request (e.g. 30s, 2m)",
+			Required:    false,
+		},
 		{
 			Name:        "aws-cli-profile",
 			Default:     "",
 			Description: "AWS profile for authentication",
 			Required:    false,
 		},
+		{
+			Name:        "snapshot",
+			Default:     "",
+			Description: "Analyze a snapshot captured with kubernetes-snapshot instead of a live cluster",
+			Required:    false,
+		},
+		{
+			Name:        "vpa-output",
+			Default:     "",
+			Description: "Path to write VerticalPodAutoscaler manifests bounded by the recommendations to",
+			Required:    false,
+		},
+		{
+			Name:        "json-output",
+			Default:     "",
+			Description: "Path to write the recommendations to as JSON, with the same content as the csv output",
+			Required:    false,
+		},
 	}
+	snapshotFlags := append([]*golang.Flag{
+		{
+			Name:        "snapshot-output",
+			Default:     "kubernetes-snapshot.json.gz",
+			Description: "Path the snapshot is written to",
+			Required:    false,
+		},
+	}, commonFlags...)
 	return golang.RegisterConfig{
 		Name:     "kaytu-io/plugin-kubernetes",
 		Version:  version.VERSION,
@@ -174,6 +468,13 @@ func (p *KubernetesPlugin) GetConfig(_ context.Context) golang.RegisterConfig {
 				DefaultPreferences: preferences.DefaultKubernetesPreferences,
 				LoginRequired:      true,
 			},
+			{
+				Name:               "kubernetes-cronjobs",
+				Description:        "Get optimization suggestions for your Kubernetes CronJobs",
+				Flags:              commonFlags,
+				DefaultPreferences: preferences.DefaultKubernetesPreferences,
+				LoginRequired:      true,
+			},
 			{
 				Name:               "kubernetes",
 				Description:        "Get optimization suggestions for all Kubernetes resources",
@@ -181,6 +482,13 @@ func (p *KubernetesPlugin) GetConfig(_ context.Context) golang.RegisterConfig {
 				DefaultPreferences: preferences.DefaultKubernetesPreferences,
 				LoginRequired:      true,
 			},
+			{
+				Name:               "kubernetes-snapshot",
+				Description:        "Capture the cluster objects and metrics of all Kubernetes resources into a snapshot that can be analyzed later with --snapshot",
+				Flags:              snapshotFlags,
+				DefaultPreferences: preferences.DefaultKubernetesPreferences,
+				LoginRequired:      false,
+			},
 		},
 		RootCommands: []*golang.Command{
 			{
@@ -210,46 +518,7 @@ func (p *KubernetesPlugin) GetConfig(_ context.Context) golang.RegisterConfig {
 		},
 		MinKaytuVersion: "v0.9.0",
 		OverviewChart: &golang.ChartDefinition{
-			Columns: []*golang.ChartColumnItem{
-				{
-					Id:    "name",
-					Name:  "Name",
-					Width: 20,
-				},
-				{
-					Id:    "namespace",
-					Name:  "Namespace",
-					Width: 15,
-				},
-				{
-					Id:       "cpu_change",
-					Name:     "CPU Change",
-					Width:    40,
-					Sortable: true,
-				},
-				{
-					Id:       "memory_change",
-					Name:     "Memory Change",
-					Width:    40,
-					Sortable: true,
-				},
-				{
-					Id:       "cost",
-					Name:     "Monthly Cost",
-					Width:    10,
-					Sortable: true,
-				},
-				{
-					Id:    "x_kaytu_status",
-					Name:  "Status",
-					Width: 21,
-				},
-				{
-					Id:    "x_kaytu_right_arrow",
-					Name:  " ",
-					Width: 1,
-				},
-			},
+			Columns: podOverviewColumns,
 		},
 		DevicesChart: &golang.ChartDefinition{
 			Columns: []*golang.ChartColumnItem{
@@ -295,80 +564,101 @@ func getFlagOrNil(flags map[string]string, key string) *string {
 }
 
 func (p *KubernetesPlugin) StartProcess(ctx context.Context, command string, flags map[string]string, kaytuAccessToken string, preferences []*golang.PreferenceItem, jobQueue *sdk.JobQueue) error {
-	kubeContext := getFlagOrNil(flags, "context")
-	restclientConfig, kubeConfig, err := kaytuKubernetes.GetConfig(ctx, kubeContext)
-	if err != nil {
-		return err
-	}
-	kubeClient, err := kaytuKubernetes.NewKubernetes(restclientConfig, kubeConfig)
-	if err != nil {
-		return err
+	var err error
+	var replay *snapshot.Snapshot
+	if snapshotPath := getFlagOrNil(flags, "snapshot"); snapshotPath != nil && *snapshotPath != "" {
+		if command == "kubernetes-snapshot" {
+			return errors.New("a snapshot can't be captured from another snapshot")
+		}
+		replay, err = snapshot.Load(*snapshotPath)
+		if err != nil {
+			return err
+		}
 	}
 
-	identification := kubeClient.Identify()
-	conn, err := grpc.NewClient("gapi.kaytu.io:443",
-		grpc.WithTransportCredentials(credentials.NewTLS(nil)),
-		grpc.WithPerRPCCredentials(oauth.TokenSource{
-			TokenSource: oauth2.StaticTokenSource(&oauth2.Token{
-				AccessToken: kaytuAccessToken,
-			}),
-		}))
+	contexts, err := getClusterContexts(flags)
 	if err != nil {
 		return err
 	}
-	client := golang2.NewOptimizationClient(conn)
+	multiCluster := len(contexts) > 0
+	if multiCluster {
+		if replay != nil {
+			return errors.New("contexts and all-contexts can't be used with a snapshot")
+		}
+		switch command {
+		case "kubernetes-snapshot", "agent-trigger", "agent-job-status":
+			return fmt.Errorf("%s works on a single cluster, pick it with context", command)
+		}
+	}
 
-	agentAddress := getFlagOrNil(flags, "agent-address")
-	agentDisabledStr := getFlagOrNil(flags, "agent-disabled")
-	agentDisabled := false
-	if agentDisabledStr != nil {
-		agentDisabled, err = strconv.ParseBool(*agentDisabledStr)
+	offline := false
+	if offlineStr := getFlagOrNil(flags, "offline"); offlineStr != nil && *offlineStr != "" {
+		offline, err = strconv.ParseBool(*offlineStr)
 		if err != nil {
 			return err
 		}
 	}
-	kaytuAgentCfg, err := kaytuAgent.GetConfig(ctx, agentAddress, agentDisabled, kubeClient)
-	if err != nil {
-		return err
+	if command == "kubernetes-snapshot" {
+		// snapshots are usually captured where only the cluster is reachable
+		offline = true
 	}
 
-	kaytuClient, err := kaytuAgent.NewKaytuAgent(kaytuAgentCfg, agentDisabled)
+	backendCfg, err := kaytu.GetBackendConfig(
+		getFlagOrNil(flags, "optimizer-address"),
+		getFlagOrNil(flags, "api-url"),
+		getFlagOrNil(flags, "ca-file"),
+		getFlagOrNil(flags, "insecure"),
+		getFlagOrNil(flags, "plaintext"),
+		getFlagOrNil(flags, "proxy"),
+		getFlagOrNil(flags, "request-timeout"),
+	)
 	if err != nil {
 		return err
 	}
 
-	if kaytuClient.IsEnabled() {
-		err = kaytuClient.Ping(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to connect to kaytu agent on %s due to %v", kaytuAgentCfg.Address, err)
-		}
-	}
-
-	promAddress := getFlagOrNil(flags, "prom-address")
-	promUsername := getFlagOrNil(flags, "prom-username")
-	promPassword := getFlagOrNil(flags, "prom-password")
-	promClientId := getFlagOrNil(flags, "prom-client-id")
-	promClientSecret := getFlagOrNil(flags, "prom-client-secret")
-	promTokenUrl := getFlagOrNil(flags, "prom-token-url")
-	promScopesStr := getFlagOrNil(flags, "prom-scopes")
-	var promScopes []string
-	if promScopesStr != nil {
-		promScopes = strings.Split(*promScopesStr, ",")
-	}
-
-	var promClient *kaytuPrometheus.Prometheus
-	if !kaytuClient.IsEnabled() {
-		promCfg, err := kaytuPrometheus.GetConfig(ctx, promAddress, promUsername, promPassword, promClientId, promClientSecret, promTokenUrl, promScopes, kubeClient)
+	var client golang2.OptimizationClient
+	if offline {
+		client = optimizer.NewLocalClient()
+	} else {
+		dialOptions, err := backendCfg.GrpcDialOptions(kaytuAccessToken)
 		if err != nil {
 			return err
 		}
-		promClient, err = kaytuPrometheus.NewPrometheus(ctx, promCfg)
+		conn, err := grpc.NewClient(backendCfg.GrpcAddress, dialOptions...)
 		if err != nil {
 			return err
 		}
-		err = promClient.Ping(ctx)
+		client = golang2.NewOptimizationClient(conn)
+	}
+
+	var conn *clusterConnection
+	clusterConns := make(map[string]*clusterConnection)
+	if multiCluster {
+		var wg sync.WaitGroup
+		var lock sync.Mutex
+		var errs []error
+		for _, kubeContext := range contexts {
+			wg.Add(1)
+			go func(kubeContext string) {
+				defer wg.Done()
+				c, err := connectCluster(ctx, command, flags, &kubeContext, nil)
+				lock.Lock()
+				defer lock.Unlock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("cluster %s: %v", kubeContext, err))
+					return
+				}
+				clusterConns[kubeContext] = c
+			}(kubeContext)
+		}
+		wg.Wait()
+		if len(errs) > 0 {
+			return errors.Join(errs...)
+		}
+	} else {
+		conn, err = connectCluster(ctx, command, flags, getFlagOrNil(flags, "context"), replay)
 		if err != nil {
-			return fmt.Errorf("failed to connect to prometheus on %s due to %v", promCfg.Address, err)
+			return err
 		}
 	}
 
@@ -442,9 +732,27 @@ func (p *KubernetesPlugin) StartProcess(ctx context.Context, command string, fla
 
 	publishResultsReady(false)
 
-	configurations, err := kaytu.ConfigurationRequest()
-	if err != nil {
-		return err
+	var capture *snapshot.Snapshot
+	var recorder *snapshot.Recorder
+	if command == "kubernetes-snapshot" {
+		ns := ""
+		if namespace := getFlagOrNil(flags, "namespace"); namespace != nil {
+			ns = *namespace
+		}
+		capture, err = snapshot.Capture(ctx, conn.kubeClient, ns)
+		if err != nil {
+			return err
+		}
+		recorder = snapshot.NewRecorder(conn.metricsProvider)
+		conn.metricsProvider = recorder
+	}
+
+	configurations := &kaytu.Configuration{KubernetesLazyLoad: math.MaxInt}
+	if !offline {
+		configurations, err = kaytu.ConfigurationRequest(backendCfg)
+		if err != nil {
+			return err
+		}
 	}
 
 	namespace := getFlagOrNil(flags, "namespace")
@@ -458,6 +766,18 @@ func (p *KubernetesPlugin) StartProcess(ctx context.Context, command string, fla
 	if nodeSelector != nil {
 		nodeLabelSelector = *nodeSelector
 	}
+	workloadTemplatePaths, err := kaytuKubernetes.ParseTemplatePaths(getFlagOrNil(flags, "workload-template-paths"))
+	if err != nil {
+		return err
+	}
+	pricingCatalog, err := shared.LoadPricingCatalog(getFlagOrNil(flags, "pricing-catalog"))
+	if err != nil {
+		return err
+	}
+	nodePoolMinSizes, err := shared.ParseNodePoolMinSizes(getFlagOrNil(flags, "node-pool-min-sizes"))
+	if err != nil {
+		return err
+	}
 
 	for key, value := range flags {
 		if key == "output" && value != "" && value != "interactive" {
@@ -474,10 +794,6 @@ func (p *KubernetesPlugin) StartProcess(ctx context.Context, command string, fla
 	}
 
 	processorConf := shared.Configuration{
-		Identification:            identification,
-		KubernetesProvider:        kubeClient,
-		PrometheusProvider:        promClient,
-		KaytuClient:               kaytuClient,
 		KaytuAcccessToken:         kaytuAccessToken,
 		PublishOptimizationItem:   publishOptimizationItem,
 		PublishResultSummary:      publishResultSummary,
@@ -491,11 +807,23 @@ func (p *KubernetesPlugin) StartProcess(ctx context.Context, command string, fla
 		NodeSelector:              nodeLabelSelector,
 		ObservabilityDays:         observabilityDays,
 		DefaultPreferences:        preferences,
+		RequestTimeout:            backendCfg.RequestTimeout,
+		WorkloadTemplatePaths:     workloadTemplatePaths,
+		PricingCatalog:            pricingCatalog,
+		NodePoolMinSizes:          nodePoolMinSizes,
 	}
+	if conn != nil {
+		processorConf.Identification = conn.identification
+		processorConf.KubernetesProvider = conn.kubeClient
+		processorConf.MetricsProvider = conn.metricsProvider
+		processorConf.KaytuClient = conn.kaytuClient
+	}
+
+	var newProcessor func(conf shared.Configuration) processor.Processor
 
 	switch command {
 	case "agent-trigger":
-		if kaytuClient.IsEnabled() {
+		if conn.kaytuClient.IsEnabled() {
 			cmd := getFlagOrNil(flags, "command")
 			if cmd == nil {
 				p.stream.Send(&golang.PluginMessage{
@@ -506,7 +834,7 @@ func (p *KubernetesPlugin) StartProcess(ctx context.Context, command string, fla
 					},
 				})
 			}
-			err := kaytuClient.TriggerCommand(ctx, *cmd)
+			err := conn.kaytuClient.TriggerCommand(ctx, *cmd)
 			if err != nil {
 				return err
 			}
@@ -527,7 +855,7 @@ func (p *KubernetesPlugin) StartProcess(ctx context.Context, command string, fla
 		publishResultsReady(true)
 		return nil
 	case "agent-job-status":
-		if kaytuClient.IsEnabled() {
+		if conn.kaytuClient.IsEnabled() {
 			cmd := getFlagOrNil(flags, "command")
 			if cmd == nil {
 				p.stream.Send(&golang.PluginMessage{
@@ -538,7 +866,7 @@ func (p *KubernetesPlugin) StartProcess(ctx context.Context, command string, fla
 					},
 				})
 			}
-			jobs, err := kaytuClient.GetJobStatus(ctx, *cmd)
+			jobs, err := conn.kaytuClient.GetJobStatus(ctx, *cmd)
 			if err != nil {
 				p.stream.Send(&golang.PluginMessage{
 					PluginMessage: &golang.PluginMessage_Err{
@@ -569,317 +897,151 @@ func (p *KubernetesPlugin) StartProcess(ctx context.Context, command string, fla
 		publishResultsReady(true)
 		return nil
 	case "kubernetes-pods":
-		nodeProcessor := nodes.NewProcessor(processorConf)
-		p.processor = pods.NewProcessor(processorConf, pods.ProcessorModeAll, nodeProcessor)
+		if multiCluster {
+			err = p.updateOverviewChart(podOverviewColumns, multiCluster)
+			if err != nil {
+				return err
+			}
+		}
+		newProcessor = func(conf shared.Configuration) processor.Processor {
+			return pods.NewProcessor(conf, pods.ProcessorModeAll, nodes.NewProcessor(conf))
+		}
 	case "kubernetes-deployments":
-		nodeProcessor := nodes.NewProcessor(processorConf)
-		err = p.stream.Send(&golang.PluginMessage{
-			PluginMessage: &golang.PluginMessage_UpdateChart{
-				UpdateChart: &golang.UpdateChartDefinition{
-					OverviewChart: &golang.ChartDefinition{
-						Columns: []*golang.ChartColumnItem{
-							{
-								Id:    "name",
-								Name:  "Name",
-								Width: 20,
-							},
-							{
-								Id:    "namespace",
-								Name:  "Namespace",
-								Width: 15,
-							},
-							{
-								Id:       "pod_count",
-								Name:     "# Pods",
-								Width:    6,
-								Sortable: true,
-							},
-							{
-								Id:       "cpu_change",
-								Name:     "CPU Change (x Replicas)",
-								Width:    40,
-								Sortable: true,
-							},
-							{
-								Id:       "memory_change",
-								Name:     "Memory Change (x Replicas)",
-								Width:    40,
-								Sortable: true,
-							},
-							{
-								Id:       "cost",
-								Name:     "Monthly Cost",
-								Width:    10,
-								Sortable: true,
-							},
-							{
-								Id:    "x_kaytu_status",
-								Name:  "Status",
-								Width: 21,
-							},
-							{
-								Id:    "x_kaytu_right_arrow",
-								Name:  " ",
-								Width: 1,
-							},
-						},
-					},
-				},
-			},
-		})
+		err = p.updateOverviewChart(workloadOverviewColumns, multiCluster)
 		if err != nil {
 			return err
 		}
-		p.processor = deployments.NewProcessor(processorConf, nodeProcessor)
+		newProcessor = func(conf shared.Configuration) processor.Processor {
+			return deployments.NewProcessor(conf, nodes.NewProcessor(conf))
+		}
 	case "kubernetes-statefulsets":
-		nodeProcessor := nodes.NewProcessor(processorConf)
-		err = p.stream.Send(&golang.PluginMessage{
-			PluginMessage: &golang.PluginMessage_UpdateChart{
-				UpdateChart: &golang.UpdateChartDefinition{
-					OverviewChart: &golang.ChartDefinition{
-						Columns: []*golang.ChartColumnItem{
-							{
-								Id:    "name",
-								Name:  "Name",
-								Width: 20,
-							},
-							{
-								Id:    "namespace",
-								Name:  "Namespace",
-								Width: 15,
-							},
-							{
-								Id:       "pod_count",
-								Name:     "# Pods",
-								Width:    6,
-								Sortable: true,
-							},
-							{
-								Id:       "cpu_change",
-								Name:     "CPU Change (x Replicas)",
-								Width:    40,
-								Sortable: true,
-							},
-							{
-								Id:       "memory_change",
-								Name:     "Memory Change (x Replicas)",
-								Width:    40,
-								Sortable: true,
-							},
-							{
-								Id:       "cost",
-								Name:     "Monthly Cost",
-								Width:    10,
-								Sortable: true,
-							},
-							{
-								Id:    "x_kaytu_status",
-								Name:  "Status",
-								Width: 21,
-							},
-							{
-								Id:    "x_kaytu_right_arrow",
-								Name:  " ",
-								Width: 1,
-							},
-						},
-					},
-				},
-			},
-		})
+		err = p.updateOverviewChart(workloadOverviewColumns, multiCluster)
 		if err != nil {
 			return err
 		}
-		p.processor = statefulsets.NewProcessor(processorConf, nodeProcessor)
+		newProcessor = func(conf shared.Configuration) processor.Processor {
+			return statefulsets.NewProcessor(conf, nodes.NewProcessor(conf))
+		}
 	case "kubernetes-daemonsets":
-		nodeProcessor := nodes.NewProcessor(processorConf)
-		err = p.stream.Send(&golang.PluginMessage{
-			PluginMessage: &golang.PluginMessage_UpdateChart{
-				UpdateChart: &golang.UpdateChartDefinition{
-					OverviewChart: &golang.ChartDefinition{
-						Columns: []*golang.ChartColumnItem{
-							{
-								Id:    "name",
-								Name:  "Name",
-								Width: 20,
-							},
-							{
-								Id:    "namespace",
-								Name:  "Namespace",
-								Width: 15,
-							},
-							{
-								Id:       "pod_count",
-								Name:     "# Pods",
-								Width:    6,
-								Sortable: true,
-							},
-							{
-								Id:       "cpu_change",
-								Name:     "CPU Change (x Replicas)",
-								Width:    40,
-								Sortable: true,
-							},
-							{
-								Id:       "memory_change",
-								Name:     "Memory Change (x Replicas)",
-								Width:    40,
-								Sortable: true,
-							},
-							{
-								Id:       "cost",
-								Name:     "Monthly Cost",
-								Width:    10,
-								Sortable: true,
-							},
-							{
-								Id:    "x_kaytu_status",
-								Name:  "Status",
-								Width: 21,
-							},
-							{
-								Id:    "x_kaytu_right_arrow",
-								Name:  " ",
-								Width: 1,
-							},
-						},
-					},
-				},
-			},
-		})
+		err = p.updateOverviewChart(workloadOverviewColumns, multiCluster)
 		if err != nil {
 			return err
 		}
-		p.processor = daemonsets.NewProcessor(processorConf, nodeProcessor)
+		newProcessor = func(conf shared.Configuration) processor.Processor {
+			return daemonsets.NewProcessor(conf, nodes.NewProcessor(conf))
+		}
 	case "kubernetes-jobs":
-		nodeProcessor := nodes.NewProcessor(processorConf)
-		err = p.stream.Send(&golang.PluginMessage{
-			PluginMessage: &golang.PluginMessage_UpdateChart{
-				UpdateChart: &golang.UpdateChartDefinition{
-					OverviewChart: &golang.ChartDefinition{
-						Columns: []*golang.ChartColumnItem{
-							{
-								Id:    "name",
-								Name:  "Name",
-								Width: 20,
-							},
-							{
-								Id:    "namespace",
-								Name:  "Namespace",
-								Width: 15,
-							},
-							{
-								Id:       "pod_count",
-								Name:     "# Pods",
-								Width:    6,
-								Sortable: true,
-							},
-							{
-								Id:       "cpu_change",
-								Name:     "CPU Change (x Replicas)",
-								Width:    40,
-								Sortable: true,
-							},
-							{
-								Id:       "memory_change",
-								Name:     "Memory Change (x Replicas)",
-								Width:    40,
-								Sortable: true,
-							},
-							{
-								Id:       "cost",
-								Name:     "Monthly Cost",
-								Width:    10,
-								Sortable: true,
-							},
-							{
-								Id:    "x_kaytu_status",
-								Name:  "Status",
-								Width: 21,
-							},
-							{
-								Id:    "x_kaytu_right_arrow",
-								Name:  " ",
-								Width: 1,
-							},
-						},
-					},
-				},
-			},
-		})
+		err = p.updateOverviewChart(workloadOverviewColumns, multiCluster)
 		if err != nil {
 			return err
 		}
-		p.processor = jobs.NewProcessor(processorConf, nodeProcessor)
-	case "kubernetes":
-		nodeProcessor := nodes.NewProcessor(processorConf)
-		err = p.stream.Send(&golang.PluginMessage{
-			PluginMessage: &golang.PluginMessage_UpdateChart{
-				UpdateChart: &golang.UpdateChartDefinition{
-					OverviewChart: &golang.ChartDefinition{
-						Columns: []*golang.ChartColumnItem{
-							{
-								Id:    "name",
-								Name:  "Name",
-								Width: 20,
-							},
-							{
-								Id:    "namespace",
-								Name:  "Namespace",
-								Width: 15,
-							},
-							{
-								Id:       "kubernetes_type",
-								Name:     "Kube Type",
-								Width:    11,
-								Sortable: true,
-							},
-							{
-								Id:       "pod_count",
-								Name:     "# Pods",
-								Width:    6,
-								Sortable: true,
-							},
-							{
-								Id:       "cpu_change",
-								Name:     "CPU Change (x Replicas)",
-								Width:    40,
-								Sortable: true,
-							},
-							{
-								Id:       "memory_change",
-								Name:     "Memory Change (x Replicas)",
-								Width:    40,
-								Sortable: true,
-							},
-							{
-								Id:       "cost",
-								Name:     "Monthly Cost",
-								Width:    10,
-								Sortable: true,
-							},
-							{
-								Id:    "x_kaytu_status",
-								Name:  "Status",
-								Width: 21,
-							},
-							{
-								Id:    "x_kaytu_right_arrow",
-								Name:  " ",
-								Width: 1,
-							},
-						},
-					},
-				},
-			},
-		})
+		newProcessor = func(conf shared.Configuration) processor.Processor {
+			return jobs.NewProcessor(conf, nodes.NewProcessor(conf))
+		}
+	case "kubernetes-cronjobs":
+		err = p.updateOverviewChart(workloadOverviewColumns, multiCluster)
 		if err != nil {
 			return err
 		}
-		p.processor = all.NewProcessor(processorConf, nodeProcessor)
+		newProcessor = func(conf shared.Configuration) processor.Processor {
+			return cronjobs.NewProcessor(conf, nodes.NewProcessor(conf))
+		}
+	case "kubernetes", "kubernetes-snapshot":
+		err = p.updateOverviewChart(allOverviewColumns, multiCluster)
+		if err != nil {
+			return err
+		}
+		newProcessor = func(conf shared.Configuration) processor.Processor {
+			return all.NewProcessor(conf, nodes.NewProcessor(conf))
+		}
+	}
+
+	if newProcessor != nil {
+		if multiCluster {
+			clusterConfs := make(map[string]shared.Configuration)
+			for name, c := range clusterConns {
+				clusterConf := processorConf
+				clusterConf.Identification = c.identification
+				clusterConf.KubernetesProvider = c.kubeClient
+				clusterConf.MetricsProvider = c.metricsProvider
+				clusterConf.KaytuClient = c.kaytuClient
+				clusterConfs[name] = clusterConf
+			}
+			p.processor = clusters.NewProcessor(processorConf, clusterConfs, newProcessor)
+		} else {
+			p.processor = newProcessor(processorConf)
+		}
 	}
 
 	jobQueue.SetOnFinish(func(ctx context.Context) {
+		if capture != nil {
+			recorder.Fill(capture)
+			snapshotOutput := "kubernetes-snapshot.json.gz"
+			if output := getFlagOrNil(flags, "snapshot-output"); output != nil && *output != "" {
+				snapshotOutput = *output
+			}
+			if err := capture.Write(snapshotOutput); err != nil {
+				p.stream.Send(&golang.PluginMessage{
+					PluginMessage: &golang.PluginMessage_Err{
+						Err: &golang.Error{
+							Error: fmt.Sprintf("failed to write snapshot: %v", err),
+						},
+					},
+				})
+			} else {
+				publishResultSummary(&golang.ResultSummary{
+					Message: fmt.Sprintf("snapshot written to %s", snapshotOutput),
+				})
+			}
+		}
+		if vpaOutput := getFlagOrNil(flags, "vpa-output"); vpaOutput != nil && *vpaOutput != "" {
+			exporter, ok := p.processor.(processor.VPAExporter)
+			if !ok {
+				p.stream.Send(&golang.PluginMessage{
+					PluginMessage: &golang.PluginMessage_Err{
+						Err: &golang.Error{
+							Error: "vpa-output is not supported for this command",
+						},
+					},
+				})
+			} else if err := writeVerticalPodAutoscalers(*vpaOutput, exporter.ExportVerticalPodAutoscalers()); err != nil {
+				p.stream.Send(&golang.PluginMessage{
+					PluginMessage: &golang.PluginMessage_Err{
+						Err: &golang.Error{
+							Error: fmt.Sprintf("failed to write vpa manifests: %v", err),
+						},
+					},
+				})
+			} else {
+				publishResultSummary(&golang.ResultSummary{
+					Message: fmt.Sprintf("vpa manifests written to %s", *vpaOutput),
+				})
+			}
+		}
+		if jsonOutput := getFlagOrNil(flags, "json-output"); jsonOutput != nil && *jsonOutput != "" {
+			exporter, ok := p.processor.(processor.WorkloadExporter)
+			if !ok {
+				p.stream.Send(&golang.PluginMessage{
+					PluginMessage: &golang.PluginMessage_Err{
+						Err: &golang.Error{
+							Error: "json-output is not supported for this command",
+						},
+					},
+				})
+			} else if err := writeJSONExport(*jsonOutput, exporter.ExportWorkloads()); err != nil {
+				p.stream.Send(&golang.PluginMessage{
+					PluginMessage: &golang.PluginMessage_Err{
+						Err: &golang.Error{
+							Error: fmt.Sprintf("failed to write json output: %v", err),
+						},
+					},
+				})
+			} else {
+				publishResultSummary(&golang.ResultSummary{
+					Message: fmt.Sprintf("json output written to %s", *jsonOutput),
+				})
+			}
+		}
 		publishNonInteractiveExport(p.processor.ExportNonInteractive())
 		publishResultsReady(true)
 	})
@@ -887,6 +1049,29 @@ func (p *KubernetesPlugin) StartProcess(ctx context.Context, command string, fla
 	return nil
 }
 
+// updateOverviewChart replaces the overview chart columns, with a leading cluster column when several clusters are analyzed.
+func (p *KubernetesPlugin) updateOverviewChart(columns []*golang.ChartColumnItem, multiCluster bool) error {
+	if multiCluster {
+		columns = append([]*golang.ChartColumnItem{
+			{
+				Id:       "cluster",
+				Name:     "Cluster",
+				Width:    15,
+				Sortable: true,
+			},
+		}, columns...)
+	}
+	return p.stream.Send(&golang.PluginMessage{
+		PluginMessage: &golang.PluginMessage_UpdateChart{
+			UpdateChart: &golang.UpdateChartDefinition{
+				OverviewChart: &golang.ChartDefinition{
+					Columns: columns,
+				},
+			},
+		},
+	})
+}
+
 func (p *KubernetesPlugin) ReEvaluate(_ context.Context, evaluate *golang.ReEvaluate) {
 	p.processor.ReEvaluate(evaluate.Id, evaluate.Preferences)
 }
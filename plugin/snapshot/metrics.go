@@ -0,0 +1,193 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
+	"sync"
+	"time"
+)
+
+const (
+	metricCpu           = "cpu"
+	metricMemory        = "memory"
+	metricCpuThrottling = "cpu_throttling"
+)
+
+func podKey(metric, namespace, podName string) string {
+	return fmt.Sprintf("%s/%s/%s", metric, namespace, podName)
+}
+
+func ownerKey(metric, namespace, ownerKind, podOwnerPrefix string, suffixMode kaytuPrometheus.PodSuffixMode) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%d", metric, namespace, ownerKind, podOwnerPrefix, suffixMode)
+}
+
+// Recorder passes every request through to the wrapped provider and keeps the responses for the snapshot.
+type Recorder struct {
+	provider kaytuPrometheus.MetricsProvider
+
+	lock         sync.Mutex
+	podMetrics   map[string]map[string][]kaytuPrometheus.PromDatapoint
+	ownerMetrics map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint
+}
+
+func NewRecorder(provider kaytuPrometheus.MetricsProvider) *Recorder {
+	return &Recorder{
+		provider:     provider,
+		podMetrics:   make(map[string]map[string][]kaytuPrometheus.PromDatapoint),
+		ownerMetrics: make(map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint),
+	}
+}
+
+func (r *Recorder) recordPod(key string, result map[string][]kaytuPrometheus.PromDatapoint, err error) (map[string][]kaytuPrometheus.PromDatapoint, error) {
+	if err != nil {
+		return nil, err
+	}
+	r.lock.Lock()
+	r.podMetrics[key] = result
+	r.lock.Unlock()
+	return result, nil
+}
+
+func (r *Recorder) recordOwner(key string, result map[string]map[string][]kaytuPrometheus.PromDatapoint, err error) (map[string]map[string][]kaytuPrometheus.PromDatapoint, error) {
+	if err != nil {
+		return nil, err
+	}
+	r.lock.Lock()
+	r.ownerMetrics[key] = result
+	r.lock.Unlock()
+	return result, nil
+}
+
+// Fill copies the recorded responses into the snapshot.
+func (r *Recorder) Fill(snapshot *Snapshot) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	snapshot.PodMetrics = make(map[string]map[string][]kaytuPrometheus.PromDatapoint)
+	for k, v := range r.podMetrics {
+		snapshot.PodMetrics[k] = v
+	}
+	snapshot.OwnerMetrics = make(map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint)
+	for k, v := range r.ownerMetrics {
+		snapshot.OwnerMetrics[k] = v
+	}
+}
+
+func (r *Recorder) GetCpuMetricsForPod(ctx context.Context, namespace, podName string, observabilityDays int) (map[string][]kaytuPrometheus.PromDatapoint, error) {
+	result, err := r.provider.GetCpuMetricsForPod(ctx, namespace, podName, observabilityDays)
+	return r.recordPod(podKey(metricCpu, namespace, podName), result, err)
+}
+
+func (r *Recorder) GetCpuMetricsForPodOwnerPrefix(ctx context.Context, namespace, ownerKind, podOwnerPrefix string, observabilityDays int, suffixMode kaytuPrometheus.PodSuffixMode) (map[string]map[string][]kaytuPrometheus.PromDatapoint, error) {
+	result, err := r.provider.GetCpuMetricsForPodOwnerPrefix(ctx, namespace, ownerKind, podOwnerPrefix, observabilityDays, suffixMode)
+	return r.recordOwner(ownerKey(metricCpu, namespace, ownerKind, podOwnerPrefix, suffixMode), result, err)
+}
+
+func (r *Recorder) GetMemoryMetricsForPod(ctx context.Context, namespace, podName string, observabilityDays int) (map[string][]kaytuPrometheus.PromDatapoint, error) {
+	result, err := r.provider.GetMemoryMetricsForPod(ctx, namespace, podName, observabilityDays)
+	return r.recordPod(podKey(metricMemory, namespace, podName), result, err)
+}
+
+func (r *Recorder) GetMemoryMetricsForPodOwnerPrefix(ctx context.Context, namespace, ownerKind, podPrefix string, observabilityDays int, suffixMode kaytuPrometheus.PodSuffixMode) (map[string]map[string][]kaytuPrometheus.PromDatapoint, error) {
+	result, err := r.provider.GetMemoryMetricsForPodOwnerPrefix(ctx, namespace, ownerKind, podPrefix, observabilityDays, suffixMode)
+	return r.recordOwner(ownerKey(metricMemory, namespace, ownerKind, podPrefix, suffixMode), result, err)
+}
+
+func (r *Recorder) GetCpuThrottlingMetricsForPod(ctx context.Context, namespace, podName string, observabilityDays int) (map[string][]kaytuPrometheus.PromDatapoint, error) {
+	result, err := r.provider.GetCpuThrottlingMetricsForPod(ctx, namespace, podName, observabilityDays)
+	return r.recordPod(podKey(metricCpuThrottling, namespace, podName), result, err)
+}
+
+func (r *Recorder) GetCpuThrottlingMetricsForPodOwnerPrefix(ctx context.Context, namespace, ownerKind, podPrefix string, observabilityDays int, suffixMode kaytuPrometheus.PodSuffixMode) (map[string]map[string][]kaytuPrometheus.PromDatapoint, error) {
+	result, err := r.provider.GetCpuThrottlingMetricsForPodOwnerPrefix(ctx, namespace, ownerKind, podPrefix, observabilityDays, suffixMode)
+	return r.recordOwner(ownerKey(metricCpuThrottling, namespace, ownerKind, podPrefix, suffixMode), result, err)
+}
+
+func (r *Recorder) Ping(ctx context.Context) error {
+	return r.provider.Ping(ctx)
+}
+
+func (r *Recorder) InsufficientHistory(usage map[string]map[string][]kaytuPrometheus.PromDatapoint) string {
+	return kaytuPrometheus.InsufficientHistory(r.provider, usage)
+}
+
+func (r *Recorder) WaitForHistory(ctx context.Context) error {
+	return kaytuPrometheus.WaitForHistory(ctx, r.provider)
+}
+
+// Replay answers requests from the metrics recorded in a snapshot. Requests that were not recorded get no data.
+// observabilityDays is counted back from the capture time, and can't reach further than the capture did.
+type Replay struct {
+	snapshot *Snapshot
+}
+
+func NewReplay(snapshot *Snapshot) *Replay {
+	return &Replay{snapshot: snapshot}
+}
+
+func (r *Replay) since(observabilityDays int) time.Time {
+	return r.snapshot.CapturedAt.Add(time.Duration(observabilityDays) * -24 * time.Hour)
+}
+
+func filterDatapoints(datapoints []kaytuPrometheus.PromDatapoint, since time.Time) []kaytuPrometheus.PromDatapoint {
+	var result []kaytuPrometheus.PromDatapoint
+	for _, dp := range datapoints {
+		if !dp.Timestamp.Before(since) {
+			result = append(result, dp)
+		}
+	}
+	return result
+}
+
+func (r *Replay) pod(key string, observabilityDays int) map[string][]kaytuPrometheus.PromDatapoint {
+	since := r.since(observabilityDays)
+	result := make(map[string][]kaytuPrometheus.PromDatapoint)
+	for container, datapoints := range r.snapshot.PodMetrics[key] {
+		result[container] = filterDatapoints(datapoints, since)
+	}
+	return result
+}
+
+func (r *Replay) owner(key string, observabilityDays int) map[string]map[string][]kaytuPrometheus.PromDatapoint {
+	since := r.since(observabilityDays)
+	result := make(map[string]map[string][]kaytuPrometheus.PromDatapoint)
+	for pod, containers := range r.snapshot.OwnerMetrics[key] {
+		result[pod] = make(map[string][]kaytuPrometheus.PromDatapoint)
+		for container, datapoints := range containers {
+			result[pod][container] = filterDatapoints(datapoints, since)
+		}
+	}
+	return result
+}
+
+func (r *Replay) GetCpuMetricsForPod(_ context.Context, namespace, podName string, observabilityDays int) (map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return r.pod(podKey(metricCpu, namespace, podName), observabilityDays), nil
+}
+
+func (r *Replay) GetCpuMetricsForPodOwnerPrefix(_ context.Context, namespace, ownerKind, podOwnerPrefix string, observabilityDays int, suffixMode kaytuPrometheus.PodSuffixMode) (map[string]map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return r.owner(ownerKey(metricCpu, namespace, ownerKind, podOwnerPrefix, suffixMode), observabilityDays), nil
+}
+
+func (r *Replay) GetMemoryMetricsForPod(_ context.Context, namespace, podName string, observabilityDays int) (map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return r.pod(podKey(metricMemory, namespace, podName), observabilityDays), nil
+}
+
+func (r *Replay) GetMemoryMetricsForPodOwnerPrefix(_ context.Context, namespace, ownerKind, podPrefix string, observabilityDays int, suffixMode kaytuPrometheus.PodSuffixMode) (map[string]map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return r.owner(ownerKey(metricMemory, namespace, ownerKind, podPrefix, suffixMode), observabilityDays), nil
+}
+
+func (r *Replay) GetCpuThrottlingMetricsForPod(_ context.Context, namespace, podName string, observabilityDays int) (map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return r.pod(podKey(metricCpuThrottling, namespace, podName), observabilityDays), nil
+}
+
+func (r *Replay) GetCpuThrottlingMetricsForPodOwnerPrefix(_ context.Context, namespace, ownerKind, podPrefix string, observabilityDays int, suffixMode kaytuPrometheus.PodSuffixMode) (map[string]map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return r.owner(ownerKey(metricCpuThrottling, namespace, ownerKind, podPrefix, suffixMode), observabilityDays), nil
+}
+
+func (r *Replay) Ping(_ context.Context) error {
+	return nil
+}
+
+var _ kaytuPrometheus.MetricsProvider = (*Recorder)(nil)
+var _ kaytuPrometheus.MetricsProvider = (*Replay)(nil)
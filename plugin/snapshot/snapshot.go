@@ -0,0 +1,227 @@
+package snapshot
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
+	appv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"os"
+	"time"
+)
+
+const Version = 1
+
+// Snapshot is everything the processors read from a cluster and its metrics provider, so a run can be replayed without access to either.
+type Snapshot struct {
+	Version        int               `json:"version"`
+	CapturedAt     time.Time         `json:"capturedAt"`
+	Identification map[string]string `json:"identification"`
+
+	Namespaces               []corev1.Namespace                      `json:"namespaces"`
+	Nodes                    []corev1.Node                           `json:"nodes"`
+	Pods                     []corev1.Pod                            `json:"pods"`
+	Deployments              []appv1.Deployment                      `json:"deployments"`
+	ReplicaSets              []appv1.ReplicaSet                      `json:"replicaSets"`
+	StatefulSets             []appv1.StatefulSet                     `json:"statefulSets"`
+	DaemonSets               []appv1.DaemonSet                       `json:"daemonSets"`
+	Jobs                     []batchv1.Job                           `json:"jobs"`
+	CronJobs                 []batchv1.CronJob                       `json:"cronJobs"`
+	PodDisruptionBudgets     []policyv1.PodDisruptionBudget          `json:"podDisruptionBudgets"`
+	HorizontalPodAutoscalers []autoscalingv2.HorizontalPodAutoscaler `json:"horizontalPodAutoscalers"`
+	LimitRanges              []corev1.LimitRange                     `json:"limitRanges"`
+	ResourceQuotas           []corev1.ResourceQuota                  `json:"resourceQuotas"`
+	PersistentVolumeClaims   []corev1.PersistentVolumeClaim          `json:"persistentVolumeClaims"`
+	PersistentVolumes        []corev1.PersistentVolume               `json:"persistentVolumes"`
+
+	// PodMetrics and OwnerMetrics hold the metrics provider responses, keyed by the request that produced them
+	PodMetrics   map[string]map[string][]kaytuPrometheus.PromDatapoint            `json:"podMetrics"`
+	OwnerMetrics map[string]map[string]map[string][]kaytuPrometheus.PromDatapoint `json:"ownerMetrics"`
+}
+
+// Capture lists the cluster objects of the namespace, or of all namespaces when it is empty.
+// Selectors are not applied here, they are applied by the processors when the snapshot is replayed.
+func Capture(ctx context.Context, client *kaytuKubernetes.Kubernetes, namespace string) (*Snapshot, error) {
+	snapshot := Snapshot{
+		Version:        Version,
+		CapturedAt:     client.Now(),
+		Identification: client.Identify(),
+	}
+
+	var err error
+	snapshot.Namespaces, err = client.ListAllNamespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %v", err)
+	}
+	if namespace != "" {
+		var namespaces []corev1.Namespace
+		for _, ns := range snapshot.Namespaces {
+			if ns.Name == namespace {
+				namespaces = append(namespaces, ns)
+			}
+		}
+		snapshot.Namespaces = namespaces
+	}
+	snapshot.Nodes, err = client.ListAllNodes(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	snapshot.Pods, err = client.ListPodsInNamespace(ctx, namespace, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+	snapshot.Deployments, err = client.ListDeploymentsInNamespace(ctx, namespace, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %v", err)
+	}
+	snapshot.ReplicaSets, err = client.ListReplicaSetsInNamespace(ctx, namespace, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %v", err)
+	}
+	snapshot.StatefulSets, err = client.ListStatefulsetsInNamespace(ctx, namespace, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %v", err)
+	}
+	snapshot.DaemonSets, err = client.ListDaemonsetsInNamespace(ctx, namespace, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %v", err)
+	}
+	snapshot.Jobs, err = client.ListJobsInNamespace(ctx, namespace, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %v", err)
+	}
+	snapshot.CronJobs, err = client.ListCronJobsInNamespace(ctx, namespace, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cronjobs: %v", err)
+	}
+	snapshot.PodDisruptionBudgets, err = client.ListPodDisruptionBudgetsInNamespace(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod disruption budgets: %v", err)
+	}
+	snapshot.HorizontalPodAutoscalers, err = client.ListHorizontalPodAutoscalersInNamespace(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list horizontal pod autoscalers: %v", err)
+	}
+	snapshot.LimitRanges, err = client.ListLimitRangesInNamespace(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list limit ranges: %v", err)
+	}
+	snapshot.ResourceQuotas, err = client.ListResourceQuotasInNamespace(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource quotas: %v", err)
+	}
+	snapshot.PersistentVolumeClaims, err = client.ListPersistentVolumeClaimsInNamespace(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volume claims: %v", err)
+	}
+	// persistent volumes are cluster scoped, without access to them the snapshot is replayed as if no volume was zonal
+	snapshot.PersistentVolumes, err = client.ListPersistentVolumes(ctx)
+	if err != nil {
+		fmt.Println("failed to list persistent volumes due to", err)
+		snapshot.PersistentVolumes = nil
+	}
+
+	return &snapshot, nil
+}
+
+// Objects returns the cluster objects of the snapshot, to be served by kubernetes.NewKubernetesFromObjects.
+func (s *Snapshot) Objects() []runtime.Object {
+	var objects []runtime.Object
+	for i := range s.Namespaces {
+		objects = append(objects, &s.Namespaces[i])
+	}
+	for i := range s.Nodes {
+		objects = append(objects, &s.Nodes[i])
+	}
+	for i := range s.Pods {
+		objects = append(objects, &s.Pods[i])
+	}
+	for i := range s.Deployments {
+		objects = append(objects, &s.Deployments[i])
+	}
+	for i := range s.ReplicaSets {
+		objects = append(objects, &s.ReplicaSets[i])
+	}
+	for i := range s.StatefulSets {
+		objects = append(objects, &s.StatefulSets[i])
+	}
+	for i := range s.DaemonSets {
+		objects = append(objects, &s.DaemonSets[i])
+	}
+	for i := range s.Jobs {
+		objects = append(objects, &s.Jobs[i])
+	}
+	for i := range s.CronJobs {
+		objects = append(objects, &s.CronJobs[i])
+	}
+	for i := range s.PodDisruptionBudgets {
+		objects = append(objects, &s.PodDisruptionBudgets[i])
+	}
+	for i := range s.HorizontalPodAutoscalers {
+		objects = append(objects, &s.HorizontalPodAutoscalers[i])
+	}
+	for i := range s.LimitRanges {
+		objects = append(objects, &s.LimitRanges[i])
+	}
+	for i := range s.ResourceQuotas {
+		objects = append(objects, &s.ResourceQuotas[i])
+	}
+	for i := range s.PersistentVolumeClaims {
+		objects = append(objects, &s.PersistentVolumeClaims[i])
+	}
+	for i := range s.PersistentVolumes {
+		objects = append(objects, &s.PersistentVolumes[i])
+	}
+	return objects
+}
+
+// Write stores the snapshot as gzipped json.
+func (s *Snapshot) Write(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	err = json.NewEncoder(gz).Encode(s)
+	if err != nil {
+		return err
+	}
+	err = gz.Close()
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func Load(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %v", path, err)
+	}
+	defer gz.Close()
+
+	var snapshot Snapshot
+	err = json.NewDecoder(gz).Decode(&snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %v", path, err)
+	}
+	if snapshot.Version != Version {
+		return nil, fmt.Errorf("unsupported snapshot version %d, expected %d", snapshot.Version, Version)
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,121 @@
+package snapshot
+
+import (
+	"context"
+	kaytuKubernetes "github.com/opengovern/plugin-kubernetes-internal/plugin/kubernetes"
+	kaytuPrometheus "github.com/opengovern/plugin-kubernetes-internal/plugin/prometheus"
+	"github.com/stretchr/testify/assert"
+	appv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// staticProvider answers every request with the same usage of a single "app" container in pod "web-1".
+type staticProvider struct {
+	datapoints []kaytuPrometheus.PromDatapoint
+}
+
+func (p staticProvider) pod() map[string][]kaytuPrometheus.PromDatapoint {
+	return map[string][]kaytuPrometheus.PromDatapoint{"app": p.datapoints}
+}
+
+func (p staticProvider) owner() map[string]map[string][]kaytuPrometheus.PromDatapoint {
+	return map[string]map[string][]kaytuPrometheus.PromDatapoint{"web-1": p.pod()}
+}
+
+func (p staticProvider) GetCpuMetricsForPod(context.Context, string, string, int) (map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return p.pod(), nil
+}
+
+func (p staticProvider) GetCpuMetricsForPodOwnerPrefix(context.Context, string, string, string, int, kaytuPrometheus.PodSuffixMode) (map[string]map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return p.owner(), nil
+}
+
+func (p staticProvider) GetMemoryMetricsForPod(context.Context, string, string, int) (map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return p.pod(), nil
+}
+
+func (p staticProvider) GetMemoryMetricsForPodOwnerPrefix(context.Context, string, string, string, int, kaytuPrometheus.PodSuffixMode) (map[string]map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return p.owner(), nil
+}
+
+func (p staticProvider) GetCpuThrottlingMetricsForPod(context.Context, string, string, int) (map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return p.pod(), nil
+}
+
+func (p staticProvider) GetCpuThrottlingMetricsForPodOwnerPrefix(context.Context, string, string, string, int, kaytuPrometheus.PodSuffixMode) (map[string]map[string][]kaytuPrometheus.PromDatapoint, error) {
+	return p.owner(), nil
+}
+
+func (p staticProvider) Ping(context.Context) error {
+	return nil
+}
+
+func TestCaptureReplayRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	capturedAt := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	replicas := int32(2)
+	cluster := kaytuKubernetes.NewKubernetesFromObjects(capturedAt,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "other"}},
+		&appv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}, Spec: appv1.DeploymentSpec{Replicas: &replicas}},
+	)
+
+	captured, err := Capture(ctx, cluster, "default")
+	assert.NoError(t, err)
+	assert.True(t, captured.CapturedAt.Equal(capturedAt), "the capture time comes from the cluster clock")
+	assert.Len(t, captured.Namespaces, 1, "only the requested namespace is captured")
+	assert.Len(t, captured.Pods, 1)
+
+	// a week of hourly usage up to the capture time
+	var datapoints []kaytuPrometheus.PromDatapoint
+	for ts := capturedAt.Add(-7 * 24 * time.Hour); !ts.After(capturedAt); ts = ts.Add(time.Hour) {
+		datapoints = append(datapoints, kaytuPrometheus.PromDatapoint{Timestamp: ts, Value: 0.5})
+	}
+	recorder := NewRecorder(staticProvider{datapoints: datapoints})
+	_, err = recorder.GetCpuMetricsForPod(ctx, "default", "web-1", 7)
+	assert.NoError(t, err)
+	_, err = recorder.GetMemoryMetricsForPodOwnerPrefix(ctx, "default", kaytuPrometheus.PodOwnerKindReplicaSet, "web-5d8f9", 7, kaytuPrometheus.PodSuffixModeRandom)
+	assert.NoError(t, err)
+	recorder.Fill(captured)
+
+	path := filepath.Join(t.TempDir(), "snapshot.json.gz")
+	assert.NoError(t, captured.Write(path))
+	loaded, err := Load(path)
+	assert.NoError(t, err)
+	assert.True(t, loaded.CapturedAt.Equal(capturedAt))
+
+	replayed := kaytuKubernetes.NewKubernetesFromObjects(loaded.CapturedAt, loaded.Objects()...)
+	assert.True(t, replayed.Now().Equal(capturedAt), "the replay clock is stopped at the capture time")
+	pods, err := replayed.ListPodsInNamespace(ctx, "", "", false)
+	assert.NoError(t, err)
+	if assert.Len(t, pods, 1) {
+		assert.Equal(t, "web-1", pods[0].Name)
+	}
+	deployments, err := replayed.ListDeploymentsInNamespace(ctx, "default", "")
+	assert.NoError(t, err)
+	if assert.Len(t, deployments, 1) {
+		assert.Equal(t, replicas, *deployments[0].Spec.Replicas)
+	}
+
+	replay := NewReplay(loaded)
+	cpu, err := replay.GetCpuMetricsForPod(ctx, "default", "web-1", 7)
+	assert.NoError(t, err)
+	assert.Len(t, cpu["app"], len(datapoints), "the whole recorded window is replayed however long after the capture")
+	cpu, err = replay.GetCpuMetricsForPod(ctx, "default", "web-1", 1)
+	assert.NoError(t, err)
+	assert.Len(t, cpu["app"], 25, "observability days count back from the capture time")
+	memory, err := replay.GetMemoryMetricsForPodOwnerPrefix(ctx, "default", kaytuPrometheus.PodOwnerKindReplicaSet, "web-5d8f9", 7, kaytuPrometheus.PodSuffixModeRandom)
+	assert.NoError(t, err)
+	assert.Len(t, memory["web-1"]["app"], len(datapoints))
+
+	missing, err := replay.GetCpuMetricsForPod(ctx, "default", "api-1", 7)
+	assert.NoError(t, err)
+	assert.Empty(t, missing, "requests that were not recorded get no data")
+}
@@ -0,0 +1,21 @@
+package plugin
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"os"
+	"sigs.k8s.io/yaml"
+	"strings"
+)
+
+// writeVerticalPodAutoscalers writes the manifests as a multi document yaml file, ready for kubectl apply -f.
+func writeVerticalPodAutoscalers(path string, vpas []*unstructured.Unstructured) error {
+	var docs []string
+	for _, vpa := range vpas {
+		doc, err := yaml.Marshal(vpa.Object)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, string(doc))
+	}
+	return os.WriteFile(path, []byte(strings.Join(docs, "---\n")), 0644)
+}